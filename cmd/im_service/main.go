@@ -10,9 +10,11 @@ import (
 	"github.com/glide-im/glide/pkg/logger"
 	"github.com/glide-im/glide/pkg/messages"
 	"github.com/glide-im/glide/pkg/messaging"
+	"github.com/glide-im/glide/pkg/push"
 	"github.com/glide-im/glide/pkg/rpc"
 	"github.com/glide-im/glide/pkg/store"
 	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"time"
 )
 
 func main() {
@@ -36,8 +38,12 @@ func main() {
 		config.Common.SecretKey,
 	)
 
-	var cStore store.MessageStore = &message_store_db.IdleChatMessageStore{}
-	var sStore store.SubscriptionStore = &message_store_db.IdleSubscriptionStore{}
+	// the recent messages are kept in memory if the history is not stored, used to recall, edit and react
+	mStore := store.NewMemoryMessageStore(200)
+	var cStore store.MessageStore = mStore
+	var hStore store.MessageHistoryStore = mStore
+	var sStore store.SubscriptionStore = mStore
+	var oStore store.OfflineStore
 
 	if config.Common.StoreMessageHistory {
 		if config.Kafka != nil && len(config.Kafka.Address) != 0 {
@@ -45,8 +51,11 @@ func main() {
 			if err != nil {
 				panic(err)
 			}
-			cStore = producer
-			sStore = producer
+			// the messages are written to kafka only, the recent messages are kept in memory to recall, edit and react
+			recent := store.NewRecentMessageStore(mStore, producer, producer)
+			cStore = recent
+			hStore = recent
+			sStore = recent
 			logger.D("Kafka is configured, all message will push to kafka: %v", config.Kafka.Address)
 		} else {
 			dbStore, err := message_store_db.New(config.MySql)
@@ -54,22 +63,70 @@ func main() {
 				panic(err)
 			}
 			cStore = dbStore
-			sStore = &message_store_db.SubscriptionMessageStore{}
+			hStore = dbStore
+			oStore = dbStore
+			sStore = message_store_db.NewSubscriptionMessageStore(dbStore)
 		}
 
 	} else {
-		logger.D("Common.StoreMessageHistory is false, message history will not be stored")
+		logger.D("Common.StoreMessageHistory is false, only the recent messages are kept in memory")
 	}
 
+	if !config.Common.StoreOfflineMessage {
+		oStore = nil
+	} else if oStore == nil {
+		oStore = store.NewRedisOfflineStore(db.Redis, time.Hour*24*2)
+	}
+
+	cvStore := store.NewRedisConversationStore(db.Redis)
+	pStore := store.NewRedisPresenceStore(db.Redis)
+
+	var pushBridge push.Bridge
+	if config.Push != nil && config.Push.WebhookUrl != "" {
+		pushBridge = push.NewWebhookBridge(config.Push.WebhookUrl, config.Push.Secret, time.Second*5)
+	}
+
+	ackStore, ok := sStore.(store.ChannelAckStore)
+	if !ok {
+		ackStore = store.NewMemoryChannelAckStore()
+	}
+
+	var dmPolicy *messaging.DMPolicyHandler
+	var relations *messaging.RelationCache
+	if config.Common.DMPolicy {
+		relations = messaging.NewRelationCache()
+		dmPolicy = messaging.NewDMPolicyHandler(relations)
+	}
+
+	broadcaster := gate.NewBroadcaster(gateway, gate.DefaultBroadcastRate)
+
 	handler, err := messaging.NewHandlerWithOptions(gateway, &messaging.MessageHandlerOptions{
 		MessageStore:           cStore,
+		HistoryStore:           hStore,
+		OfflineStore:           oStore,
+		ConversationStore:      cvStore,
+		PresenceStore:          pStore,
+		ScheduledStore:         store.NewRedisScheduledMessageStore(db.Redis),
+		BlockStore:             store.NewRedisBlockStore(db.Redis),
+		ReactionStore:          store.NewRedisReactionStore(db.Redis),
+		ExpiryStore:            store.NewRedisMessageExpiryStore(db.Redis),
+		ChatSequenceStore:      store.NewRedisChatSequenceStore(db.Redis, 1),
+		ChannelAckStore:        ackStore,
+		PushBridge:             pushBridge,
+		PushSettingStore:       store.NewRedisPushSettingStore(db.Redis),
+		DedupCache:             store.NewRedisDedupCache(db.Redis, time.Minute*10),
+		DMPolicy:               dmPolicy,
+		ContentRegistry:        messages.NewDefaultContentRegistry(!config.Common.RejectUnknownContentType),
+		ReadCursorStore:        store.NewRedisReadCursorStore(db.Redis),
+		ReadReceiptToSender:    true,
+		Broadcaster:            broadcaster,
+		Admins:                 config.Common.Admins,
 		DontInitDefaultHandler: false,
 		NotifyOnErr:            true,
 	})
 	if err != nil {
 		panic(err)
 	}
-	messaging.StoreOfflineMessage = config.Common.StoreOfflineMessage
 
 	subscription := subscription_impl.NewSubscription(sStore, sStore)
 	subscription.SetGateInterface(gateway)
@@ -105,7 +162,17 @@ func main() {
 		Port:    config.IMService.Port,
 	}
 	logger.D("rpc %s listening on %s %s:%d", rpcOpts.Name, rpcOpts.Network, rpcOpts.Addr, rpcOpts.Port)
-	err = server.RunRpcService(&rpcOpts, gateway, subscription)
+	services := server.ServiceOptions{
+		Conversation: cvStore,
+		Presence:     pStore,
+		Scheduler:    handler.Scheduler(),
+		Broadcaster:  broadcaster,
+		Blocks:       handler.Blocks(),
+	}
+	if relations != nil {
+		services.Relations = relations
+	}
+	err = server.RunRpcServiceWithOptions(&rpcOpts, gateway, subscription, &services)
 	if err != nil {
 		panic(err)
 	}
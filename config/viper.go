@@ -9,12 +9,19 @@ var (
 	IMService *IMRpcServerConf
 	Redis     *RedisConf
 	Kafka     *KafkaConf
+	Push      *PushConf
 )
 
 type CommonConf struct {
 	StoreOfflineMessage bool
 	StoreMessageHistory bool
 	SecretKey           string
+	// DMPolicy true express only the users with relationship can send direct messages to each other
+	DMPolicy bool
+	// RejectUnknownContentType true express reject the message of the type not registered in the content registry
+	RejectUnknownContentType bool
+	// Admins the uids allowed to call the admin apis, such as broadcasting the announcement
+	Admins []string
 }
 
 type WsServerConf struct {
@@ -41,6 +48,11 @@ type KafkaConf struct {
 	Address []string
 }
 
+type PushConf struct {
+	WebhookUrl string
+	Secret     string
+}
+
 type MySqlConf struct {
 	Host     string
 	Port     int
@@ -78,6 +90,7 @@ func MustLoad() {
 		IMRpcServer *IMRpcServerConf
 		CommonConf  *CommonConf
 		Kafka       *KafkaConf
+		Push        *PushConf
 	}{}
 
 	err = viper.Unmarshal(&c)
@@ -90,6 +103,7 @@ func MustLoad() {
 	Common = c.CommonConf
 	Redis = c.Redis
 	Kafka = c.Kafka
+	Push = c.Push
 
 	if Common == nil {
 		panic("CommonConf is nil")
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/im_service/server"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+var _ server.BlockRpcServer = &blockRpcClient{}
+
+type blockRpcClient struct {
+	cli *rpc.BaseClient
+}
+
+func (c *blockRpcClient) UpdateBlockList(ctx context.Context, request *proto.BlockRequest, response *proto.BlockListResponse) error {
+	return c.cli.Call(ctx, "UpdateBlockList", request, response)
+}
+
+func (c *blockRpcClient) GetBlockList(ctx context.Context, request *proto.GetBlockListRequest, response *proto.BlockListResponse) error {
+	return c.cli.Call(ctx, "GetBlockList", request, response)
+}
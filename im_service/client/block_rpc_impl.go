@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+type BlockRpcImpl struct {
+	rpc *blockRpcClient
+}
+
+func NewBlockRpcImplWithClient(client *rpc.BaseClient) *BlockRpcImpl {
+	return &BlockRpcImpl{
+		rpc: &blockRpcClient{
+			cli: client,
+		},
+	}
+}
+
+func NewBlockRpcImpl(opts *rpc.ClientOptions) (*BlockRpcImpl, error) {
+	cli, err := rpc.NewBaseClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlockRpcImplWithClient(cli), nil
+}
+
+// Block adds uids to the block list of uid, returns the block list after changed.
+func (c *BlockRpcImpl) Block(uid string, uids []string) ([]string, error) {
+	return c.update(uid, uids, true)
+}
+
+// Unblock removes uids from the block list of uid, returns the block list after changed.
+func (c *BlockRpcImpl) Unblock(uid string, uids []string) ([]string, error) {
+	return c.update(uid, uids, false)
+}
+
+func (c *BlockRpcImpl) update(uid string, uids []string, blocked bool) ([]string, error) {
+	request := proto.BlockRequest{
+		Uid:     uid,
+		Uids:    uids,
+		Blocked: blocked,
+	}
+	response := proto.BlockListResponse{}
+	err := c.rpc.UpdateBlockList(context.TODO(), &request, &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if err = getResponseError(response.GetResponse()); err != nil {
+		return nil, err
+	}
+	return response.GetUids(), nil
+}
+
+// GetBlockList returns the block list of uid.
+func (c *BlockRpcImpl) GetBlockList(uid string) ([]string, error) {
+	request := proto.GetBlockListRequest{
+		Uid: uid,
+	}
+	response := proto.BlockListResponse{}
+	err := c.rpc.GetBlockList(context.TODO(), &request, &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if err = getResponseError(response.GetResponse()); err != nil {
+		return nil, err
+	}
+	return response.GetUids(), nil
+}
+
+func (c *BlockRpcImpl) Close() error {
+	return c.rpc.cli.Close()
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/im_service/server"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+var _ server.BroadcastRpcServer = &broadcastRpcClient{}
+
+type broadcastRpcClient struct {
+	cli *rpc.BaseClient
+}
+
+func (c *broadcastRpcClient) Broadcast(ctx context.Context, request *proto.BroadcastRequest, response *proto.BroadcastResponse) error {
+	return c.cli.Call(ctx, "Broadcast", request, response)
+}
+
+func (c *broadcastRpcClient) GetBroadcastProgress(ctx context.Context, request *proto.GetBroadcastProgressRequest, response *proto.BroadcastResponse) error {
+	return c.cli.Call(ctx, "GetBroadcastProgress", request, response)
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+type BroadcastRpcImpl struct {
+	rpc *broadcastRpcClient
+}
+
+func NewBroadcastRpcImplWithClient(client *rpc.BaseClient) *BroadcastRpcImpl {
+	return &BroadcastRpcImpl{
+		rpc: &broadcastRpcClient{
+			cli: client,
+		},
+	}
+}
+
+func NewBroadcastRpcImpl(opts *rpc.ClientOptions) (*BroadcastRpcImpl, error) {
+	cli, err := rpc.NewBaseClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewBroadcastRpcImplWithClient(cli), nil
+}
+
+func broadcastProgressFromProto(p *proto.BroadcastProgress) *gate.BroadcastProgress {
+	if p == nil {
+		return nil
+	}
+	return &gate.BroadcastProgress{
+		ID:        p.Id,
+		Total:     int(p.Total),
+		Sent:      int(p.Sent),
+		Delivered: int(p.Delivered),
+		Failed:    int(p.Failed),
+		Done:      p.Done,
+		StartAt:   p.StartAt,
+		FinishAt:  p.FinishAt,
+	}
+}
+
+func broadcastRequest(a *messages.Announcement, filter *gate.BroadcastFilter) *proto.BroadcastRequest {
+	request := proto.BroadcastRequest{
+		Title:   a.Title,
+		Content: a.Content,
+	}
+	if filter != nil {
+		request.Devices = filter.Devices
+		request.Versions = filter.Versions
+		request.Uids = filter.Uids
+	}
+	return &request
+}
+
+// Broadcast pushes the announcement to the clients selected by filter, nil filter selects all clients. Only the clients
+// of the node selected to handle the call are reached, the returned progress is kept by that node, use BroadcastAll to
+// reach the clients of all nodes.
+func (c *BroadcastRpcImpl) Broadcast(a *messages.Announcement, filter *gate.BroadcastFilter) (*gate.BroadcastProgress, error) {
+	response := proto.BroadcastResponse{}
+	err := c.rpc.Broadcast(context.TODO(), broadcastRequest(a, filter), &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if err = getResponseError(response.GetResponse()); err != nil {
+		return nil, err
+	}
+	return broadcastProgressFromProto(response.GetProgress()), nil
+}
+
+// BroadcastAll pushes the announcement to the clients selected by filter of all nodes, returns error if any node failed.
+// The broadcast of each node is independent, the progress is not returned.
+func (c *BroadcastRpcImpl) BroadcastAll(a *messages.Announcement, filter *gate.BroadcastFilter) error {
+	response := proto.BroadcastResponse{}
+	err := c.rpc.cli.Broadcast("Broadcast", broadcastRequest(a, filter), &response)
+	if err != nil {
+		return errors.New(errRpcInvocation + err.Error())
+	}
+	return getResponseError(response.GetResponse())
+}
+
+// GetBroadcastProgress returns the progress of the broadcast started by Broadcast, the call must be handled by the same
+// node as the broadcast.
+func (c *BroadcastRpcImpl) GetBroadcastProgress(id int64) (*gate.BroadcastProgress, error) {
+	request := proto.GetBroadcastProgressRequest{
+		Id: id,
+	}
+	response := proto.BroadcastResponse{}
+	err := c.rpc.GetBroadcastProgress(context.TODO(), &request, &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if err = getResponseError(response.GetResponse()); err != nil {
+		return nil, err
+	}
+	return broadcastProgressFromProto(response.GetProgress()), nil
+}
+
+func (c *BroadcastRpcImpl) Close() error {
+	return c.rpc.cli.Close()
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/im_service/server"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+var _ server.ConversationRpcServer = &conversationRpcClient{}
+
+type conversationRpcClient struct {
+	cli *rpc.BaseClient
+}
+
+func (c *conversationRpcClient) GetConversations(ctx context.Context, request *proto.GetConversationsRequest, response *proto.GetConversationsResponse) error {
+	return c.cli.Call(ctx, "GetConversations", request, response)
+}
+
+func (c *conversationRpcClient) UpdateConversation(ctx context.Context, request *proto.UpdateConversationRequest, response *proto.Response) error {
+	return c.cli.Call(ctx, "UpdateConversation", request, response)
+}
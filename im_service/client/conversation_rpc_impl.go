@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+type ConversationRpcImpl struct {
+	rpc *conversationRpcClient
+}
+
+func NewConversationRpcImplWithClient(client *rpc.BaseClient) *ConversationRpcImpl {
+	return &ConversationRpcImpl{
+		rpc: &conversationRpcClient{
+			cli: client,
+		},
+	}
+}
+
+func NewConversationRpcImpl(opts *rpc.ClientOptions) (*ConversationRpcImpl, error) {
+	cli, err := rpc.NewBaseClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewConversationRpcImplWithClient(cli), nil
+}
+
+// GetConversations returns the conversation list of uid, the pinned conversations first.
+func (c *ConversationRpcImpl) GetConversations(uid string, offset int, limit int) ([]*messages.Conversation, error) {
+	request := proto.GetConversationsRequest{
+		Uid:    uid,
+		Offset: int32(offset),
+		Limit:  int32(limit),
+	}
+	response := proto.GetConversationsResponse{}
+	err := c.rpc.GetConversations(context.TODO(), &request, &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if response.GetResponse() != nil {
+		if err = getResponseError(response.GetResponse()); err != nil {
+			return nil, err
+		}
+	}
+	var result []*messages.Conversation
+	for _, pc := range response.GetConversations() {
+		result = append(result, &messages.Conversation{
+			ChatType: pc.ChatType,
+			ID:       pc.Id,
+			LastMid:  pc.LastMid,
+			LastFrom: pc.LastFrom,
+			LastType: pc.LastType,
+			Preview:  pc.Preview,
+			ActiveAt: pc.ActiveAt,
+			Unread:   pc.Unread,
+			Pinned:   pc.Pinned,
+			Muted:    pc.Muted,
+		})
+	}
+	return result, nil
+}
+
+// UpdateConversation sets the pinned and muted flags of the conversation of uid, the nil flag is kept, and clears the
+// unread count if clearUnread.
+func (c *ConversationRpcImpl) UpdateConversation(uid string, chatType int32, id string, pinned *bool, muted *bool, clearUnread bool) error {
+	request := proto.UpdateConversationRequest{
+		Uid:         uid,
+		ChatType:    chatType,
+		Id:          id,
+		Pinned:      pinned,
+		Muted:       muted,
+		ClearUnread: clearUnread,
+	}
+	response := proto.Response{}
+	err := c.rpc.UpdateConversation(context.TODO(), &request, &response)
+	if err != nil {
+		return errors.New(errRpcInvocation + err.Error())
+	}
+	return getResponseError(&response)
+}
+
+func (c *ConversationRpcImpl) Close() error {
+	return c.rpc.cli.Close()
+}
@@ -3,13 +3,20 @@ package client
 import (
 	"github.com/glide-im/glide/pkg/gate"
 	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/messaging"
 	"github.com/glide-im/glide/pkg/rpc"
 	"github.com/glide-im/glide/pkg/subscription"
 )
 
 type Client struct {
-	sub  *SubscriptionRpcImpl
-	gate *GatewayRpcImpl
+	sub          *SubscriptionRpcImpl
+	gate         *GatewayRpcImpl
+	conversation *ConversationRpcImpl
+	presence     *PresenceRpcImpl
+	schedule     *ScheduleRpcImpl
+	broadcast    *BroadcastRpcImpl
+	block        *BlockRpcImpl
+	relation     *RelationRpcImpl
 }
 
 func NewClient(opts *rpc.ClientOptions) (*Client, error) {
@@ -18,8 +25,14 @@ func NewClient(opts *rpc.ClientOptions) (*Client, error) {
 		return nil, err
 	}
 	c := Client{
-		sub:  NewSubscriptionRpcImplWithClient(cli),
-		gate: NewGatewayRpcImplWithClient(cli),
+		sub:          NewSubscriptionRpcImplWithClient(cli),
+		gate:         NewGatewayRpcImplWithClient(cli),
+		conversation: NewConversationRpcImplWithClient(cli),
+		presence:     NewPresenceRpcImplWithClient(cli),
+		schedule:     NewScheduleRpcImplWithClient(cli),
+		broadcast:    NewBroadcastRpcImplWithClient(cli),
+		block:        NewBlockRpcImplWithClient(cli),
+		relation:     NewRelationRpcImplWithClient(cli),
 	}
 	return &c, nil
 }
@@ -63,3 +76,63 @@ func (c *Client) UpdateChannel(ch subscription.ChanID, update *subscription.Chan
 func (c *Client) Publish(ch subscription.ChanID, msg subscription.Message) error {
 	return c.sub.Publish(ch, msg)
 }
+
+func (c *Client) GetConversations(uid string, offset int, limit int) ([]*messages.Conversation, error) {
+	return c.conversation.GetConversations(uid, offset, limit)
+}
+
+func (c *Client) UpdateConversation(uid string, chatType int32, id string, pinned *bool, muted *bool, clearUnread bool) error {
+	return c.conversation.UpdateConversation(uid, chatType, id, pinned, muted, clearUnread)
+}
+
+func (c *Client) GetPresence(viewer string, uids []string) ([]*messages.Presence, error) {
+	return c.presence.GetPresence(viewer, uids)
+}
+
+func (c *Client) ScheduleMessage(m *messages.ScheduledMessage) (*messages.ScheduledMessage, error) {
+	return c.schedule.ScheduleMessage(m)
+}
+
+func (c *Client) EditScheduledMessage(uid string, edit *messages.ScheduledEdit) (*messages.ScheduledMessage, error) {
+	return c.schedule.EditScheduledMessage(uid, edit)
+}
+
+func (c *Client) CancelScheduledMessage(uid string, id int64) error {
+	return c.schedule.CancelScheduledMessage(uid, id)
+}
+
+func (c *Client) Broadcast(a *messages.Announcement, filter *gate.BroadcastFilter) (*gate.BroadcastProgress, error) {
+	return c.broadcast.Broadcast(a, filter)
+}
+
+func (c *Client) BroadcastAll(a *messages.Announcement, filter *gate.BroadcastFilter) error {
+	return c.broadcast.BroadcastAll(a, filter)
+}
+
+func (c *Client) GetBroadcastProgress(id int64) (*gate.BroadcastProgress, error) {
+	return c.broadcast.GetBroadcastProgress(id)
+}
+
+func (c *Client) Block(uid string, uids []string) ([]string, error) {
+	return c.block.Block(uid, uids)
+}
+
+func (c *Client) Unblock(uid string, uids []string) ([]string, error) {
+	return c.block.Unblock(uid, uids)
+}
+
+func (c *Client) GetBlockList(uid string) ([]string, error) {
+	return c.block.GetBlockList(uid)
+}
+
+func (c *Client) AddRelations(kind messaging.RelationKind, uid string, targets ...string) error {
+	return c.relation.AddRelations(kind, uid, targets...)
+}
+
+func (c *Client) RemoveRelations(kind messaging.RelationKind, uid string, targets ...string) error {
+	return c.relation.RemoveRelations(kind, uid, targets...)
+}
+
+func (c *Client) SetRelations(kind messaging.RelationKind, uid string, targets ...string) error {
+	return c.relation.SetRelations(kind, uid, targets...)
+}
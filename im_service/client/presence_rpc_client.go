@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/im_service/server"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+var _ server.PresenceRpcServer = &presenceRpcClient{}
+
+type presenceRpcClient struct {
+	cli *rpc.BaseClient
+}
+
+func (c *presenceRpcClient) GetPresence(ctx context.Context, request *proto.GetPresenceRequest, response *proto.GetPresenceResponse) error {
+	return c.cli.Call(ctx, "GetPresence", request, response)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+type PresenceRpcImpl struct {
+	rpc *presenceRpcClient
+}
+
+func NewPresenceRpcImplWithClient(client *rpc.BaseClient) *PresenceRpcImpl {
+	return &PresenceRpcImpl{
+		rpc: &presenceRpcClient{
+			cli: client,
+		},
+	}
+}
+
+func NewPresenceRpcImpl(opts *rpc.ClientOptions) (*PresenceRpcImpl, error) {
+	cli, err := rpc.NewBaseClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewPresenceRpcImplWithClient(cli), nil
+}
+
+// GetPresence returns the presence of uids seen by viewer, the presence hidden to viewer is offline, the privacy is not
+// checked when the viewer is empty.
+func (c *PresenceRpcImpl) GetPresence(viewer string, uids []string) ([]*messages.Presence, error) {
+	request := proto.GetPresenceRequest{
+		Viewer: viewer,
+		Uids:   uids,
+	}
+	response := proto.GetPresenceResponse{}
+	err := c.rpc.GetPresence(context.TODO(), &request, &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if response.GetResponse() != nil {
+		if err = getResponseError(response.GetResponse()); err != nil {
+			return nil, err
+		}
+	}
+	var result []*messages.Presence
+	for _, p := range response.GetPresences() {
+		result = append(result, &messages.Presence{
+			Uid:        p.Uid,
+			State:      p.State,
+			Online:     p.State != messages.PresenceOffline,
+			LastSeen:   p.LastSeen,
+			StatusText: p.StatusText,
+		})
+	}
+	return result, nil
+}
+
+func (c *PresenceRpcImpl) Close() error {
+	return c.rpc.cli.Close()
+}
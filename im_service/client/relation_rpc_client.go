@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/im_service/server"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+var _ server.RelationRpcServer = &relationRpcClient{}
+
+type relationRpcClient struct {
+	cli *rpc.BaseClient
+}
+
+func (c *relationRpcClient) UpdateRelation(ctx context.Context, request *proto.UpdateRelationRequest, response *proto.Response) error {
+	return c.cli.Call(ctx, "UpdateRelation", request, response)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/pkg/messaging"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+type RelationRpcImpl struct {
+	rpc *relationRpcClient
+}
+
+func NewRelationRpcImplWithClient(client *rpc.BaseClient) *RelationRpcImpl {
+	return &RelationRpcImpl{
+		rpc: &relationRpcClient{
+			cli: client,
+		},
+	}
+}
+
+func NewRelationRpcImpl(opts *rpc.ClientOptions) (*RelationRpcImpl, error) {
+	cli, err := rpc.NewBaseClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewRelationRpcImplWithClient(cli), nil
+}
+
+// AddRelations adds the targets to the relations of uid, the contacts are added to both sides.
+func (c *RelationRpcImpl) AddRelations(kind messaging.RelationKind, uid string, targets ...string) error {
+	return c.update(kind, proto.UpdateRelationRequest_Add, uid, targets)
+}
+
+// RemoveRelations removes the targets from the relations of uid, the contacts are removed from both sides.
+func (c *RelationRpcImpl) RemoveRelations(kind messaging.RelationKind, uid string, targets ...string) error {
+	return c.update(kind, proto.UpdateRelationRequest_Remove, uid, targets)
+}
+
+// SetRelations replaces the relations of uid with targets.
+func (c *RelationRpcImpl) SetRelations(kind messaging.RelationKind, uid string, targets ...string) error {
+	return c.update(kind, proto.UpdateRelationRequest_Replace, uid, targets)
+}
+
+func (c *RelationRpcImpl) update(kind messaging.RelationKind, op proto.UpdateRelationRequest_Op, uid string, targets []string) error {
+	request := proto.UpdateRelationRequest{
+		Kind:    proto.UpdateRelationRequest_Kind(kind),
+		Op:      op,
+		Uid:     uid,
+		Targets: targets,
+	}
+	response := proto.Response{}
+	err := c.rpc.UpdateRelation(context.TODO(), &request, &response)
+	if err != nil {
+		return errors.New(errRpcInvocation + err.Error())
+	}
+	return getResponseError(&response)
+}
+
+func (c *RelationRpcImpl) Close() error {
+	return c.rpc.cli.Close()
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/im_service/server"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+var _ server.ScheduleRpcServer = &scheduleRpcClient{}
+
+type scheduleRpcClient struct {
+	cli *rpc.BaseClient
+}
+
+func (c *scheduleRpcClient) ScheduleMessage(ctx context.Context, request *proto.ScheduledMessage, response *proto.ScheduleMessageResponse) error {
+	return c.cli.Call(ctx, "ScheduleMessage", request, response)
+}
+
+func (c *scheduleRpcClient) EditScheduledMessage(ctx context.Context, request *proto.EditScheduledRequest, response *proto.ScheduleMessageResponse) error {
+	return c.cli.Call(ctx, "EditScheduledMessage", request, response)
+}
+
+func (c *scheduleRpcClient) CancelScheduledMessage(ctx context.Context, request *proto.CancelScheduledRequest, response *proto.Response) error {
+	return c.cli.Call(ctx, "CancelScheduledMessage", request, response)
+}
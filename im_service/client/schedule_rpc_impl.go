@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/rpc"
+)
+
+type ScheduleRpcImpl struct {
+	rpc *scheduleRpcClient
+}
+
+func NewScheduleRpcImplWithClient(client *rpc.BaseClient) *ScheduleRpcImpl {
+	return &ScheduleRpcImpl{
+		rpc: &scheduleRpcClient{
+			cli: client,
+		},
+	}
+}
+
+func NewScheduleRpcImpl(opts *rpc.ClientOptions) (*ScheduleRpcImpl, error) {
+	cli, err := rpc.NewBaseClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewScheduleRpcImplWithClient(cli), nil
+}
+
+func scheduledFromProto(p *proto.ScheduledMessage) *messages.ScheduledMessage {
+	if p == nil {
+		return nil
+	}
+	return &messages.ScheduledMessage{
+		ID:       p.Id,
+		ChatType: p.ChatType,
+		Message: &messages.ChatMessage{
+			CliMid:  p.CliMid,
+			From:    p.From,
+			To:      p.To,
+			Type:    p.Type,
+			Content: p.Content,
+		},
+		DeliverAt: p.DeliverAt,
+		CreateAt:  p.CreateAt,
+	}
+}
+
+// ScheduleMessage schedules the m.Message from m.Message.From delivers at m.DeliverAt.
+func (c *ScheduleRpcImpl) ScheduleMessage(m *messages.ScheduledMessage) (*messages.ScheduledMessage, error) {
+	if m.Message == nil {
+		return nil, errors.New("scheduled message is nil")
+	}
+	request := proto.ScheduledMessage{
+		ChatType:  m.ChatType,
+		From:      m.Message.From,
+		To:        m.Message.To,
+		Type:      m.Message.Type,
+		Content:   m.Message.Content,
+		CliMid:    m.Message.CliMid,
+		DeliverAt: m.DeliverAt,
+	}
+	response := proto.ScheduleMessageResponse{}
+	err := c.rpc.ScheduleMessage(context.TODO(), &request, &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if err = getResponseError(response.GetResponse()); err != nil {
+		return nil, err
+	}
+	return scheduledFromProto(response.GetMessage()), nil
+}
+
+// EditScheduledMessage changes the content or the deliver time of the undelivered message of uid.
+func (c *ScheduleRpcImpl) EditScheduledMessage(uid string, edit *messages.ScheduledEdit) (*messages.ScheduledMessage, error) {
+	request := proto.EditScheduledRequest{
+		Uid:       uid,
+		Id:        edit.ID,
+		Content:   edit.Content,
+		DeliverAt: edit.DeliverAt,
+	}
+	response := proto.ScheduleMessageResponse{}
+	err := c.rpc.EditScheduledMessage(context.TODO(), &request, &response)
+	if err != nil {
+		return nil, errors.New(errRpcInvocation + err.Error())
+	}
+	if err = getResponseError(response.GetResponse()); err != nil {
+		return nil, err
+	}
+	return scheduledFromProto(response.GetMessage()), nil
+}
+
+// CancelScheduledMessage removes the undelivered message of uid.
+func (c *ScheduleRpcImpl) CancelScheduledMessage(uid string, id int64) error {
+	request := proto.CancelScheduledRequest{
+		Uid: uid,
+		Id:  id,
+	}
+	response := proto.Response{}
+	err := c.rpc.CancelScheduledMessage(context.TODO(), &request, &response)
+	if err != nil {
+		return errors.New(errRpcInvocation + err.Error())
+	}
+	return getResponseError(&response)
+}
+
+func (c *ScheduleRpcImpl) Close() error {
+	return c.rpc.cli.Close()
+}
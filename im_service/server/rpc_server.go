@@ -7,9 +7,12 @@ import (
 	"github.com/glide-im/glide/im_service/proto"
 	"github.com/glide-im/glide/pkg/gate"
 	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/messaging"
 	"github.com/glide-im/glide/pkg/rpc"
+	"github.com/glide-im/glide/pkg/store"
 	"github.com/glide-im/glide/pkg/subscription"
 	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"time"
 )
 
 type GatewayRpcServer interface {
@@ -34,16 +37,115 @@ type SubscriptionRpcServer interface {
 	Publish(ctx context.Context, request *proto.PublishRequest, response *proto.Response) error
 }
 
+type ConversationRpcServer interface {
+	GetConversations(ctx context.Context, request *proto.GetConversationsRequest, response *proto.GetConversationsResponse) error
+
+	UpdateConversation(ctx context.Context, request *proto.UpdateConversationRequest, response *proto.Response) error
+}
+
+type PresenceRpcServer interface {
+	GetPresence(ctx context.Context, request *proto.GetPresenceRequest, response *proto.GetPresenceResponse) error
+}
+
+type ScheduleRpcServer interface {
+	ScheduleMessage(ctx context.Context, request *proto.ScheduledMessage, response *proto.ScheduleMessageResponse) error
+
+	EditScheduledMessage(ctx context.Context, request *proto.EditScheduledRequest, response *proto.ScheduleMessageResponse) error
+
+	CancelScheduledMessage(ctx context.Context, request *proto.CancelScheduledRequest, response *proto.Response) error
+}
+
+type BroadcastRpcServer interface {
+	Broadcast(ctx context.Context, request *proto.BroadcastRequest, response *proto.BroadcastResponse) error
+
+	GetBroadcastProgress(ctx context.Context, request *proto.GetBroadcastProgressRequest, response *proto.BroadcastResponse) error
+}
+
+type BlockRpcServer interface {
+	UpdateBlockList(ctx context.Context, request *proto.BlockRequest, response *proto.BlockListResponse) error
+
+	GetBlockList(ctx context.Context, request *proto.GetBlockListRequest, response *proto.BlockListResponse) error
+}
+
+type RelationRpcServer interface {
+	UpdateRelation(ctx context.Context, request *proto.UpdateRelationRequest, response *proto.Response) error
+}
+
+// MessageScheduler schedules the messages deliver in future, implemented by messaging.MessageScheduler.
+type MessageScheduler interface {
+	Schedule(m *messages.ScheduledMessage) (*messages.ScheduledMessage, error)
+
+	Edit(uid string, edit *messages.ScheduledEdit) (*messages.ScheduledMessage, error)
+
+	Cancel(uid string, id int64) error
+}
+
+// BlockService manages the block lists of users, implemented by messaging.BlockService.
+type BlockService interface {
+	Block(uid string, uids []string) ([]string, error)
+
+	Unblock(uid string, uids []string) ([]string, error)
+
+	List(uid string) ([]string, error)
+}
+
+// RelationCache caches the relationships of users for the direct message policy, implemented by
+// messaging.RelationCache.
+type RelationCache interface {
+	AddRelations(kind messaging.RelationKind, uid string, targets ...string)
+
+	RemoveRelations(kind messaging.RelationKind, uid string, targets ...string)
+
+	SetRelations(kind messaging.RelationKind, uid string, targets ...string)
+}
+
+// ServiceOptions the optional services of IMRpcService, the rpc of the service responses error when it is nil.
+type ServiceOptions struct {
+	// Conversation the conversation store shared with messaging
+	Conversation store.ConversationStore
+
+	// Presence the presence store shared with messaging
+	Presence store.PresenceStore
+
+	// Scheduler the scheduler of the messaging
+	Scheduler MessageScheduler
+
+	// Broadcaster pushes the announcement to all clients of the gateway
+	Broadcaster *gate.Broadcaster
+
+	// Blocks the block list service of the messaging
+	Blocks BlockService
+
+	// Relations the relationships cache of the direct message policy
+	Relations RelationCache
+}
+
 type IMRpcService struct {
-	gateway gate.Server
-	sub     subscription_impl.SubscribeWrap
+	gateway      gate.Server
+	sub          subscription_impl.SubscribeWrap
+	conversation store.ConversationStore
+	presence     store.PresenceStore
+	scheduler    MessageScheduler
+	broadcaster  *gate.Broadcaster
+	blocks       BlockService
+	relations    RelationCache
 }
 
 func RunRpcService(options *rpc.ServerOptions, gate gate.Server, subscribe subscription.Subscribe) error {
+	return RunRpcServiceWithOptions(options, gate, subscribe, &ServiceOptions{})
+}
+
+func RunRpcServiceWithOptions(options *rpc.ServerOptions, gate gate.Server, subscribe subscription.Subscribe, services *ServiceOptions) error {
 	server := rpc.NewBaseServer(options)
 	rpcServer := IMRpcService{
-		gateway: gate,
-		sub:     subscription_impl.NewSubscribeWrap(subscribe),
+		gateway:      gate,
+		sub:          subscription_impl.NewSubscribeWrap(subscribe),
+		conversation: services.Conversation,
+		presence:     services.Presence,
+		scheduler:    services.Scheduler,
+		broadcaster:  services.Broadcaster,
+		blocks:       services.Blocks,
+		relations:    services.Relations,
 	}
 	server.Register(options.Name, &rpcServer)
 	return server.Run()
@@ -216,3 +318,312 @@ func (r *IMRpcService) Publish(ctx context.Context, request *proto.PublishReques
 	}
 	return nil
 }
+
+////////////////////////////////////// Conversation //////////////////////////////////////////////
+
+const errServiceNotAvailable = "service is not available"
+
+func (r *IMRpcService) GetConversations(ctx context.Context, request *proto.GetConversationsRequest, response *proto.GetConversationsResponse) error {
+	response.Response = &proto.Response{}
+	if r.conversation == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	list, err := r.conversation.ListConversations(request.Uid, int(request.Offset), int(request.Limit))
+	if err != nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = err.Error()
+		return nil
+	}
+	for _, c := range list {
+		response.Conversations = append(response.Conversations, &proto.Conversation{
+			ChatType: c.ChatType,
+			Id:       c.ID,
+			LastMid:  c.LastMid,
+			LastFrom: c.LastFrom,
+			LastType: c.LastType,
+			Preview:  c.Preview,
+			ActiveAt: c.ActiveAt,
+			Unread:   c.Unread,
+			Pinned:   c.Pinned,
+			Muted:    c.Muted,
+		})
+	}
+	return nil
+}
+
+func (r *IMRpcService) UpdateConversation(ctx context.Context, request *proto.UpdateConversationRequest, response *proto.Response) error {
+	if r.conversation == nil {
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = errServiceNotAvailable
+		return nil
+	}
+	var err error
+	if request.Pinned != nil || request.Muted != nil {
+		err = r.conversation.SetConversationFlags(request.Uid, request.ChatType, request.Id, request.Pinned, request.Muted)
+	}
+	if err == nil && request.ClearUnread {
+		err = r.conversation.ClearUnread(request.Uid, request.ChatType, request.Id)
+	}
+	if err != nil {
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = err.Error()
+	}
+	return nil
+}
+
+////////////////////////////////////// Presence //////////////////////////////////////////////
+
+func (r *IMRpcService) GetPresence(ctx context.Context, request *proto.GetPresenceRequest, response *proto.GetPresenceResponse) error {
+	response.Response = &proto.Response{}
+	if r.presence == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	for _, uid := range request.Uids {
+		visible := true
+		var err error
+		if request.Viewer != "" {
+			visible, err = store.CanSeePresence(r.presence, request.Viewer, uid)
+		}
+		p := &messages.Presence{Uid: uid}
+		if err == nil && visible {
+			p, err = r.presence.GetPresence(uid)
+		}
+		if err != nil {
+			response.Response.Code = int32(proto.Response_ERROR)
+			response.Response.Msg = err.Error()
+			response.Presences = nil
+			return nil
+		}
+		response.Presences = append(response.Presences, &proto.Presence{
+			Uid:        p.Uid,
+			State:      p.State,
+			LastSeen:   p.LastSeen,
+			StatusText: p.StatusText,
+		})
+	}
+	return nil
+}
+
+////////////////////////////////////// Schedule //////////////////////////////////////////////
+
+func scheduledToProto(m *messages.ScheduledMessage) *proto.ScheduledMessage {
+	return &proto.ScheduledMessage{
+		Id:        m.ID,
+		ChatType:  m.ChatType,
+		From:      m.Message.From,
+		To:        m.Message.To,
+		Type:      m.Message.Type,
+		Content:   m.Message.Content,
+		CliMid:    m.Message.CliMid,
+		DeliverAt: m.DeliverAt,
+		CreateAt:  m.CreateAt,
+	}
+}
+
+func (r *IMRpcService) ScheduleMessage(ctx context.Context, request *proto.ScheduledMessage, response *proto.ScheduleMessageResponse) error {
+	response.Response = &proto.Response{}
+	if r.scheduler == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	m, err := r.scheduler.Schedule(&messages.ScheduledMessage{
+		ChatType: request.ChatType,
+		Message: &messages.ChatMessage{
+			CliMid:  request.CliMid,
+			From:    request.From,
+			To:      request.To,
+			Type:    request.Type,
+			Content: request.Content,
+		},
+		DeliverAt: request.DeliverAt,
+	})
+	if err != nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = err.Error()
+		return nil
+	}
+	response.Message = scheduledToProto(m)
+	return nil
+}
+
+func (r *IMRpcService) EditScheduledMessage(ctx context.Context, request *proto.EditScheduledRequest, response *proto.ScheduleMessageResponse) error {
+	response.Response = &proto.Response{}
+	if r.scheduler == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	m, err := r.scheduler.Edit(request.Uid, &messages.ScheduledEdit{
+		ID:        request.Id,
+		Content:   request.Content,
+		DeliverAt: request.DeliverAt,
+	})
+	if err != nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = err.Error()
+		return nil
+	}
+	response.Message = scheduledToProto(m)
+	return nil
+}
+
+func (r *IMRpcService) CancelScheduledMessage(ctx context.Context, request *proto.CancelScheduledRequest, response *proto.Response) error {
+	if r.scheduler == nil {
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = errServiceNotAvailable
+		return nil
+	}
+	err := r.scheduler.Cancel(request.Uid, request.Id)
+	if err != nil {
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = err.Error()
+	}
+	return nil
+}
+
+////////////////////////////////////// Broadcast //////////////////////////////////////////////
+
+func broadcastProgressToProto(p *gate.BroadcastProgress) *proto.BroadcastProgress {
+	return &proto.BroadcastProgress{
+		Id:        p.ID,
+		Total:     int32(p.Total),
+		Sent:      int32(p.Sent),
+		Delivered: int32(p.Delivered),
+		Failed:    int32(p.Failed),
+		Done:      p.Done,
+		StartAt:   p.StartAt,
+		FinishAt:  p.FinishAt,
+	}
+}
+
+// Broadcast pushes the announcement to the clients of this node, the broadcast and progress of each node are
+// independent, the caller reaches all nodes by calling every node.
+func (r *IMRpcService) Broadcast(ctx context.Context, request *proto.BroadcastRequest, response *proto.BroadcastResponse) error {
+	response.Response = &proto.Response{}
+	if r.broadcaster == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	m := messages.NewMessage(0, messages.ActionNotifyAnnouncement, &messages.Announcement{
+		Title:   request.Title,
+		Content: request.Content,
+		SendAt:  time.Now().Unix(),
+	})
+	p, err := r.broadcaster.Broadcast(m, &gate.BroadcastFilter{
+		Devices:  request.Devices,
+		Versions: request.Versions,
+		Uids:     request.Uids,
+	})
+	if err != nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = err.Error()
+		return nil
+	}
+	response.Progress = broadcastProgressToProto(p)
+	return nil
+}
+
+func (r *IMRpcService) GetBroadcastProgress(ctx context.Context, request *proto.GetBroadcastProgressRequest, response *proto.BroadcastResponse) error {
+	response.Response = &proto.Response{}
+	if r.broadcaster == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	p := r.broadcaster.Progress(request.Id)
+	if p == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = "broadcast not found"
+		return nil
+	}
+	response.Progress = broadcastProgressToProto(p)
+	return nil
+}
+
+////////////////////////////////////// Block //////////////////////////////////////////////
+
+func (r *IMRpcService) UpdateBlockList(ctx context.Context, request *proto.BlockRequest, response *proto.BlockListResponse) error {
+	response.Response = &proto.Response{}
+	if r.blocks == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	var uids []string
+	var err error
+	if request.Blocked {
+		uids, err = r.blocks.Block(request.Uid, request.Uids)
+	} else {
+		uids, err = r.blocks.Unblock(request.Uid, request.Uids)
+	}
+	if err != nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = err.Error()
+		return nil
+	}
+	response.Uids = uids
+	return nil
+}
+
+func (r *IMRpcService) GetBlockList(ctx context.Context, request *proto.GetBlockListRequest, response *proto.BlockListResponse) error {
+	response.Response = &proto.Response{}
+	if r.blocks == nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = errServiceNotAvailable
+		return nil
+	}
+	uids, err := r.blocks.List(request.Uid)
+	if err != nil {
+		response.Response.Code = int32(proto.Response_ERROR)
+		response.Response.Msg = err.Error()
+		return nil
+	}
+	response.Uids = uids
+	return nil
+}
+
+////////////////////////////////////// Relation //////////////////////////////////////////////
+
+func (r *IMRpcService) UpdateRelation(ctx context.Context, request *proto.UpdateRelationRequest, response *proto.Response) error {
+	if r.relations == nil {
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = errServiceNotAvailable
+		return nil
+	}
+	var kind messaging.RelationKind
+	switch request.Kind {
+	case proto.UpdateRelationRequest_Contact:
+		kind = messaging.RelationContact
+	case proto.UpdateRelationRequest_Channel:
+		kind = messaging.RelationChannel
+	case proto.UpdateRelationRequest_Allow:
+		kind = messaging.RelationAllow
+	default:
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = "unknown relation kind"
+		return nil
+	}
+	if request.Uid == "" {
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = "uid is empty"
+		return nil
+	}
+	switch request.Op {
+	case proto.UpdateRelationRequest_Add:
+		r.relations.AddRelations(kind, request.Uid, request.Targets...)
+	case proto.UpdateRelationRequest_Remove:
+		r.relations.RemoveRelations(kind, request.Uid, request.Targets...)
+	case proto.UpdateRelationRequest_Replace:
+		r.relations.SetRelations(kind, request.Uid, request.Targets...)
+	default:
+		response.Code = int32(proto.Response_ERROR)
+		response.Msg = "unknown relation op"
+	}
+	return nil
+}
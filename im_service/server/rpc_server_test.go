@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"github.com/glide-im/glide/im_service/proto"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIMRpcService_UpdateConversation(t *testing.T) {
+	conv := store.NewMemoryConversationStore()
+	r := &IMRpcService{conversation: conv}
+	update := func(request *proto.UpdateConversationRequest) *messages.Conversation {
+		request.Uid = "1"
+		request.ChatType = messages.ChatTypeSingle
+		request.Id = "2"
+		response := &proto.Response{}
+		assert.NoError(t, r.UpdateConversation(context.TODO(), request, response))
+		assert.Equal(t, int32(proto.Response_OK), response.Code)
+		c, err := conv.GetConversation("1", messages.ChatTypeSingle, "2")
+		assert.NoError(t, err)
+		return c
+	}
+
+	_ = conv.UpdateConversation("1", &messages.Conversation{ChatType: messages.ChatTypeSingle, ID: "2", ActiveAt: 1}, true)
+	pinned, muted := true, true
+	c := update(&proto.UpdateConversationRequest{Pinned: &pinned, Muted: &muted})
+	assert.True(t, c.Pinned)
+	assert.True(t, c.Muted)
+	assert.Equal(t, int64(1), c.Unread)
+
+	// the flags are kept if the request clears the unread only
+	c = update(&proto.UpdateConversationRequest{ClearUnread: true})
+	assert.True(t, c.Pinned)
+	assert.True(t, c.Muted)
+	assert.Zero(t, c.Unread)
+
+	muted = false
+	c = update(&proto.UpdateConversationRequest{Muted: &muted})
+	assert.True(t, c.Pinned)
+	assert.False(t, c.Muted)
+}
@@ -1,20 +1,100 @@
 package message_store_db
 
 import (
+	"database/sql"
 	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
 	"github.com/glide-im/glide/pkg/subscription"
 	"math"
+	"strings"
+	"time"
 )
 
+// maxCountAckedMembers the max count of members in one query of CountAcked
+const maxCountAckedMembers = 500
+
+var _ store.SubscriptionStore = &SubscriptionMessageStore{}
+var _ store.ChannelAckStore = &SubscriptionMessageStore{}
+
 type SubscriptionMessageStore struct {
+	db *sql.DB
+}
+
+// NewSubscriptionMessageStore returns the SubscriptionMessageStore which shares the database of ChatMessageStore.
+func NewSubscriptionMessageStore(s *ChatMessageStore) *SubscriptionMessageStore {
+	return &SubscriptionMessageStore{
+		db: s.db,
+	}
 }
 
+// NextSegmentSequence returns the seq next to the latest stored message of the channel, the seq of channel continues
+// after restart.
 func (c *SubscriptionMessageStore) NextSegmentSequence(id subscription.ChanID, info subscription.ChanInfo) (int64, int64, error) {
-	return 1, math.MaxInt64, nil
+	var seq int64
+	err := c.db.QueryRow("SELECT COALESCE(MAX(`seq`), 0) FROM im_group_message WHERE `to`=?", id).Scan(&seq)
+	if err != nil {
+		return 0, 0, err
+	}
+	return seq + 1, math.MaxInt64, nil
 }
 
+// StoreChannelMessage stores the message and sets the auto increment id to msg.Mid.
 func (c *SubscriptionMessageStore) StoreChannelMessage(ch subscription.ChanID, msg *messages.ChatMessage) error {
-	return nil
+	s, err := c.db.Exec(
+		"INSERT INTO im_group_message (`seq`, `from`, `to`, `type`, `content`, `send_at`, `create_at`, `status`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		msg.Seq, msg.From, ch, msg.Type, msg.Content, msg.SendAt, time.Now().Unix(), messageStatusNormal)
+	if err != nil {
+		return err
+	}
+	msg.Mid, err = s.LastInsertId()
+	return err
+}
+
+func (c *SubscriptionMessageStore) UpdateMemberAck(ch subscription.ChanID, member subscription.SubscriberID, seq int64) (bool, error) {
+	s, err := c.db.Exec("INSERT INTO im_group_member_ack (`channel`, `uid`, `seq`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `seq`=GREATEST(`seq`, VALUES(`seq`))",
+		ch, member, seq)
+	if err != nil {
+		return false, err
+	}
+	// 1 inserted, 2 updated, 0 the seq is not greater than acked seq
+	affected, err := s.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (c *SubscriptionMessageStore) GetMemberAck(ch subscription.ChanID, member subscription.SubscriberID) (int64, error) {
+	var seq int64
+	err := c.db.QueryRow("SELECT `seq` FROM im_group_member_ack WHERE `channel`=? AND `uid`=?", ch, member).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// CountAcked counts the acks of members in batches of maxCountAckedMembers, the acks of the users left the channel
+// are kept in the table but not counted.
+func (c *SubscriptionMessageStore) CountAcked(ch subscription.ChanID, seq int64, members []string) (int64, error) {
+	var total int64
+	for i := 0; i < len(members); i += maxCountAckedMembers {
+		end := i + maxCountAckedMembers
+		if end > len(members) {
+			end = len(members)
+		}
+		args := []interface{}{ch, seq}
+		for _, member := range members[i:end] {
+			args = append(args, member)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", end-i), ",")
+		var count int64
+		err := c.db.QueryRow("SELECT COUNT(*) FROM im_group_member_ack WHERE `channel`=? AND `seq`>=? AND `uid` IN ("+placeholders+")", args...).Scan(&count)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
 }
 
 type IdleSubscriptionStore struct {
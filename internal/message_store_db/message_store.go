@@ -2,6 +2,7 @@ package message_store_db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/glide-im/glide/config"
 	"github.com/glide-im/glide/pkg/messages"
@@ -11,7 +12,16 @@ import (
 	"time"
 )
 
+const (
+	messageStatusNormal   = messages.MessageStatusNormal
+	messageStatusRecalled = messages.MessageStatusRecalled
+	messageStatusBlocked  = messages.MessageStatusBlocked
+)
+
 var _ store.MessageStore = &ChatMessageStore{}
+var _ store.MessageHistoryStore = &ChatMessageStore{}
+var _ store.OfflineStore = &ChatMessageStore{}
+var _ store.MessagePurgeStore = &ChatMessageStore{}
 
 type ChatMessageStore struct {
 	db *sql.DB
@@ -33,34 +43,75 @@ func New(conf *config.MySqlConf) (*ChatMessageStore, error) {
 	return m, nil
 }
 
+// StoreOffline does nothing, the offline messages are stored by the store.OfflineStore implementation.
 func (D *ChatMessageStore) StoreOffline(message *messages.ChatMessage) error {
-	//TODO implement me
-	panic("implement me")
+	return nil
 }
 
-func (D *ChatMessageStore) StoreMessage(m *messages.ChatMessage) error {
+func (D *ChatMessageStore) AppendOffline(uid string, message *messages.ChatMessage) (int64, error) {
+	bytes, err := messages.JsonCodec.Encode(message)
+	if err != nil {
+		return 0, err
+	}
+	s, err := D.db.Exec("INSERT INTO im_offline_message (`uid`, `message`, `create_at`) VALUES (?, ?, ?)",
+		uid, string(bytes), time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return s.LastInsertId()
+}
 
-	from, err := strconv.ParseInt(m.From, 10, 64)
+func (D *ChatMessageStore) RangeOffline(uid string, cursor int64, limit int) ([]*messages.OfflineMessage, error) {
+	rows, err := D.db.Query("SELECT `id`, `message` FROM im_offline_message WHERE `uid`=? AND `id`>? ORDER BY `id` LIMIT ?",
+		uid, cursor, limit)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*messages.OfflineMessage
+	for rows.Next() {
+		var content string
+		om := &messages.OfflineMessage{Message: &messages.ChatMessage{}}
+		err = rows.Scan(&om.Cursor, &content)
+		if err != nil {
+			return nil, err
+		}
+		err = messages.JsonCodec.Decode([]byte(content), om.Message)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, om)
 	}
-	to, err := strconv.ParseInt(m.To, 10, 64)
+	return result, rows.Err()
+}
+
+func (D *ChatMessageStore) AckOffline(uid string, cursor int64) (int64, error) {
+	s, err := D.db.Exec("DELETE FROM im_offline_message WHERE `uid`=? AND `id`<=?", uid, cursor)
 	if err != nil {
-		return nil
+		return 0, err
 	}
+	return s.RowsAffected()
+}
 
-	lg := from
-	sm := to
-	if lg < sm {
-		lg, sm = sm, lg
+func (D *ChatMessageStore) CountOffline(uid string) (int64, error) {
+	var count int64
+	err := D.db.QueryRow("SELECT COUNT(*) FROM im_offline_message WHERE `uid`=?", uid).Scan(&count)
+	return count, err
+}
+
+func (D *ChatMessageStore) StoreMessage(m *messages.ChatMessage) error {
+
+	from, to, sid, err := sessionOf(m.From, m.To)
+	if err != nil {
+		return nil
 	}
-	sid := fmt.Sprintf("%d_%d", lg, sm)
 
 	// todo update the type of user id to string
 	//mysql only
 	s, e := D.db.Exec(
 		"INSERT INTO im_chat_message (`session_id`, `from`, `to`, `type`, `content`, `send_at`, `create_at`, `cli_seq`, `status`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)ON DUPLICATE KEY UPDATE send_at=?",
-		sid, from, to, m.Type, m.Content, m.SendAt, time.Now().Unix(), 0, 0, m.SendAt)
+		sid, from, to, m.Type, m.Content, m.SendAt, time.Now().Unix(), m.Seq, m.Status, m.SendAt)
 	if e != nil {
 		return e
 	}
@@ -68,7 +119,168 @@ func (D *ChatMessageStore) StoreMessage(m *messages.ChatMessage) error {
 	return nil
 }
 
+// sessionOf returns the numeric uid of two participants and the session id of single chat.
+func sessionOf(uid1 string, uid2 string) (int64, int64, string, error) {
+	from, err := strconv.ParseInt(uid1, 10, 64)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	to, err := strconv.ParseInt(uid2, 10, 64)
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	lg := from
+	sm := to
+	if lg < sm {
+		lg, sm = sm, lg
+	}
+	return from, to, fmt.Sprintf("%d_%d", lg, sm), nil
+}
+
+func (D *ChatMessageStore) RecallMessage(recall *messages.Recall) error {
+	table := "im_chat_message"
+	if recall.ChatType == messages.ChatTypeChannel {
+		table = "im_group_message"
+	}
+	_, err := D.db.Exec("UPDATE "+table+" SET `status`=? WHERE `m_id`=?", messageStatusRecalled, recall.Mid)
+	return err
+}
+
+// PurgeMessage deletes the message and its revisions.
+func (D *ChatMessageStore) PurgeMessage(chatType int32, mid int64) error {
+	table := "im_chat_message"
+	if chatType == messages.ChatTypeChannel {
+		table = "im_group_message"
+	}
+	_, err := D.db.Exec("DELETE FROM "+table+" WHERE `m_id`=?", mid)
+	if err != nil {
+		return err
+	}
+	_, err = D.db.Exec("DELETE FROM im_message_revision WHERE `chat_type`=? AND `m_id`=?", chatType, mid)
+	return err
+}
+
+func (D *ChatMessageStore) StoreMessageRevision(edit *messages.MessageEdit) error {
+	table := "im_chat_message"
+	if edit.ChatType == messages.ChatTypeChannel {
+		table = "im_group_message"
+	}
+
+	tx, err := D.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// lock the message row to assign the revision number in sequence
+	var exist int
+	err = tx.QueryRow("SELECT 1 FROM "+table+" WHERE `m_id`=? FOR UPDATE", edit.Mid).Scan(&exist)
+	if err == sql.ErrNoRows {
+		return errors.New(store.ErrMessageNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	var revision int64
+	err = tx.QueryRow("SELECT COALESCE(MAX(`revision`), 0) FROM im_message_revision WHERE `chat_type`=? AND `m_id`=?",
+		edit.ChatType, edit.Mid).Scan(&revision)
+	if err != nil {
+		return err
+	}
+	edit.Revision = revision + 1
+
+	_, err = tx.Exec("INSERT INTO im_message_revision (`chat_type`, `m_id`, `revision`, `content`, `edit_at`) VALUES (?, ?, ?, ?, ?)",
+		edit.ChatType, edit.Mid, edit.Revision, edit.Content, edit.EditAt)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE "+table+" SET `content`=? WHERE `m_id`=?", edit.Content, edit.Mid)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (D *ChatMessageStore) GetMessage(chatType int32, mid int64) (*messages.ChatMessage, error) {
+	var row *sql.Row
+	if chatType == messages.ChatTypeChannel {
+		row = D.db.QueryRow("SELECT `m_id`, `seq`, `from`, `to`, `type`, `content`, `send_at`, `status` FROM im_group_message WHERE `m_id`=?", mid)
+	} else {
+		row = D.db.QueryRow("SELECT `m_id`, `cli_seq`, `from`, `to`, `type`, `content`, `send_at`, `status` FROM im_chat_message WHERE `m_id`=?", mid)
+	}
+	m := &messages.ChatMessage{}
+	err := row.Scan(&m.Mid, &m.Seq, &m.From, &m.To, &m.Type, &m.Content, &m.SendAt, &m.Status)
+	if err == sql.ErrNoRows {
+		return nil, errors.New(store.ErrMessageNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (D *ChatMessageStore) ListMessages(query *store.HistoryQuery) ([]*messages.ChatMessage, error) {
+	var stmt, cursorColumn string
+	var args []interface{}
+	if query.ChatType == messages.ChatTypeChannel {
+		cursorColumn = "seq"
+		stmt = "SELECT `m_id`, `seq`, `from`, `to`, `type`, `content`, `send_at` FROM im_group_message WHERE `to`=?"
+		args = append(args, query.To)
+	} else {
+		_, _, sid, err := sessionOf(query.From, query.To)
+		if err != nil {
+			return nil, err
+		}
+		cursorColumn = "m_id"
+		// the blocked messages are visible to the sender only
+		stmt = "SELECT `m_id`, `cli_seq`, `from`, `to`, `type`, `content`, `send_at` FROM im_chat_message WHERE `session_id`=? AND (`status`<>? OR `from`=?)"
+		args = append(args, sid, messageStatusBlocked, query.From)
+	}
+	stmt += " AND `status`<>?"
+	args = append(args, messageStatusRecalled)
+
+	order := "DESC"
+	if query.After {
+		stmt += " AND `" + cursorColumn + "`>?"
+		args = append(args, query.Cursor)
+		order = "ASC"
+	} else if query.Cursor > 0 {
+		stmt += " AND `" + cursorColumn + "`<?"
+		args = append(args, query.Cursor)
+	}
+	args = append(args, query.Limit)
+
+	rows, err := D.db.Query(stmt+" ORDER BY `"+cursorColumn+"` "+order+" LIMIT ?", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*messages.ChatMessage
+	for rows.Next() {
+		m := &messages.ChatMessage{}
+		err = rows.Scan(&m.Mid, &m.Seq, &m.From, &m.To, &m.Type, &m.Content, &m.SendAt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if !query.After {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+	return result, nil
+}
+
 var _ store.MessageStore = &IdleChatMessageStore{}
+var _ store.MessageHistoryStore = &IdleChatMessageStore{}
 
 type IdleChatMessageStore struct {
 }
@@ -81,3 +293,20 @@ func (i *IdleChatMessageStore) StoreMessage(message *messages.ChatMessage) error
 	message.Mid = time.Now().Unix()
 	return nil
 }
+
+func (i *IdleChatMessageStore) RecallMessage(recall *messages.Recall) error {
+	return nil
+}
+
+func (i *IdleChatMessageStore) StoreMessageRevision(edit *messages.MessageEdit) error {
+	edit.Revision = time.Now().UnixNano()
+	return nil
+}
+
+func (i *IdleChatMessageStore) GetMessage(chatType int32, mid int64) (*messages.ChatMessage, error) {
+	return nil, errors.New(store.ErrMessageNotFound)
+}
+
+func (i *IdleChatMessageStore) ListMessages(query *store.HistoryQuery) ([]*messages.ChatMessage, error) {
+	return nil, nil
+}
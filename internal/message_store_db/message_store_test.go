@@ -0,0 +1,172 @@
+package message_store_db
+
+import (
+	"database/sql"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStore returns the store connected to the mysql of the env GLIDE_TEST_MYSQL, the tables are created by
+// schema.sql and cleared, the test is skipped if the env is not set.
+func newTestStore(t *testing.T) *ChatMessageStore {
+	dsn := os.Getenv("GLIDE_TEST_MYSQL")
+	if dsn == "" {
+		t.Skip("GLIDE_TEST_MYSQL is not set, e.g. root:root@tcp(localhost:3306)/im_test")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil || db.Ping() != nil {
+		t.Skip("mysql is not available")
+	}
+	schema, err := os.ReadFile("schema.sql")
+	assert.NoError(t, err)
+	for _, stmt := range strings.Split(string(schema), ";") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		_, err = db.Exec(stmt)
+		assert.NoError(t, err)
+	}
+	for _, table := range []string{"im_chat_message", "im_group_message", "im_message_revision", "im_offline_message", "im_group_member_ack"} {
+		_, err = db.Exec("DELETE FROM " + table)
+		assert.NoError(t, err)
+	}
+	return &ChatMessageStore{db: db}
+}
+
+func TestChatMessageStore_Offline(t *testing.T) {
+	s := newTestStore(t)
+
+	var cursors []int64
+	for i := 1; i <= 5; i++ {
+		cursor, err := s.AppendOffline("1", &messages.ChatMessage{Mid: int64(i), Content: "hello", SendAt: time.Now().Unix()})
+		assert.NoError(t, err)
+		cursors = append(cursors, cursor)
+	}
+	_, err := s.AppendOffline("2", &messages.ChatMessage{Mid: 100})
+	assert.NoError(t, err)
+
+	list, err := s.RangeOffline("1", cursors[1], 2)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, cursors[2], list[0].Cursor)
+	assert.Equal(t, int64(3), list[0].Message.Mid)
+	assert.Equal(t, "hello", list[0].Message.Content)
+
+	removed, err := s.AckOffline("1", cursors[2])
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+
+	count, err := s.CountOffline("1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	count, err = s.CountOffline("2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// the cursor keeps increasing after ack
+	cursor, err := s.AppendOffline("1", &messages.ChatMessage{Mid: 6})
+	assert.NoError(t, err)
+	assert.Greater(t, cursor, cursors[4])
+}
+
+func TestChatMessageStore_BlockedHistory(t *testing.T) {
+	s := newTestStore(t)
+
+	normal := &messages.ChatMessage{From: "2", To: "1", Content: "hi", SendAt: time.Now().Unix()}
+	assert.NoError(t, s.StoreMessage(normal))
+	blocked := &messages.ChatMessage{From: "1", To: "2", Content: "hello", SendAt: time.Now().Unix(), Status: messages.MessageStatusBlocked}
+	assert.NoError(t, s.StoreMessage(blocked))
+
+	// the blocked message is visible to the sender only
+	list, err := s.ListMessages(&store.HistoryQuery{ChatType: messages.ChatTypeSingle, From: "1", To: "2", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	list, err = s.ListMessages(&store.HistoryQuery{ChatType: messages.ChatTypeSingle, From: "2", To: "1", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, normal.Mid, list[0].Mid)
+
+	got, err := s.GetMessage(messages.ChatTypeSingle, blocked.Mid)
+	assert.NoError(t, err)
+	assert.Equal(t, messages.MessageStatusBlocked, got.Status)
+}
+
+func TestSubscriptionMessageStore_ChannelHistory(t *testing.T) {
+	s := newTestStore(t)
+	cs := NewSubscriptionMessageStore(s)
+
+	var mids []int64
+	for i := 1; i <= 5; i++ {
+		seq, _, err := cs.NextSegmentSequence("ch", subscription.ChanInfo{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), seq)
+		m := &messages.ChatMessage{Seq: seq, From: "1", Type: 1, Content: "m" + strconv.Itoa(i), SendAt: time.Now().Unix()}
+		assert.NoError(t, cs.StoreChannelMessage("ch", m))
+		assert.NotZero(t, m.Mid)
+		mids = append(mids, m.Mid)
+	}
+	assert.NoError(t, cs.StoreChannelMessage("other", &messages.ChatMessage{Seq: 1, From: "1", Content: "other"}))
+	assert.NoError(t, s.RecallMessage(&messages.Recall{ChatType: messages.ChatTypeChannel, Mid: mids[2]}))
+
+	contents := func(list []*messages.ChatMessage) []string {
+		var result []string
+		for _, m := range list {
+			assert.Equal(t, "ch", m.To)
+			result = append(result, m.Content)
+		}
+		return result
+	}
+	query := func(cursor int64, after bool, limit int) []*messages.ChatMessage {
+		list, err := s.ListMessages(&store.HistoryQuery{ChatType: messages.ChatTypeChannel, To: "ch", Cursor: cursor, After: after, Limit: limit})
+		assert.NoError(t, err)
+		return list
+	}
+
+	// the recalled message is excluded, the pages are old to new
+	list := query(0, false, 2)
+	assert.Equal(t, []string{"m4", "m5"}, contents(list))
+	list = query(list[0].Seq, false, 2)
+	assert.Equal(t, []string{"m1", "m2"}, contents(list))
+	assert.Empty(t, query(list[0].Seq, false, 2))
+	assert.Equal(t, []string{"m2", "m4"}, contents(query(1, true, 2)))
+
+	got, err := s.GetMessage(messages.ChatTypeChannel, mids[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "m1", got.Content)
+	assert.Equal(t, messages.MessageStatusNormal, got.Status)
+	got, err = s.GetMessage(messages.ChatTypeChannel, mids[2])
+	assert.NoError(t, err)
+	assert.Equal(t, messages.MessageStatusRecalled, got.Status)
+}
+
+func TestSubscriptionMessageStore_CountAcked(t *testing.T) {
+	cs := NewSubscriptionMessageStore(newTestStore(t))
+
+	for member, seq := range map[string]int64{"1": 5, "2": 3, "3": 8} {
+		updated, err := cs.UpdateMemberAck("ch", subscription.SubscriberID(member), seq)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+	}
+	updated, err := cs.UpdateMemberAck("ch", "1", 4)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+	seq, err := cs.GetMemberAck("ch", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), seq)
+
+	count, err := cs.CountAcked("ch", 4, []string{"1", "2", "3", "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// the member "3" left the channel
+	count, err = cs.CountAcked("ch", 4, []string{"1", "2", "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,68 @@
+-- the tables used by ChatMessageStore and SubscriptionMessageStore, mysql only
+
+CREATE TABLE IF NOT EXISTS `im_chat_message`
+(
+    `m_id`       BIGINT      NOT NULL AUTO_INCREMENT,
+    `session_id` VARCHAR(64) NOT NULL,
+    `from`       BIGINT      NOT NULL,
+    `to`         BIGINT      NOT NULL,
+    `type`       INT         NOT NULL DEFAULT 0,
+    `content`    TEXT        NOT NULL,
+    `send_at`    BIGINT      NOT NULL,
+    `create_at`  BIGINT      NOT NULL,
+    `cli_seq`    BIGINT      NOT NULL DEFAULT 0,
+    `status`     TINYINT     NOT NULL DEFAULT 0,
+    PRIMARY KEY (`m_id`),
+    KEY `idx_session` (`session_id`, `m_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+-- the seq is assigned by the channel, unique in the channel
+CREATE TABLE IF NOT EXISTS `im_group_message`
+(
+    `m_id`      BIGINT      NOT NULL AUTO_INCREMENT,
+    `seq`       BIGINT      NOT NULL,
+    `from`      VARCHAR(64) NOT NULL,
+    `to`        VARCHAR(64) NOT NULL,
+    `type`      INT         NOT NULL DEFAULT 0,
+    `content`   TEXT        NOT NULL,
+    `send_at`   BIGINT      NOT NULL,
+    `create_at` BIGINT      NOT NULL,
+    `status`    TINYINT     NOT NULL DEFAULT 0,
+    PRIMARY KEY (`m_id`),
+    UNIQUE KEY `uk_channel_seq` (`to`, `seq`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+-- the acked seq of each member in channels, the rows of the members left are not counted
+CREATE TABLE IF NOT EXISTS `im_group_member_ack`
+(
+    `channel` VARCHAR(64) NOT NULL,
+    `uid`     VARCHAR(64) NOT NULL,
+    `seq`     BIGINT      NOT NULL DEFAULT 0,
+    PRIMARY KEY (`channel`, `uid`),
+    KEY `idx_channel_seq` (`channel`, `seq`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `im_message_revision`
+(
+    `chat_type` INT    NOT NULL,
+    `m_id`      BIGINT NOT NULL,
+    `revision`  BIGINT NOT NULL,
+    `content`   TEXT   NOT NULL,
+    `edit_at`   BIGINT NOT NULL,
+    PRIMARY KEY (`chat_type`, `m_id`, `revision`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `im_offline_message`
+(
+    `id`        BIGINT      NOT NULL AUTO_INCREMENT,
+    `uid`       VARCHAR(64) NOT NULL,
+    `message`   TEXT        NOT NULL,
+    `create_at` BIGINT      NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_uid` (`uid`, `id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
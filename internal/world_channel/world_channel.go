@@ -40,7 +40,7 @@ func OnUserOnline(id gate.ID) {
 			Seq:     0,
 			From:    "system",
 			To:      string(chanId),
-			Type:    100,
+			Type:    messages.MessageTypeUserOnline,
 			Content: id.UID(),
 			SendAt:  time.Now().Unix(),
 		}
@@ -75,7 +75,7 @@ func OnUserOffline(id gate.ID) {
 		Seq:     0,
 		From:    "system",
 		To:      string(chanId),
-		Type:    101,
+		Type:    messages.MessageTypeUserOffline,
 		Content: id.UID(),
 		SendAt:  time.Now().Unix(),
 	})
@@ -279,6 +279,9 @@ func (a *Authenticator) updateClient(dc DefaultClient, authCredentials *ClientAu
 
 	oldID := dc.GetInfo().ID
 	newID := NewID2(authCredentials.UserID)
+	if authCredentials.Type == ClientTypeRobot {
+		newID = NewRobotID(authCredentials.UserID)
+	}
 	err := a.gateway.SetClientID(oldID, newID)
 	if IsIDAlreadyExist(err) {
 		if newID.Equals(oldID) {
@@ -0,0 +1,190 @@
+package gate
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/messages"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBroadcastRate the default max count of messages enqueued per second by the Broadcaster
+	DefaultBroadcastRate = 5000
+
+	broadcastTick = time.Millisecond * 100
+	// broadcastQueueSize the max count of broadcasts waiting for sending
+	broadcastQueueSize = 16
+	// broadcastHistory the max count of broadcasts kept for querying progress
+	broadcastHistory = 100
+)
+
+var ErrBroadcastBusy = errors.New("too many broadcasts in queue")
+
+// BroadcastFilter selects the clients receive the broadcast, the empty field matches all clients.
+type BroadcastFilter struct {
+	// Devices the client types, the device part of the client ID
+	Devices []string
+	// Versions the client versions, matches Info.Version
+	Versions []string
+	// Uids the users to receive
+	Uids []string
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the client is selected by the filter.
+func (f *BroadcastFilter) Match(info *Info) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Devices) != 0 && !contains(f.Devices, info.ID.Device()) {
+		return false
+	}
+	if len(f.Versions) != 0 && !contains(f.Versions, info.Version) {
+		return false
+	}
+	if len(f.Uids) != 0 && !contains(f.Uids, info.ID.UID()) {
+		return false
+	}
+	return true
+}
+
+// BroadcastProgress the progress of a broadcast, Delivered is the count of messages enqueued to the clients, Failed
+// is the count of clients disconnected or rejected by the gateway.
+type BroadcastProgress struct {
+	ID        int64 `json:"id"`
+	Total     int   `json:"total"`
+	Sent      int   `json:"sent"`
+	Delivered int   `json:"delivered"`
+	Failed    int   `json:"failed"`
+	Done      bool  `json:"done"`
+	StartAt   int64 `json:"start_at"`
+	FinishAt  int64 `json:"finish_at"`
+}
+
+type broadcastTask struct {
+	progress *BroadcastProgress
+	message  *messages.GlideMessage
+	clients  []ID
+}
+
+// Broadcaster pushes message to all connected clients of the gateway. The broadcasts are sent one by one, and the
+// messages are enqueued at most rate per second, so a large fan-out does not exhaust the message pool of gateway.
+// The Broadcaster only reaches the clients of the gateway of this node, and the progress is kept in this node, to
+// broadcast to the clients of all nodes, call the Broadcaster of every node.
+type Broadcaster struct {
+	gateway DefaultGateway
+	// batch the count of messages enqueued per tick
+	batch int
+
+	mu      sync.Mutex
+	id      int64
+	history []*BroadcastProgress
+	queue   chan *broadcastTask
+}
+
+// NewBroadcaster returns a Broadcaster sends rate messages per second, DefaultBroadcastRate is used when rate <= 0.
+func NewBroadcaster(gateway DefaultGateway, rate int) *Broadcaster {
+	if rate <= 0 {
+		rate = DefaultBroadcastRate
+	}
+	batch := rate * int(broadcastTick) / int(time.Second)
+	if batch <= 0 {
+		batch = 1
+	}
+	ret := &Broadcaster{
+		gateway: gateway,
+		batch:   batch,
+		queue:   make(chan *broadcastTask, broadcastQueueSize),
+	}
+	go ret.run()
+	return ret
+}
+
+// Broadcast selects the clients connected now by filter and sends m to them in background, returns the progress
+// snapshot with the ID to query progress later.
+func (b *Broadcaster) Broadcast(m *messages.GlideMessage, filter *BroadcastFilter) (*BroadcastProgress, error) {
+	var clients []ID
+	for id, info := range b.gateway.GetAll() {
+		info := info
+		if filter.Match(&info) {
+			clients = append(clients, id)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.id++
+	task := &broadcastTask{
+		progress: &BroadcastProgress{
+			ID:      b.id,
+			Total:   len(clients),
+			StartAt: time.Now().Unix(),
+		},
+		message: m,
+		clients: clients,
+	}
+	select {
+	case b.queue <- task:
+	default:
+		return nil, ErrBroadcastBusy
+	}
+	b.history = append(b.history, task.progress)
+	if len(b.history) > broadcastHistory {
+		b.history = b.history[len(b.history)-broadcastHistory:]
+	}
+	p := *task.progress
+	return &p, nil
+}
+
+// Progress returns the progress snapshot of the broadcast, nil if the broadcast is not found.
+func (b *Broadcaster) Progress(id int64) *BroadcastProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, p := range b.history {
+		if p.ID == id {
+			c := *p
+			return &c
+		}
+	}
+	return nil
+}
+
+func (b *Broadcaster) run() {
+	ticker := time.NewTicker(broadcastTick)
+	defer ticker.Stop()
+	for task := range b.queue {
+		for i := 0; i < len(task.clients); i += b.batch {
+			end := i + b.batch
+			if end > len(task.clients) {
+				end = len(task.clients)
+			}
+			delivered := 0
+			for _, id := range task.clients[i:end] {
+				if b.gateway.EnqueueMessage(id, task.message) == nil {
+					delivered++
+				}
+			}
+			b.mu.Lock()
+			task.progress.Sent = end
+			task.progress.Delivered += delivered
+			task.progress.Failed += end - i - delivered
+			b.mu.Unlock()
+			if end < len(task.clients) {
+				<-ticker.C
+			}
+		}
+		b.mu.Lock()
+		task.progress.Done = true
+		task.progress.FinishAt = time.Now().Unix()
+		b.mu.Unlock()
+	}
+}
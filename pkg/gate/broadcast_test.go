@@ -0,0 +1,53 @@
+package gate
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type broadcastGateway struct {
+	mockGateway
+	clients map[ID]Info
+
+	mu       sync.Mutex
+	received []ID
+}
+
+func (g *broadcastGateway) GetAll() map[ID]Info {
+	return g.clients
+}
+
+func (g *broadcastGateway) EnqueueMessage(id ID, message *messages.GlideMessage) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.received = append(g.received, id)
+	return nil
+}
+
+func TestBroadcaster_Broadcast(t *testing.T) {
+	g := &broadcastGateway{clients: map[ID]Info{}}
+	for _, id := range []ID{NewID("gw", "1", "1"), NewID("gw", "1", "2"), NewID("gw", "2", "2"), NewID("gw", "3", "2")} {
+		g.clients[id] = Info{ID: id, Version: "1.0"}
+	}
+	g.clients[NewID("gw", "4", "2")] = Info{ID: NewID("gw", "4", "2"), Version: "2.0"}
+
+	b := NewBroadcaster(g, 20)
+	p, err := b.Broadcast(messages.NewMessage(0, messages.ActionNotifyAnnouncement, &messages.Announcement{}),
+		&BroadcastFilter{Devices: []string{"2"}, Versions: []string{"1.0"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, p.Total)
+
+	assert.Eventually(t, func() bool {
+		return b.Progress(p.ID).Done
+	}, time.Second, time.Millisecond*10)
+
+	p = b.Progress(p.ID)
+	assert.Equal(t, 3, p.Sent)
+	assert.Equal(t, 3, p.Delivered)
+	assert.Equal(t, 0, p.Failed)
+	assert.NotContains(t, g.received, NewID("gw", "1", "1"))
+	assert.NotContains(t, g.received, NewID("gw", "4", "2"))
+}
@@ -10,6 +10,13 @@ var (
 	ClientTypeUser  = 2
 )
 
+// DeviceRobot is the device part of the ID of the bot client, it never collides with the device type of user clients.
+const DeviceRobot = "robot"
+
+// DeviceInternal is the device part of the ID used by the server to handle messages on behalf of the user, such as
+// delivering the scheduled messages, no client connects with it, so the replies to it are dropped.
+const DeviceInternal = "internal"
+
 // tempIdPrefix is the prefix for temporary IDs in the second part of the ID.
 const tempIdPrefix = "tmp@"
 
@@ -77,6 +84,16 @@ func (i *ID) SetDevice(device string) bool {
 	return true
 }
 
+// NewRobotID creates a new ID of the bot client connected with the robot credentials.
+func NewRobotID(uid string) ID {
+	return NewID("", uid, DeviceRobot)
+}
+
+// IsRobot returns true if the client is a bot authenticated with the robot credentials.
+func (i *ID) IsRobot() bool {
+	return i.Device() == DeviceRobot
+}
+
 // IsTemp returns true if the ID is a temporary.
 func (i *ID) IsTemp() bool {
 	return strings.HasPrefix(i.getPart(1), tempIdPrefix)
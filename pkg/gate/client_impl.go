@@ -19,6 +19,8 @@ const (
 	defaultHeartbeatDuration       = time.Second * 20
 	defaultHeartbeatLostLimit      = 3
 	defaultCloseImmediately        = false
+	// ephemeralDropThreshold the queued message count above which the ephemeral message is dropped instead of queued.
+	ephemeralDropThreshold = 10
 )
 
 // client state
@@ -182,6 +184,10 @@ func (c *UserClient) EnqueueMessage(msg *messages.GlideMessage) error {
 	if atomic.LoadInt32(&c.state) == stateClosed {
 		return errors.New("client has closed")
 	}
+	if msg.GetAction().IsEphemeral() && atomic.LoadInt64(&c.queuedMessage) > ephemeralDropThreshold {
+		logger.D("client queue under pressure, ephemeral message dropped, id=%v", c.info.ID)
+		return nil
+	}
 	logger.I("EnqueueMessage ID=%s msg=%v", c.info.ID, msg)
 	select {
 	case c.messages <- msg:
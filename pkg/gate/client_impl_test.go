@@ -176,6 +176,10 @@ func (m mockGateway) GetAll() map[ID]Info {
 	panic("implement me")
 }
 
+func (m mockGateway) IsOnline(id ID) bool {
+	return false
+}
+
 func (m mockGateway) SetMessageHandler(h MessageHandler) {
 	//TODO implement me
 	panic("implement me")
@@ -198,3 +202,16 @@ func (m mockGateway) ExitClient(id ID) error {
 func (m mockGateway) EnqueueMessage(id ID, message *messages.GlideMessage) error {
 	return nil
 }
+
+func TestClient_EnqueueEphemeralUnderPressure(t *testing.T) {
+	client := NewClient(&mockConnection{}, mockGateway{}, mockMsgHandler).(*UserClient)
+
+	for i := 0; i <= ephemeralDropThreshold; i++ {
+		err := client.EnqueueMessage(messages.NewMessage(1, messages.ActionHeartbeat, nil))
+		assert.NoError(t, err)
+	}
+	err := client.EnqueueMessage(messages.NewMessage(0, messages.ActionNotifyTyping, nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(ephemeralDropThreshold+1), client.queuedMessage)
+}
@@ -37,6 +37,18 @@ func TestID_IsTemp(t *testing.T) {
 	assert.False(t, id.IsTemp())
 }
 
+func TestID_IsRobot(t *testing.T) {
+	id := NewRobotID("bot")
+	assert.True(t, id.IsRobot())
+	assert.Equal(t, "bot", id.UID())
+
+	id = NewID2("uid")
+	assert.False(t, id.IsRobot())
+
+	id = NewID("", "uid", "1")
+	assert.False(t, id.IsRobot())
+}
+
 func TestID_UID(t *testing.T) {
 	id := NewID2("uid")
 	assert.Equal(t, "uid", id.UID())
@@ -51,6 +51,9 @@ type DefaultGateway interface {
 
 	GetAll() map[ID]Info
 
+	// IsOnline returns true if the client with the given id is connected to the gateway.
+	IsOnline(id ID) bool
+
 	SetMessageHandler(h MessageHandler)
 
 	AddClient(cs Client)
@@ -127,6 +130,16 @@ func (c *Impl) GetAll() map[ID]Info {
 	return result
 }
 
+// IsOnline returns true if the client with specified id is connected and running.
+func (c *Impl) IsOnline(id ID) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	id.SetGateway(c.id)
+	cli, ok := c.clients[id]
+	return ok && cli != nil && cli.IsRunning()
+}
+
 func (c *Impl) SetMessageHandler(h MessageHandler) {
 	c.msgHandler = h
 }
@@ -340,6 +353,10 @@ func (w *WebsocketGatewayServer) GetAll() map[ID]Info {
 	return w.decorator.GetAll()
 }
 
+func (w *WebsocketGatewayServer) IsOnline(id ID) bool {
+	return w.decorator.IsOnline(id)
+}
+
 func (w *WebsocketGatewayServer) AddClient(cs Client) {
 	w.decorator.AddClient(cs)
 }
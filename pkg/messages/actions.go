@@ -15,25 +15,54 @@ const (
 	ActionGroupMessage      = "message.group"
 	ActionGroupNotify       = "message.group.notify"
 	ActionClientCustom      = "message.cli"
+	ActionMessageRecall     = "message.recall"
+	ActionMessageEdit       = "message.edit"
+	ActionMessageReact      = "message.react"
 
 	ActionAuthenticate          = "authenticate"
 	ActionNotifyError           = "notify.error"
 	ActionNotifySuccess         = "notify.success"
 	ActionNotifyKickOut         = "notify.kickout"
 	ActionNotifyForbidden       = "notify.forbidden"
+	ActionNotifyDMRejected      = "notify.dm.rejected"
 	ActionNotifyUnauthenticated = "notify.unauthenticated"
 	ActionNotifyUserState       = "notify.state"
+	ActionNotifyRead            = "notify.read"
+	ActionNotifyTyping          = "notify.typing"
+	ActionNotifyMessageDeleted  = "notify.message.deleted"
+	ActionNotifyAnnouncement    = "notify.announcement"
+	ActionNotifyReaction        = "notify.reaction"
 
 	ActionAckRequest  = "ack.request"
 	ActionAckGroupMsg = "ack.group.msg"
 	ActionAckMessage  = "ack.message"
 	ActionAckNotify   = "ack.notify"
 	AckOffline        = "ack.offline"
+	ActionAckRead     = "ack.read"
 
-	ActionApiGroupMembers = "api.group.members"
-	ActionApiSubUserState = "api.state.sub"
-	ActionApiFailed       = "api.failed"
-	ActionApiSuccess      = "api.success"
+	ActionApiGroupMembers      = "api.group.members"
+	ActionApiGroupAckCoverage  = "api.group.coverage"
+	ActionApiSubUserState      = "api.state.sub"
+	ActionApiSchedule          = "api.schedule"
+	ActionApiScheduleEdit      = "api.schedule.edit"
+	ActionApiScheduleCancel    = "api.schedule.cancel"
+	ActionApiScheduleList      = "api.schedule.list"
+	ActionApiUnsubUserState    = "api.state.unsub"
+	ActionApiPresenceQuery     = "api.presence.query"
+	ActionApiPresenceUpdate    = "api.presence.update"
+	ActionApiPresencePrivacy   = "api.presence.privacy"
+	ActionApiOfflineSync       = "api.offline.sync"
+	ActionApiHistory           = "api.history"
+	ActionApiConversations     = "api.conversations"
+	ActionApiConversationFlags = "api.conversations.flags"
+	ActionApiPushSetting       = "api.push.setting"
+	ActionApiPushSettingGet    = "api.push.setting.get"
+	ActionApiBlock             = "api.block"
+	ActionApiBlockList         = "api.block.list"
+	ActionApiBroadcast         = "api.broadcast"
+	ActionApiBroadcastProgress = "api.broadcast.progress"
+	ActionApiFailed            = "api.failed"
+	ActionApiSuccess           = "api.success"
 
 	ActionInternalOnline  = "internal.online"
 	ActionInternalOffline = "internal.offline"
@@ -42,3 +71,8 @@ const (
 func (a Action) IsInternal() bool {
 	return strings.HasPrefix(string(a), "internal.")
 }
+
+// IsEphemeral returns true if the action is a live signal that is never stored and can be dropped under pressure.
+func (a Action) IsEphemeral() bool {
+	return a == ActionNotifyTyping
+}
@@ -0,0 +1,263 @@
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+const (
+	// MessageTypeText the content is the plain text.
+	MessageTypeText int32 = 1
+	// MessageTypeImage the content is the json of ImageContent.
+	MessageTypeImage int32 = 2
+	// MessageTypeFile the content is the json of FileContent.
+	MessageTypeFile int32 = 3
+	// MessageTypeLocation the content is the json of LocationContent.
+	MessageTypeLocation int32 = 4
+	// MessageTypeCard the content is the json of Card.
+	MessageTypeCard int32 = 5
+	// MessageTypeSystemEvent the content is the json of SystemEvent, sent by server only.
+	MessageTypeSystemEvent int32 = 6
+
+	// MessageTypeUserOnline the user online event of the world channel, the content is the uid, sent by server only.
+	MessageTypeUserOnline int32 = 100
+	// MessageTypeUserOffline the user offline event of the world channel, the content is the uid, sent by server only.
+	MessageTypeUserOffline int32 = 101
+)
+
+var (
+	ErrContentUnknownType = errors.New("unknown content type")
+	ErrContentTooLarge    = errors.New("content too large")
+	ErrContentInvalid     = errors.New("invalid content")
+	ErrContentServerOnly  = errors.New("content type is sent by server only")
+)
+
+// ImageContent 图片消息
+type ImageContent struct {
+	Url       string `json:"url,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Width     int32  `json:"width,omitempty"`
+	Height    int32  `json:"height,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+}
+
+// FileContent 文件消息
+type FileContent struct {
+	Url  string `json:"url,omitempty"`
+	Name string `json:"name,omitempty"`
+	Mime string `json:"mime,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// LocationContent 位置消息
+type LocationContent struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+}
+
+// SystemEvent 系统事件消息, 如成员加入频道等, 由客户端按 Event 展示
+type SystemEvent struct {
+	Event string      `json:"event,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// ContentType declares the content schema of a message type.
+type ContentType struct {
+	Type int32
+	Name string
+	// MaxSize the max bytes of the content, 0 express no limit
+	MaxSize int
+	// ServerOnly true express the message of the type can not be sent by clients
+	ServerOnly bool
+	// Validate checks the content, nil express any content is valid
+	Validate func(content string) error
+}
+
+// ContentRegistry 消息类型注册表, 声明每种消息类型的内容格式, 校验规则和大小限制.
+type ContentRegistry struct {
+	mu    sync.RWMutex
+	types map[int32]*ContentType
+
+	// AllowUnknown true express the message of the unregistered type is passed through without validation
+	AllowUnknown bool
+}
+
+func NewContentRegistry(allowUnknown bool) *ContentRegistry {
+	return &ContentRegistry{
+		types:        map[int32]*ContentType{},
+		AllowUnknown: allowUnknown,
+	}
+}
+
+// NewDefaultContentRegistry returns the registry with the built-in types registered.
+func NewDefaultContentRegistry(allowUnknown bool) *ContentRegistry {
+	r := NewContentRegistry(allowUnknown)
+	r.Register(&ContentType{
+		Type:     MessageTypeText,
+		Name:     "text",
+		MaxSize:  8 * 1024,
+		Validate: validateText,
+	})
+	r.Register(&ContentType{
+		Type:     MessageTypeImage,
+		Name:     "image",
+		MaxSize:  2 * 1024,
+		Validate: validateImage,
+	})
+	r.Register(&ContentType{
+		Type:     MessageTypeFile,
+		Name:     "file",
+		MaxSize:  2 * 1024,
+		Validate: validateFile,
+	})
+	r.Register(&ContentType{
+		Type:     MessageTypeLocation,
+		Name:     "location",
+		MaxSize:  1024,
+		Validate: validateLocation,
+	})
+	r.Register(&ContentType{
+		Type:     MessageTypeCard,
+		Name:     "card",
+		MaxSize:  8 * 1024,
+		Validate: validateCard,
+	})
+	r.Register(&ContentType{
+		Type:       MessageTypeSystemEvent,
+		Name:       "system_event",
+		MaxSize:    4 * 1024,
+		ServerOnly: true,
+		Validate:   validateSystemEvent,
+	})
+	r.Register(&ContentType{
+		Type:       MessageTypeUserOnline,
+		Name:       "user_online",
+		MaxSize:    256,
+		ServerOnly: true,
+		Validate:   validateText,
+	})
+	r.Register(&ContentType{
+		Type:       MessageTypeUserOffline,
+		Name:       "user_offline",
+		MaxSize:    256,
+		ServerOnly: true,
+		Validate:   validateText,
+	})
+	return r
+}
+
+// Register adds the type to the registry, replaces the type registered before.
+func (r *ContentRegistry) Register(t *ContentType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[t.Type] = t
+}
+
+// Get returns the registered type, nil if not registered.
+func (r *ContentRegistry) Get(t int32) *ContentType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.types[t]
+}
+
+// Validate checks the content of the message type sent by server.
+func (r *ContentRegistry) Validate(t int32, content string) error {
+	return r.validate(t, content, false)
+}
+
+// ValidateClient checks the content of the message type sent by client, the server only types are rejected.
+func (r *ContentRegistry) ValidateClient(t int32, content string) error {
+	return r.validate(t, content, true)
+}
+
+func (r *ContentRegistry) validate(t int32, content string, client bool) error {
+	ct := r.Get(t)
+	if ct == nil {
+		if r.AllowUnknown {
+			return nil
+		}
+		return ErrContentUnknownType
+	}
+	if client && ct.ServerOnly {
+		return ErrContentServerOnly
+	}
+	if ct.MaxSize > 0 && len(content) > ct.MaxSize {
+		return ErrContentTooLarge
+	}
+	if ct.Validate != nil {
+		return ct.Validate(content)
+	}
+	return nil
+}
+
+func decodeContent(content string, v interface{}) error {
+	if json.Unmarshal([]byte(content), v) != nil {
+		return ErrContentInvalid
+	}
+	return nil
+}
+
+func validateText(content string) error {
+	if content == "" {
+		return ErrContentInvalid
+	}
+	return nil
+}
+
+func validateImage(content string) error {
+	c := ImageContent{}
+	if err := decodeContent(content, &c); err != nil {
+		return err
+	}
+	if c.Url == "" || c.Width < 0 || c.Height < 0 || c.Size < 0 {
+		return ErrContentInvalid
+	}
+	return nil
+}
+
+func validateFile(content string) error {
+	c := FileContent{}
+	if err := decodeContent(content, &c); err != nil {
+		return err
+	}
+	if c.Url == "" || c.Name == "" || c.Size < 0 {
+		return ErrContentInvalid
+	}
+	return nil
+}
+
+func validateLocation(content string) error {
+	c := LocationContent{}
+	if err := decodeContent(content, &c); err != nil {
+		return err
+	}
+	if c.Latitude < -90 || c.Latitude > 90 || c.Longitude < -180 || c.Longitude > 180 {
+		return ErrContentInvalid
+	}
+	return nil
+}
+
+func validateCard(content string) error {
+	c := Card{}
+	if err := decodeContent(content, &c); err != nil {
+		return err
+	}
+	if c.Title == "" && c.Text == "" {
+		return ErrContentInvalid
+	}
+	return nil
+}
+
+func validateSystemEvent(content string) error {
+	c := SystemEvent{}
+	if err := decodeContent(content, &c); err != nil {
+		return err
+	}
+	if c.Event == "" {
+		return ErrContentInvalid
+	}
+	return nil
+}
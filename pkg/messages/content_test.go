@@ -0,0 +1,30 @@
+package messages
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestContentRegistry_Validate(t *testing.T) {
+	r := NewDefaultContentRegistry(false)
+
+	assert.NoError(t, r.ValidateClient(MessageTypeText, "hello"))
+	assert.Equal(t, ErrContentInvalid, r.ValidateClient(MessageTypeText, ""))
+	assert.Equal(t, ErrContentTooLarge, r.ValidateClient(MessageTypeText, strings.Repeat("a", 8*1024+1)))
+
+	assert.NoError(t, r.ValidateClient(MessageTypeImage, `{"url":"https://a/b.png","width":10,"height":10}`))
+	assert.Equal(t, ErrContentInvalid, r.ValidateClient(MessageTypeImage, `{"width":10}`))
+	assert.Equal(t, ErrContentInvalid, r.ValidateClient(MessageTypeFile, `not json`))
+	assert.NoError(t, r.ValidateClient(MessageTypeLocation, `{"latitude":31.2,"longitude":121.5}`))
+	assert.Equal(t, ErrContentInvalid, r.ValidateClient(MessageTypeLocation, `{"latitude":91,"longitude":0}`))
+	assert.NoError(t, r.ValidateClient(MessageTypeCard, `{"title":"t"}`))
+
+	assert.Equal(t, ErrContentServerOnly, r.ValidateClient(MessageTypeSystemEvent, `{"event":"join"}`))
+	assert.NoError(t, r.Validate(MessageTypeSystemEvent, `{"event":"join"}`))
+	assert.Equal(t, ErrContentServerOnly, r.ValidateClient(MessageTypeUserOnline, "1"))
+
+	assert.Equal(t, ErrContentUnknownType, r.ValidateClient(99, "x"))
+	r.AllowUnknown = true
+	assert.NoError(t, r.ValidateClient(99, "x"))
+}
@@ -1,5 +1,21 @@
 package messages
 
+const (
+	// ChatTypeSingle one-to-one chat, the conversation id is the uid of peer.
+	ChatTypeSingle int32 = 1
+	// ChatTypeChannel channel(group) chat, the conversation id is the channel id.
+	ChatTypeChannel int32 = 2
+)
+
+const (
+	// MessageStatusNormal the message is visible to the participants of the conversation.
+	MessageStatusNormal int32 = 0
+	// MessageStatusRecalled the message is recalled by the sender or the channel admin.
+	MessageStatusRecalled int32 = 1
+	// MessageStatusBlocked the single chat message sent to the user who blocked the sender, visible to the sender only.
+	MessageStatusBlocked int32 = 2
+)
+
 // ChatMessage chat message in single/group chat
 type ChatMessage struct {
 	/// client message id to identity unique a message.
@@ -12,6 +28,7 @@ type ChatMessage struct {
 	/// if this field is not empty that this message is server acked, need not store to database again.
 	Mid int64 `json:"mid,omitempty"`
 	/// message sequence for a chat, use to check message whether the message lost.
+	/// assigned by server for each single chat conversation when the sequence store configured, returned in the ack.
 	Seq int64 `json:"seq,omitempty"`
 	/// message sender
 	From string `json:"from,omitempty"`
@@ -23,6 +40,14 @@ type ChatMessage struct {
 	Content string `json:"content,omitempty"`
 	/// message send time, server store message time.
 	SendAt int64 `json:"sendAt,omitempty"`
+	/// TTL the seconds the message lives after sent, the message is deleted after expired, single chat only.
+	TTL int64 `json:"ttl,omitempty"`
+	/// BurnAfterRead the message is deleted after the receiver read it, single chat only.
+	BurnAfterRead bool `json:"burnAfterRead,omitempty"`
+	/// Reactions the aggregated reactions of the message, filled in the history query result.
+	Reactions []*ReactionSummary `json:"reactions,omitempty"`
+	/// Status the MessageStatusNormal, MessageStatusRecalled or MessageStatusBlocked of the stored message.
+	Status int32 `json:"status,omitempty"`
 }
 
 // ClientCustom client custom message, server does not store to database.
@@ -65,6 +90,392 @@ type AckNotify struct {
 	From   string `json:"from,omitempty"`
 }
 
+// ReadReceipt 已读回执, 客户端上报会话的已读位置, 服务端同步给读者的其他设备, 以及单聊的发送者或频道
+type ReadReceipt struct {
+	/// ChatType the chat type of the conversation, ChatTypeSingle or ChatTypeChannel
+	ChatType int32 `json:"chat_type,omitempty"`
+	/// the reader uid, set by server
+	From string `json:"from,omitempty"`
+	/// the peer uid or channel id of the conversation
+	To string `json:"to,omitempty"`
+	/// Seq the last read message seq of the conversation
+	Seq int64 `json:"seq,omitempty"`
+	/// Mid the mid of the read burn-after-read message, single chat only
+	Mid int64 `json:"mid,omitempty"`
+	/// ReadCount the count of channel members who have read the seq, channel only
+	ReadCount int64 `json:"read_count,omitempty"`
+}
+
+// Typing 正在输入, 仅转发给在线的会话成员, 不存储, 接收方在 TTL 后未收到新的 Typing 应自动清除输入状态
+type Typing struct {
+	/// ChatType the chat type of the conversation, ChatTypeSingle or ChatTypeChannel
+	ChatType int32 `json:"chat_type,omitempty"`
+	/// the typing user uid, set by server
+	From string `json:"from,omitempty"`
+	/// the peer uid or channel id of the conversation
+	To string `json:"to,omitempty"`
+	/// Typing false express the user stopped typing
+	Typing bool `json:"typing,omitempty"`
+	/// TTL seconds the typing state keeps on the receiving side, set by server
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// Recall 撤回消息, 客户端请求撤回消息, 撤回成功后服务端下发给会话中的所有设备
+type Recall struct {
+	/// mid of the message to recall
+	Mid int64 `json:"mid,omitempty"`
+	/// ChatType the chat type of the message, ChatTypeSingle or ChatTypeChannel
+	ChatType int32 `json:"chat_type,omitempty"`
+	/// the original sender of the message
+	From string `json:"from,omitempty"`
+	/// the receiver uid or channel id of the message
+	To string `json:"to,omitempty"`
+	/// the uid of who recalls the message, the sender or admin of the channel
+	RecallBy string `json:"recall_by,omitempty"`
+	RecallAt int64  `json:"recall_at,omitempty"`
+}
+
+// Reaction 表情回应, 客户端添加或取消对消息的回应, 成功后服务端以 ActionNotifyReaction 下发给会话中的所有设备
+type Reaction struct {
+	/// mid of the message to react
+	Mid int64 `json:"mid,omitempty"`
+	/// ChatType the chat type of the message, ChatTypeSingle or ChatTypeChannel
+	ChatType int32  `json:"chat_type,omitempty"`
+	Emoji    string `json:"emoji,omitempty"`
+	/// Remove true express cancel the reaction
+	Remove bool `json:"remove,omitempty"`
+	/// the uid of who reacts, set by server
+	Uid string `json:"uid,omitempty"`
+	/// the sender of the message
+	From string `json:"from,omitempty"`
+	/// the receiver uid or channel id of the message
+	To      string `json:"to,omitempty"`
+	ReactAt int64  `json:"react_at,omitempty"`
+	/// Reactions the aggregated reactions of the message after changed
+	Reactions []*ReactionSummary `json:"reactions,omitempty"`
+}
+
+// ReactionSummary 消息某个表情的回应数量, 以及最早回应的部分用户
+type ReactionSummary struct {
+	Emoji string   `json:"emoji,omitempty"`
+	Count int64    `json:"count,omitempty"`
+	Uids  []string `json:"uids,omitempty"`
+}
+
+// MessageEdit 编辑消息, 客户端请求编辑已发送的消息, 编辑成功后服务端作为编辑事件下发给会话中的所有设备
+type MessageEdit struct {
+	/// mid of the message to edit
+	Mid int64 `json:"mid,omitempty"`
+	/// ChatType the chat type of the message, ChatTypeSingle or ChatTypeChannel
+	ChatType int32 `json:"chat_type,omitempty"`
+	/// the sender of the message
+	From string `json:"from,omitempty"`
+	/// the receiver uid or channel id of the message
+	To string `json:"to,omitempty"`
+	/// the new content of the message
+	Content string `json:"content,omitempty"`
+	/// Revision the revision number assigned by server, increases on each edit, the original message is revision 0.
+	/// client should ignore the edit event which revision is less than the revision it has.
+	Revision int64 `json:"revision,omitempty"`
+	EditAt   int64 `json:"edit_at,omitempty"`
+}
+
+// OfflineSync 客户端拉取离线消息, 拉取 Cursor 之后的至多 Limit 条离线消息
+type OfflineSync struct {
+	/// Cursor the cursor of the last received offline message, 0 express from the beginning
+	Cursor int64 `json:"cursor,omitempty"`
+	/// Limit the page size
+	Limit int `json:"limit,omitempty"`
+}
+
+// OfflineMessage 离线消息, Cursor 为该消息在接收者离线消息中的位置
+type OfflineMessage struct {
+	Cursor  int64        `json:"cursor,omitempty"`
+	Message *ChatMessage `json:"message,omitempty"`
+}
+
+// OfflineMessages 离线消息拉取结果, 按消息时间和 seq 排序
+type OfflineMessages struct {
+	Messages []*OfflineMessage `json:"messages,omitempty"`
+	/// Cursor the max cursor of the messages, used to sync next page and ack
+	Cursor int64 `json:"cursor,omitempty"`
+	/// More true express there are more offline messages after the cursor
+	More bool `json:"more,omitempty"`
+}
+
+// OfflineAck 客户端确认收到离线消息, 服务端删除 Cursor 及之前的离线消息
+type OfflineAck struct {
+	Cursor int64 `json:"cursor,omitempty"`
+}
+
+const (
+	// HistoryBefore query the messages before the cursor, older messages.
+	HistoryBefore int32 = 0
+	// HistoryAfter query the messages after the cursor, newer messages.
+	HistoryAfter int32 = 1
+)
+
+// HistoryQuery 客户端查询会话历史消息
+type HistoryQuery struct {
+	/// ChatType the chat type of the conversation, ChatTypeSingle or ChatTypeChannel
+	ChatType int32 `json:"chat_type,omitempty"`
+	/// the peer uid or channel id of the conversation
+	To string `json:"to,omitempty"`
+	/// Cursor the mid of single chat or the seq of channel, 0 express from the latest or the first message
+	Cursor int64 `json:"cursor,omitempty"`
+	/// Direction HistoryBefore or HistoryAfter
+	Direction int32 `json:"direction,omitempty"`
+	Limit     int   `json:"limit,omitempty"`
+	/// Resume true express query the channel messages after the last acked seq of the requester, Cursor and
+	/// Direction are ignored
+	Resume bool `json:"resume,omitempty"`
+}
+
+// HistoryMessages 历史消息查询结果, 按消息从旧到新排序
+type HistoryMessages struct {
+	Messages []*ChatMessage `json:"messages,omitempty"`
+	/// More true express there may be more messages in the direction
+	More bool `json:"more,omitempty"`
+}
+
+// Conversation 会话列表中的会话, 包含最后一条消息预览, 最后活跃时间, 未读数以及置顶和免打扰标记
+type Conversation struct {
+	/// ChatType the chat type of the conversation, ChatTypeSingle or ChatTypeChannel
+	ChatType int32 `json:"chat_type,omitempty"`
+	/// ID the peer uid or channel id of the conversation
+	ID       string `json:"id,omitempty"`
+	LastMid  int64  `json:"last_mid,omitempty"`
+	LastFrom string `json:"last_from,omitempty"`
+	LastType int32  `json:"last_type,omitempty"`
+	/// Preview the preview of the last message content
+	Preview  string `json:"preview,omitempty"`
+	ActiveAt int64  `json:"active_at,omitempty"`
+	Unread   int64  `json:"unread,omitempty"`
+	Pinned   bool   `json:"pinned,omitempty"`
+	Muted    bool   `json:"muted,omitempty"`
+}
+
+// PushSetting 用户的离线推送设置
+type PushSetting struct {
+	/// Muted true express do not push any notification to the user
+	Muted bool `json:"muted,omitempty"`
+	/// DndStart, DndEnd the do-not-disturb period in minutes of the day, disabled when equal, crosses midnight when
+	/// the start is greater than the end
+	DndStart int32 `json:"dnd_start,omitempty"`
+	DndEnd   int32 `json:"dnd_end,omitempty"`
+	/// TzOffset the offset of the user timezone to UTC in minutes
+	TzOffset int32 `json:"tz_offset,omitempty"`
+}
+
+// BlockUpdate 拉黑或取消拉黑用户, 被拉黑的用户发送的消息被丢弃, 且看不到拉黑者的在线状态
+type BlockUpdate struct {
+	Uids []string `json:"uids,omitempty"`
+	/// Blocked true express block the users, otherwise unblock
+	Blocked bool `json:"blocked,omitempty"`
+}
+
+// BlockList 用户的黑名单
+type BlockList struct {
+	Uids []string `json:"uids,omitempty"`
+}
+
+// ConversationQuery 客户端分页查询会话列表
+type ConversationQuery struct {
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+}
+
+// Conversations 会话列表查询结果, 置顶会话在前, 其余按最后活跃时间倒序
+type Conversations struct {
+	Conversations []*Conversation `json:"conversations,omitempty"`
+	More          bool            `json:"more,omitempty"`
+}
+
+// ConversationFlags 客户端设置会话的置顶和免打扰
+type ConversationFlags struct {
+	ChatType int32  `json:"chat_type,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Pinned   bool   `json:"pinned,omitempty"`
+	Muted    bool   `json:"muted,omitempty"`
+}
+
+// GroupMembersQuery 客户端分页查询频道成员, 频道 id 为消息的 To
+type GroupMembersQuery struct {
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+}
+
+// GroupMember 频道成员
+type GroupMember struct {
+	Uid string `json:"uid,omitempty"`
+	/// Role the role of the member in channel, system, bot, admin, member or reader(read only)
+	Role string `json:"role,omitempty"`
+	/// Perm the permission bits of the member
+	Perm   int64 `json:"perm,omitempty"`
+	Online bool  `json:"online,omitempty"`
+}
+
+// GroupMembers 频道成员查询结果, 按 uid 排序
+type GroupMembers struct {
+	Members []*GroupMember `json:"members,omitempty"`
+	Total   int            `json:"total,omitempty"`
+	More    bool           `json:"more,omitempty"`
+}
+
+// GroupAckCoverage 频道消息的送达和已读覆盖, 如 "50 人中 42 人已读", 频道 id 为消息的 To
+type GroupAckCoverage struct {
+	/// Seq the seq of the channel message to query
+	Seq int64 `json:"seq,omitempty"`
+	/// Total the count of channel members
+	Total int64 `json:"total,omitempty"`
+	/// Delivered the count of members who have acked the seq
+	Delivered int64 `json:"delivered,omitempty"`
+	/// Read the count of members who have read the seq
+	Read int64 `json:"read,omitempty"`
+}
+
+const (
+	PresenceOffline int32 = 0
+	PresenceOnline  int32 = 1
+	PresenceAway    int32 = 2
+)
+
+const (
+	// PresencePrivacyEveryone everyone can see the presence.
+	PresencePrivacyEveryone int32 = 0
+	// PresencePrivacyAllowList only the users in allow list can see the presence.
+	PresencePrivacyAllowList int32 = 1
+	// PresencePrivacyNobody nobody can see the presence, the user is always offline for others.
+	PresencePrivacyNobody int32 = 2
+)
+
+// Presence 用户在线状态, 为用户所有设备状态的聚合, 任一设备在线则在线, 所有在线设备都离开则为离开
+type Presence struct {
+	Uid string `json:"uid,omitempty"`
+	/// State PresenceOnline, PresenceAway or PresenceOffline
+	State int32 `json:"state,omitempty"`
+	/// Online true if the State is not PresenceOffline
+	Online bool `json:"online,omitempty"`
+	/// LastSeen the time the last device of user went offline
+	LastSeen   int64  `json:"last_seen,omitempty"`
+	StatusText string `json:"status_text,omitempty"`
+}
+
+// PresenceUpdate 客户端设置当前设备的状态和用户的状态文本
+type PresenceUpdate struct {
+	/// State PresenceOnline or PresenceAway of current device, 0 express keep
+	State      int32  `json:"state,omitempty"`
+	StatusText string `json:"status_text,omitempty"`
+}
+
+// PresencePrivacy 客户端设置谁可以看到自己的在线状态
+type PresencePrivacy struct {
+	Privacy   int32    `json:"privacy,omitempty"`
+	AllowList []string `json:"allow_list,omitempty"`
+}
+
+// PresenceQuery 客户端查询用户的在线状态
+type PresenceQuery struct {
+	Uids []string `json:"uids,omitempty"`
+}
+
+// PresenceList 在线状态查询结果
+type PresenceList struct {
+	Presences []*Presence `json:"presences,omitempty"`
+}
+
+const (
+	MessageDeletedExpired = "expired"
+	MessageDeletedRead    = "read"
+)
+
+// MessageDeleted 阅后即焚或到期的消息被删除, 通知会话双方的所有设备
+type MessageDeleted struct {
+	ChatType int32  `json:"chat_type,omitempty"`
+	Mid      int64  `json:"mid,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	/// Reason MessageDeletedExpired or MessageDeletedRead
+	Reason string `json:"reason,omitempty"`
+}
+
+// Announcement 系统公告, 由服务端推送到所有在线的客户端
+type Announcement struct {
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content,omitempty"`
+	SendAt  int64  `json:"send_at,omitempty"`
+}
+
+// BroadcastRequest 管理员推送系统公告的请求, 过滤条件为空时推送给所有在线客户端, 查询推送进度时只需要 ID
+type BroadcastRequest struct {
+	ID       int64    `json:"id,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Content  string   `json:"content,omitempty"`
+	Devices  []string `json:"devices,omitempty"`
+	Versions []string `json:"versions,omitempty"`
+	Uids     []string `json:"uids,omitempty"`
+}
+
+// ScheduledMessage 定时消息, 在 DeliverAt 时由服务端以发送者的身份投递
+type ScheduledMessage struct {
+	/// ID the id of scheduled message, assigned by server
+	ID int64 `json:"id,omitempty"`
+	/// ChatType ChatTypeSingle or ChatTypeChannel
+	ChatType int32 `json:"chat_type,omitempty"`
+	/// Message the message to deliver, the Message.To is the peer uid or the channel id
+	Message *ChatMessage `json:"message,omitempty"`
+	/// DeliverAt the unix time in seconds to deliver the message
+	DeliverAt int64 `json:"deliver_at,omitempty"`
+	CreateAt  int64 `json:"create_at,omitempty"`
+}
+
+// ScheduledEdit 客户端修改或取消未投递的定时消息
+type ScheduledEdit struct {
+	ID int64 `json:"id,omitempty"`
+	/// Content the new content, empty express keep
+	Content string `json:"content,omitempty"`
+	/// DeliverAt the new delivery time, 0 express keep
+	DeliverAt int64 `json:"deliver_at,omitempty"`
+}
+
+// ScheduledMessages 用户未投递的定时消息
+type ScheduledMessages struct {
+	Messages []*ScheduledMessage `json:"messages,omitempty"`
+}
+
+// Card 卡片消息, 机器人的富文本回复等
+type Card struct {
+	Title   string        `json:"title,omitempty"`
+	Text    string        `json:"text,omitempty"`
+	Image   string        `json:"image,omitempty"`
+	Fields  []*CardField  `json:"fields,omitempty"`
+	Buttons []*CardButton `json:"buttons,omitempty"`
+}
+
+type CardField struct {
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// CardButton 卡片按钮, 点击后发送 Command 或打开 Url
+type CardButton struct {
+	Text    string `json:"text,omitempty"`
+	Command string `json:"command,omitempty"`
+	Url     string `json:"url,omitempty"`
+}
+
+// Forbidden 服务端拒绝客户端的消息, 通过 ActionNotifyForbidden 或 ActionNotifyDMRejected 通知发送者
+type Forbidden struct {
+	/// Code the reason code of the rejection
+	Code string `json:"code,omitempty"`
+	/// Action the action of the rejected message
+	Action string `json:"action,omitempty"`
+	/// CliMid the client message id of the rejected chat message
+	CliMid string `json:"cli_mid,omitempty"`
+	/// To the receiver of the rejected message
+	To string `json:"to,omitempty"`
+}
+
 type KickOutNotify struct {
 	DeviceId   string `json:"device_id,omitempty"`
 	DeviceName string `json:"device_name,omitempty"`
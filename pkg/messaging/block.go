@@ -0,0 +1,162 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+)
+
+// maxBlockList the max count of users in the block list of a user
+const maxBlockList = 1000
+
+var (
+	ErrBlockInvalid = errors.New("invalid block request")
+	ErrBlockTooMany = errors.New("too many blocked users")
+)
+
+// BlockService 用户黑名单, 被拉黑的用户发送的单聊消息照常保存和回执但不投递, 自定义消息, 正在输入和表情回应被丢弃,
+// 且只能看到拉黑者离线.
+// 黑名单保存在 store.BlockStore 中, 多个节点共享 store 时黑名单在节点间一致.
+type BlockService struct {
+	store store.BlockStore
+
+	// onChange called after the block list of uid changed
+	onChange func(uid string)
+}
+
+func newBlockService(s store.BlockStore) *BlockService {
+	return &BlockService{
+		store: s,
+	}
+}
+
+// Block adds the users to the block list of uid, returns the block list after changed.
+func (b *BlockService) Block(uid string, uids []string) ([]string, error) {
+	uids = filterBlockUids(uid, uids)
+	if uid == "" || len(uids) == 0 {
+		return nil, ErrBlockInvalid
+	}
+	blocked, err := b.store.GetBlocked(uid)
+	if err != nil {
+		return nil, err
+	}
+	count := len(blocked)
+	for _, u := range uids {
+		if !contains(blocked, u) {
+			count++
+		}
+	}
+	if count > maxBlockList {
+		return nil, ErrBlockTooMany
+	}
+	err = b.store.Block(uid, uids...)
+	if err != nil {
+		return nil, err
+	}
+	return b.changed(uid)
+}
+
+// Unblock removes the users from the block list of uid, returns the block list after changed.
+func (b *BlockService) Unblock(uid string, uids []string) ([]string, error) {
+	uids = filterBlockUids(uid, uids)
+	if uid == "" || len(uids) == 0 {
+		return nil, ErrBlockInvalid
+	}
+	err := b.store.Unblock(uid, uids...)
+	if err != nil {
+		return nil, err
+	}
+	return b.changed(uid)
+}
+
+func (b *BlockService) changed(uid string) ([]string, error) {
+	if b.onChange != nil {
+		b.onChange(uid)
+	}
+	return b.store.GetBlocked(uid)
+}
+
+// List returns the block list of uid.
+func (b *BlockService) List(uid string) ([]string, error) {
+	return b.store.GetBlocked(uid)
+}
+
+// IsBlocked returns true if uid has blocked from, the error is logged and treated as not blocked.
+func (b *BlockService) IsBlocked(uid string, from string) bool {
+	if b == nil || uid == "" || from == "" || uid == from {
+		return false
+	}
+	blocked, err := b.store.IsBlocked(uid, from)
+	if err != nil {
+		logger.E("check block list error %v", err)
+		return false
+	}
+	return blocked
+}
+
+// filterBlockUids removes the empty, duplicated and self uid.
+func filterBlockUids(uid string, uids []string) []string {
+	var result []string
+	for _, u := range uids {
+		if u != "" && u != uid && !contains(result, u) {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocks returns the block list service.
+func (d *MessageHandlerImpl) Blocks() *BlockService {
+	return d.blocks
+}
+
+// blockReply returns the reply of the error of the block service, the validation errors are replied to client.
+func blockReply(seq int64, data interface{}, err error) (*messages.GlideMessage, error) {
+	switch err {
+	case nil:
+		return messages.NewMessage(seq, messages.ActionApiSuccess, data), nil
+	case ErrBlockInvalid, ErrBlockTooMany:
+		return messages.NewMessage(seq, messages.ActionApiFailed, err.Error()), nil
+	}
+	logger.E("block list error %v", err)
+	return nil, err
+}
+
+// handleApiBlock 拉黑或取消拉黑用户, 返回修改后的黑名单
+func (d *MessageHandlerImpl) handleApiBlock(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if c.ID.IsTemp() {
+		return blockReply(m.GetSeq(), nil, ErrBlockInvalid)
+	}
+	req := new(messages.BlockUpdate)
+	if !d.unmarshalData(c, m, req) {
+		return blockReply(m.GetSeq(), nil, ErrBlockInvalid)
+	}
+	var uids []string
+	var err error
+	if req.Blocked {
+		uids, err = d.blocks.Block(c.ID.UID(), req.Uids)
+	} else {
+		uids, err = d.blocks.Unblock(c.ID.UID(), req.Uids)
+	}
+	return blockReply(m.GetSeq(), &messages.BlockList{Uids: uids}, err)
+}
+
+// handleApiBlockList 查询黑名单
+func (d *MessageHandlerImpl) handleApiBlockList(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if c.ID.IsTemp() {
+		return blockReply(m.GetSeq(), nil, ErrBlockInvalid)
+	}
+	uids, err := d.blocks.List(c.ID.UID())
+	return blockReply(m.GetSeq(), &messages.BlockList{Uids: uids}, err)
+}
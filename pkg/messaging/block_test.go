@@ -0,0 +1,116 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBlockService_HandleChatMessage(t *testing.T) {
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{gate.NewID2("1"): true, gate.NewID2("2"): true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	ms := store.NewMemoryMessageStore(0)
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore:      ms,
+		HistoryStore:      ms,
+		ChatSequenceStore: store.NewMemoryChatSequenceStore(10),
+		ReactionStore:     store.NewMemoryReactionStore(),
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	uids, err := handler.Blocks().Block("2", []string{"1", "2", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, uids)
+	assert.True(t, handler.Blocks().IsBlocked("2", "1"))
+	assert.False(t, handler.Blocks().IsBlocked("1", "2"))
+
+	send := func(from string, to string) {
+		msg := messages.NewMessage(1, messages.ActionChatMessage, &messages.ChatMessage{CliMid: from, Content: "hi"})
+		msg.To = to
+		assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2(from)}, msg))
+	}
+
+	// the blocked sender is acked with the mid as usual, the receiver receives nothing, the seq is not assigned
+	send("1", "2")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionAckMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Empty(t, g.get(gate.NewID2("2"), messages.ActionChatMessage))
+	ack := messages.AckMessage{}
+	assert.NoError(t, g.get(gate.NewID2("1"), messages.ActionAckMessage)[0].Data.Deserialize(&ack))
+	assert.NotZero(t, ack.Mid)
+	assert.Zero(t, ack.Seq)
+
+	// the blocked message is visible to the sender only
+	history := func(uid string, peer string) []*messages.ChatMessage {
+		reply, err := handler.handleApiHistory(&gate.Info{ID: gate.NewID2(uid)}, messages.NewMessage(1, messages.ActionApiHistory, &messages.HistoryQuery{
+			ChatType: messages.ChatTypeSingle,
+			To:       peer,
+		}))
+		assert.NoError(t, err)
+		result := messages.HistoryMessages{}
+		assert.NoError(t, reply.Data.Deserialize(&result))
+		return result.Messages
+	}
+	assert.Empty(t, history("2", "1"))
+	sent := history("1", "2")
+	assert.Len(t, sent, 1)
+	assert.Equal(t, ack.Mid, sent[0].Mid)
+	assert.Equal(t, messages.MessageStatusNormal, sent[0].Status)
+	react := messages.NewMessage(2, messages.ActionMessageReact, &messages.Reaction{
+		ChatType: messages.ChatTypeSingle,
+		Mid:      ack.Mid,
+		Emoji:    "+1",
+	})
+	assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2("2")}, react))
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("2"), messages.ActionNotifyError)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	send("2", "1")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionChatMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	// the reaction of the blocked user to the message of the blocker is dropped
+	received := messages.ChatMessage{}
+	assert.NoError(t, g.get(gate.NewID2("1"), messages.ActionChatMessage)[0].Data.Deserialize(&received))
+	react = messages.NewMessage(2, messages.ActionMessageReact, &messages.Reaction{
+		ChatType: messages.ChatTypeSingle,
+		Mid:      received.Mid,
+		Emoji:    "+1",
+	})
+	assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2("1")}, react))
+	time.Sleep(time.Millisecond * 50)
+	assert.Empty(t, g.get(gate.NewID2("2"), messages.ActionNotifyReaction))
+	reactions, err := handler.reactions.GetReactions(messages.ChatTypeSingle, 10, received.Mid)
+	assert.NoError(t, err)
+	assert.Empty(t, reactions[received.Mid])
+
+	// the presence is hidden from the blocked user only
+	handler.userState.onUserOnline(gate.NewID2("2"))
+	p, err := handler.userState.presenceFor("1", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), p.State)
+	p, err = handler.userState.presenceFor("3", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(messages.PresenceOnline), p.State)
+
+	_, err = handler.Blocks().Unblock("2", []string{"1"})
+	assert.NoError(t, err)
+	send("1", "2")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("2"), messages.ActionChatMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	// the seq of the receiver continues without the gap of the blocked message
+	received = messages.ChatMessage{}
+	assert.NoError(t, g.get(gate.NewID2("2"), messages.ActionChatMessage)[0].Data.Deserialize(&received))
+	assert.Equal(t, int64(2), received.Seq)
+}
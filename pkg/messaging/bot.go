@@ -0,0 +1,420 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBotRate the default max messages a bot sends per second
+	defaultBotRate = 5
+
+	// ForbiddenCodeRateLimit the reason code of the message of bot exceeds the rate limit.
+	ForbiddenCodeRateLimit = "rate_limit"
+
+	botCommandPrefix = "/"
+	botMentionSep    = "@"
+)
+
+var ErrBotRateLimited = errors.New("bot rate limited")
+
+// BotCommand the command sent to bots, the message content like "/deploy@ops_bot app v1.2".
+type BotCommand struct {
+	// Name the command name without prefix, "deploy"
+	Name string
+	// Mention the bot the command sent to, "ops_bot", empty express all bots
+	Mention string
+	// Args the arguments split by spaces, ["app", "v1.2"]
+	Args []string
+	// ArgText the raw text after the command name, "app v1.2"
+	ArgText string
+}
+
+// ParseBotCommand parses the command in the message content, returns false if the content is not a command.
+func ParseBotCommand(content string) (*BotCommand, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, botCommandPrefix) {
+		return nil, false
+	}
+	content = content[len(botCommandPrefix):]
+	name := content
+	argText := ""
+	if i := strings.IndexAny(content, " \t\n"); i >= 0 {
+		name = content[:i]
+		argText = strings.TrimSpace(content[i+1:])
+	}
+	cmd := &BotCommand{
+		Name:    name,
+		ArgText: argText,
+		Args:    strings.Fields(argText),
+	}
+	if i := strings.Index(name, botMentionSep); i >= 0 {
+		cmd.Name = name[:i]
+		cmd.Mention = name[i+1:]
+	}
+	if cmd.Name == "" {
+		return nil, false
+	}
+	return cmd, true
+}
+
+// BotContext the message received by the in-process bot, and the replies to the conversation of the message.
+type BotContext struct {
+	// Bot the uid of the bot
+	Bot string
+	// ChatType the conversation of the message, ChatTypeSingle with From or ChatTypeChannel of To
+	ChatType int32
+	From     string
+	To       string
+	Message  *messages.ChatMessage
+	// Command the command in the message, nil if the message is not a command
+	Command *BotCommand
+
+	h *MessageHandlerImpl
+}
+
+// Reply replies the text to the conversation of the message.
+func (c *BotContext) Reply(text string) error {
+	return c.ReplyMessage(messages.MessageTypeText, text)
+}
+
+// ReplyCard replies the rich card to the conversation of the message.
+func (c *BotContext) ReplyCard(card *messages.Card) error {
+	b, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	return c.ReplyMessage(messages.MessageTypeCard, string(b))
+}
+
+// ReplyMessage replies the message of type to the conversation of the message.
+func (c *BotContext) ReplyMessage(typ int32, content string) error {
+	to := c.From
+	if c.ChatType == messages.ChatTypeChannel {
+		to = c.To
+	}
+	return c.h.SendBotMessage(c.Bot, c.ChatType, to, typ, content)
+}
+
+// Bot the in-process bot, registered by MessageHandlerImpl.RegisterBot. The single chat messages sent to the bot, and
+// the commands sent to the channels the bot is a member with the bot role are delivered to it.
+type Bot interface {
+	OnMessage(ctx *BotContext) error
+}
+
+// BotFunc adapts the function to Bot.
+type BotFunc func(ctx *BotContext) error
+
+func (f BotFunc) OnMessage(ctx *BotContext) error {
+	return f(ctx)
+}
+
+type botCommand struct {
+	usage string
+	fn    BotFunc
+}
+
+// CommandBot is a Bot dispatches the commands to the handlers by name, the built-in "help" command replies the usage
+// of all commands.
+type CommandBot struct {
+	commands map[string]*botCommand
+	// Fallback handles the messages are not command or the commands are unknown, the unknown command is replied with
+	// the usage if nil.
+	Fallback BotFunc
+}
+
+func NewCommandBot() *CommandBot {
+	return &CommandBot{
+		commands: map[string]*botCommand{},
+	}
+}
+
+// Command registers the handler of the command name.
+func (b *CommandBot) Command(name string, usage string, fn BotFunc) *CommandBot {
+	b.commands[name] = &botCommand{usage: usage, fn: fn}
+	return b
+}
+
+func (b *CommandBot) OnMessage(ctx *BotContext) error {
+	if ctx.Command != nil {
+		if c, ok := b.commands[ctx.Command.Name]; ok {
+			return c.fn(ctx)
+		}
+	}
+	if b.Fallback != nil {
+		return b.Fallback(ctx)
+	}
+	if ctx.Command == nil {
+		return nil
+	}
+	return ctx.ReplyCard(b.help())
+}
+
+func (b *CommandBot) help() *messages.Card {
+	card := &messages.Card{Title: "Commands"}
+	names := make([]string, 0, len(b.commands))
+	for name := range b.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		card.Fields = append(card.Fields, &messages.CardField{
+			Name:  botCommandPrefix + name,
+			Value: b.commands[name].usage,
+		})
+	}
+	return card
+}
+
+// BotOptions the options of the in-process bot.
+type BotOptions struct {
+	// Rate the max messages the bot sends per second, MessageHandlerOptions.BotRate is used if zero.
+	Rate int
+	// Burst the max messages the bot sends at once, equals Rate if zero.
+	Burst int
+}
+
+type registeredBot struct {
+	bot  Bot
+	opts BotOptions
+}
+
+type tokenBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// botLimiter limits the message rate of each bot by token bucket.
+type botLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newBotLimiter() *botLimiter {
+	return &botLimiter{
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// allow takes a token of the bot, returns false if no token left.
+func (l *botLimiter) allow(uid string, rate int, burst int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[uid]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), at: now}
+		l.buckets[uid] = b
+	}
+	b.tokens += now.Sub(b.at).Seconds() * float64(rate)
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.at = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// bots the in-process bots and the rate limiter of all bots.
+type bots struct {
+	mu   sync.RWMutex
+	bots map[string]*registeredBot
+
+	limiter *botLimiter
+	// rate, burst the default rate limit of bots
+	rate  int
+	burst int
+}
+
+func newBots(rate int, burst int) *bots {
+	if rate <= 0 {
+		rate = defaultBotRate
+	}
+	if burst <= 0 {
+		burst = rate
+	}
+	return &bots{
+		bots:    map[string]*registeredBot{},
+		limiter: newBotLimiter(),
+		rate:    rate,
+		burst:   burst,
+	}
+}
+
+func (b *bots) get(uid string) *registeredBot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bots[uid]
+}
+
+// allow returns true if the bot does not exceed the rate limit.
+func (b *bots) allow(uid string) bool {
+	rate, burst := b.rate, b.burst
+	if r := b.get(uid); r != nil {
+		if r.opts.Rate > 0 {
+			rate = r.opts.Rate
+			burst = r.opts.Rate
+		}
+		if r.opts.Burst > 0 {
+			burst = r.opts.Burst
+		}
+	}
+	return b.limiter.allow(uid, rate, burst)
+}
+
+// Handle limits the messages sent by the bots connected with the robot credentials, the messages exceed the rate
+// limit are rejected with messages.ActionNotifyForbidden.
+func (b *bots) Handle(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
+	if !cliInfo.ID.IsRobot() {
+		return false
+	}
+	switch message.GetAction() {
+	case messages.ActionChatMessage, messages.ActionGroupMessage, messages.ActionClientCustom:
+	default:
+		return false
+	}
+	if b.get(cliInfo.ID.UID()) != nil {
+		// the in-process bot is limited when sending
+		return false
+	}
+	if b.allow(cliInfo.ID.UID()) {
+		return false
+	}
+	cm := messages.ChatMessage{}
+	_ = message.Data.Deserialize(&cm)
+	notify := messages.NewMessage(message.GetSeq(), messages.ActionNotifyForbidden, &messages.Forbidden{
+		Code:   ForbiddenCodeRateLimit,
+		Action: string(message.GetAction()),
+		CliMid: cm.CliMid,
+	})
+	err := h.GetClientInterface().EnqueueMessage(cliInfo.ID, notify)
+	if err != nil {
+		logger.E("notify forbidden error %v", err)
+	}
+	return true
+}
+
+// RegisterBot registers the in-process bot of uid, replaces the bot registered before, opts is optional.
+func (d *MessageHandlerImpl) RegisterBot(uid string, bot Bot, opts *BotOptions) {
+	r := &registeredBot{bot: bot}
+	if opts != nil {
+		r.opts = *opts
+	}
+	d.bots.mu.Lock()
+	defer d.bots.mu.Unlock()
+	d.bots.bots[uid] = r
+}
+
+// UnregisterBot removes the in-process bot of uid.
+func (d *MessageHandlerImpl) UnregisterBot(uid string) {
+	d.bots.mu.Lock()
+	defer d.bots.mu.Unlock()
+	delete(d.bots.bots, uid)
+}
+
+// SendBotMessage sends the message from the bot to the user or the channel, the message goes through the handlers as
+// the message sent by the bot client, returns ErrBotRateLimited if the bot exceeds the rate limit.
+func (d *MessageHandlerImpl) SendBotMessage(bot string, chatType int32, to string, typ int32, content string) error {
+	if !d.bots.allow(bot) {
+		return ErrBotRateLimited
+	}
+	var action messages.Action = messages.ActionChatMessage
+	if chatType == messages.ChatTypeChannel {
+		action = messages.ActionGroupMessage
+	}
+	msg := messages.NewMessage(0, action, &messages.ChatMessage{
+		From:    bot,
+		To:      to,
+		Type:    typ,
+		Content: content,
+	})
+	msg.To = to
+	return d.def.Handle(&gate.Info{ID: gate.NewRobotID(bot)}, msg)
+}
+
+// invokeBot delivers the message to the bot in background.
+func (d *MessageHandlerImpl) invokeBot(uid string, r *registeredBot, ctx *BotContext) {
+	ctx.Bot = uid
+	ctx.h = d
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				logger.E("bot %s panic: %v", uid, e)
+			}
+		}()
+		err := r.bot.OnMessage(ctx)
+		if err != nil {
+			logger.E("bot %s handle message error %v", uid, err)
+		}
+	}()
+}
+
+// dispatchBot 单聊消息的接收者是进程内的机器人时交给机器人处理, 返回 true 表示已投递
+func (d *MessageHandlerImpl) dispatchBot(msg *messages.ChatMessage) bool {
+	r := d.bots.get(msg.To)
+	if r == nil {
+		return false
+	}
+	cmd, _ := ParseBotCommand(msg.Content)
+	d.invokeBot(msg.To, r, &BotContext{
+		ChatType: messages.ChatTypeSingle,
+		From:     msg.From,
+		To:       msg.To,
+		Message:  msg,
+		Command:  cmd,
+	})
+	return true
+}
+
+// dispatchChannelBots 频道中的命令投递给频道中有机器人角色的进程内机器人, 指定了机器人的命令只投递给该机器人
+func (d *MessageHandlerImpl) dispatchChannelBots(ch string, from string, msg *messages.ChatMessage) {
+	cmd, ok := ParseBotCommand(msg.Content)
+	if !ok {
+		return
+	}
+	q, ok := d.def.GetGroupInterface().(subscription_impl.SubscriberQuery)
+	if !ok {
+		return
+	}
+
+	var targets []string
+	if cmd.Mention != "" {
+		targets = []string{cmd.Mention}
+	} else {
+		d.bots.mu.RLock()
+		for uid := range d.bots.bots {
+			targets = append(targets, uid)
+		}
+		d.bots.mu.RUnlock()
+	}
+	for _, uid := range targets {
+		r := d.bots.get(uid)
+		if r == nil || uid == from {
+			continue
+		}
+		info, err := q.GetSubscriber(subscription.ChanID(ch), subscription.SubscriberID(uid))
+		if err != nil || !info.IsBot() {
+			continue
+		}
+		d.invokeBot(uid, r, &BotContext{
+			ChatType: messages.ChatTypeChannel,
+			From:     from,
+			To:       ch,
+			Message:  msg,
+			Command:  cmd,
+		})
+	}
+}
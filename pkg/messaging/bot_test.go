@@ -0,0 +1,126 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockBotGateway records the messages enqueued to the online clients.
+type mockBotGateway struct {
+	mu       sync.Mutex
+	online   map[gate.ID]bool
+	received map[gate.ID][]*messages.GlideMessage
+}
+
+func (m *mockBotGateway) SetClientID(old gate.ID, new_ gate.ID) error { return nil }
+
+func (m *mockBotGateway) UpdateClient(id gate.ID, info *gate.ClientSecrets) error { return nil }
+
+func (m *mockBotGateway) ExitClient(id gate.ID) error { return nil }
+
+func (m *mockBotGateway) EnqueueMessage(id gate.ID, message *messages.GlideMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.online[id] {
+		return errors.New("client does not exist")
+	}
+	m.received[id] = append(m.received[id], message)
+	return nil
+}
+
+func (m *mockBotGateway) GetClient(id gate.ID) gate.Client { return nil }
+
+func (m *mockBotGateway) GetAll() map[gate.ID]gate.Info {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := map[gate.ID]gate.Info{}
+	for id, online := range m.online {
+		if online {
+			all[id] = gate.Info{ID: id}
+		}
+	}
+	return all
+}
+
+func (m *mockBotGateway) IsOnline(id gate.ID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[id]
+}
+
+func (m *mockBotGateway) SetMessageHandler(h gate.MessageHandler) {}
+
+func (m *mockBotGateway) AddClient(cs gate.Client) {}
+
+func (m *mockBotGateway) get(id gate.ID, action messages.Action) []*messages.GlideMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*messages.GlideMessage
+	for _, msg := range m.received[id] {
+		if msg.GetAction() == action {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func TestParseBotCommand(t *testing.T) {
+	cmd, ok := ParseBotCommand(" /deploy@ops_bot app  v1.2 ")
+	assert.True(t, ok)
+	assert.Equal(t, "deploy", cmd.Name)
+	assert.Equal(t, "ops_bot", cmd.Mention)
+	assert.Equal(t, []string{"app", "v1.2"}, cmd.Args)
+	assert.Equal(t, "app  v1.2", cmd.ArgText)
+
+	cmd, ok = ParseBotCommand("/help")
+	assert.True(t, ok)
+	assert.Equal(t, "help", cmd.Name)
+	assert.Empty(t, cmd.Args)
+
+	_, ok = ParseBotCommand("hello /help")
+	assert.False(t, ok)
+	_, ok = ParseBotCommand("/ help")
+	assert.False(t, ok)
+}
+
+func TestMessageHandlerImpl_RegisterBot(t *testing.T) {
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{gate.NewID2("1"): true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore: &store.IdleMessageStore{},
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	handler.RegisterBot("echo", NewCommandBot().Command("echo", "echo the args", func(ctx *BotContext) error {
+		return ctx.Reply(ctx.Command.ArgText)
+	}), &BotOptions{Rate: 1})
+
+	send := func(content string) {
+		msg := messages.NewMessage(1, messages.ActionChatMessage, &messages.ChatMessage{To: "echo", Content: content})
+		msg.To = "echo"
+		assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2("1")}, msg))
+	}
+	send("/echo hello")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionChatMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	reply := messages.ChatMessage{}
+	_ = g.get(gate.NewID2("1"), messages.ActionChatMessage)[0].Data.Deserialize(&reply)
+	assert.Equal(t, "echo", reply.From)
+	assert.Equal(t, "hello", reply.Content)
+
+	// the reply exceeds the rate limit is dropped
+	send("/echo again")
+	time.Sleep(time.Millisecond * 100)
+	assert.Len(t, g.get(gate.NewID2("1"), messages.ActionChatMessage), 1)
+}
@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"time"
+)
+
+const (
+	errBroadcastNotSupported = "broadcast is not supported"
+	errBroadcastPermission   = "permission denied: broadcast"
+	errBroadcastInvalid      = "invalid broadcast request"
+	errBroadcastNotFound     = "broadcast not found"
+)
+
+// isAdmin returns true if the client is allowed to call the admin apis.
+func (d *MessageHandlerImpl) isAdmin(c *gate.Info) bool {
+	return !c.ID.IsTemp() && !c.ID.IsRobot() && contains(d.admins, c.ID.UID())
+}
+
+// checkBroadcast returns the reason the client can not call the broadcast api, empty if allowed.
+func (d *MessageHandlerImpl) checkBroadcast(c *gate.Info) string {
+	if d.broadcaster == nil {
+		return errBroadcastNotSupported
+	}
+	if !d.isAdmin(c) {
+		return errBroadcastPermission
+	}
+	return ""
+}
+
+// handleApiBroadcast 管理员推送系统公告给本节点的在线客户端, 返回推送进度. 每个节点的推送和进度相互独立, 推送给所有节点的
+// 客户端需要通过 rpc 调用每个节点
+func (d *MessageHandlerImpl) handleApiBroadcast(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if reason := d.checkBroadcast(c); reason != "" {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, reason), nil
+	}
+	req := new(messages.BroadcastRequest)
+	if m.Data == nil || m.Data.Deserialize(req) != nil || (req.Title == "" && req.Content == "") {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errBroadcastInvalid), nil
+	}
+	a := messages.NewMessage(0, messages.ActionNotifyAnnouncement, &messages.Announcement{
+		Title:   req.Title,
+		Content: req.Content,
+		SendAt:  time.Now().Unix(),
+	})
+	p, err := d.broadcaster.Broadcast(a, &gate.BroadcastFilter{
+		Devices:  req.Devices,
+		Versions: req.Versions,
+		Uids:     req.Uids,
+	})
+	if err != nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, err.Error()), nil
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, p), nil
+}
+
+// handleApiBroadcastProgress 查询本节点推送系统公告的进度
+func (d *MessageHandlerImpl) handleApiBroadcastProgress(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if reason := d.checkBroadcast(c); reason != "" {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, reason), nil
+	}
+	req := new(messages.BroadcastRequest)
+	if m.Data == nil || m.Data.Deserialize(req) != nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errBroadcastInvalid), nil
+	}
+	p := d.broadcaster.Progress(req.ID)
+	if p == nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errBroadcastNotFound), nil
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, p), nil
+}
@@ -0,0 +1,51 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageHandlerImpl_ApiBroadcast(t *testing.T) {
+	admin, user := gate.NewID2("admin"), gate.NewID2("1")
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{admin: true, user: true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore: &store.IdleMessageStore{},
+		Broadcaster:  gate.NewBroadcaster(g, 100),
+		Admins:       []string{"admin"},
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	req := &messages.BroadcastRequest{Title: "hello", Uids: []string{"1"}}
+	reply, err := handler.handleApiBroadcast(&gate.Info{ID: user}, messages.NewMessage(1, messages.ActionApiBroadcast, req))
+	assert.NoError(t, err)
+	assert.Equal(t, messages.Action(messages.ActionApiFailed), reply.GetAction())
+
+	reply, err = handler.handleApiBroadcast(&gate.Info{ID: admin}, messages.NewMessage(2, messages.ActionApiBroadcast, req))
+	assert.NoError(t, err)
+	assert.Equal(t, messages.Action(messages.ActionApiSuccess), reply.GetAction())
+	p := gate.BroadcastProgress{}
+	assert.NoError(t, reply.Data.Deserialize(&p))
+	assert.Equal(t, 1, p.Total)
+
+	assert.Eventually(t, func() bool {
+		return len(g.get(user, messages.ActionNotifyAnnouncement)) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Empty(t, g.get(admin, messages.ActionNotifyAnnouncement))
+
+	query := messages.NewMessage(3, messages.ActionApiBroadcastProgress, &messages.BroadcastRequest{ID: p.ID})
+	assert.Eventually(t, func() bool {
+		reply, err = handler.handleApiBroadcastProgress(&gate.Info{ID: admin}, query)
+		assert.NoError(t, err)
+		_ = reply.Data.Deserialize(&p)
+		return p.Done
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, 1, p.Delivered)
+}
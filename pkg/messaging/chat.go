@@ -4,6 +4,9 @@ import (
 	"github.com/glide-im/glide/pkg/gate"
 	"github.com/glide-im/glide/pkg/logger"
 	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"time"
 )
 
 // handleChatMessage 分发用户单聊消息
@@ -14,21 +17,64 @@ func (d *MessageHandlerImpl) handleChatMessage(c *gate.Info, m *messages.GlideMe
 	}
 	msg.From = c.ID.UID()
 	msg.To = m.To
+	msg.Status = messages.MessageStatusNormal
+	if !d.validateContent(c, m, msg) {
+		return nil
+	}
+
+	// 接收者已拉黑发送者时, 消息和正常消息一样保存并回执, 但仅发送者可见, 不投递给接收者, 发送者无法感知被拉黑.
+	// 被拉黑的消息不分配 seq, 接收者的会话 seq 保持连续
+	blocked := d.blocks.IsBlocked(msg.To, msg.From)
+	if blocked {
+		msg.Status = messages.MessageStatusBlocked
+	}
 
 	if msg.Mid == 0 && m.Action != messages.ActionChatMessageResend {
 		// 当客户端发送一条 mid 为 0 的消息时表示这条消息未被服务端收到过, 或客户端未收到服务端的确认回执
+		msg.SendAt = time.Now().Unix()
+		reserved, mid, seq := d.reserveDuplicate(msg)
+		if !reserved {
+			if mid == 0 {
+				// 第一次发送的消息正在处理中, 丢弃重发的消息, 由第一次发送的处理回执
+				return nil
+			}
+			// 服务端已收到过这条消息, 客户端未收到确认回执而重发, 不再保存和投递, 使用原 mid 和 seq 回执
+			msg.Mid = mid
+			msg.Seq = seq
+			return d.ackChatMessage(c, msg)
+		}
+		if d.chatSeq != nil && !blocked {
+			seq, err := d.chatSeq.next(chatPair(msg.From, msg.To))
+			if err != nil {
+				logger.E("assign chat message seq error %v", err)
+				d.releaseDuplicate(msg)
+				return err
+			}
+			msg.Seq = seq
+		}
 		err := d.store.StoreMessage(msg)
 		if err != nil {
 			logger.E("store chat message error %v", err)
+			d.releaseDuplicate(msg)
 			return err
 		}
+		d.putDuplicateMid(msg)
+		d.updateChatConversation(msg, blocked)
+		d.trackExpiring(msg)
 	}
 	// sender resend message to receiver, server has already acked it
 	// does the server should not ack it again ?
 	err := d.ackChatMessage(c, msg)
-	if err != nil {
+	if err != nil && !gate.IsClientNotExist(err) {
 		logger.E("ack chat message error %v", err)
 	}
+	if blocked {
+		return nil
+	}
+
+	if d.dispatchBot(msg) {
+		return nil
+	}
 
 	pushMsg := messages.NewMessage(0, messages.ActionChatMessage, msg)
 
@@ -38,13 +84,46 @@ func (d *MessageHandlerImpl) handleChatMessage(c *gate.Info, m *messages.GlideMe
 		if err != nil {
 			logger.E("ack notify message error %v", err)
 		}
+		d.submitPush(msg)
 		return d.dispatchOffline(c, msg)
 	}
 	return nil
 }
 
-func (d *MessageHandlerImpl) handleChatRecallMessage(c *gate.Info, msg *messages.GlideMessage) error {
-	return d.handleChatMessage(c, msg)
+// reserveDuplicate reserves the message for the first send, returns false with the mid and seq of the message received
+// before with the same sender and CliMid, the mid is 0 if the message received before is still being handled.
+func (d *MessageHandlerImpl) reserveDuplicate(msg *messages.ChatMessage) (bool, int64, int64) {
+	if d.dedup == nil || msg.CliMid == "" {
+		return true, 0, 0
+	}
+	reserved, mid, seq, err := d.dedup.Reserve(msg.From, msg.CliMid)
+	if err != nil {
+		// handle the message as the first send, the duplicate is better than lost
+		logger.E("reserve duplicate message error %v", err)
+		return true, 0, 0
+	}
+	return reserved, mid, seq
+}
+
+// releaseDuplicate releases the reservation of the message failed to handle.
+func (d *MessageHandlerImpl) releaseDuplicate(msg *messages.ChatMessage) {
+	if d.dedup == nil || msg.CliMid == "" {
+		return
+	}
+	err := d.dedup.Release(msg.From, msg.CliMid)
+	if err != nil {
+		logger.E("release duplicate message error %v", err)
+	}
+}
+
+func (d *MessageHandlerImpl) putDuplicateMid(msg *messages.ChatMessage) {
+	if d.dedup == nil || msg.CliMid == "" || msg.Mid == 0 {
+		return
+	}
+	err := d.dedup.PutMid(msg.From, msg.CliMid, msg.Mid, msg.Seq)
+	if err != nil {
+		logger.E("put duplicate message mid error %v", err)
+	}
 }
 
 func (d *MessageHandlerImpl) ackNotifyMessage(c *gate.Info, m *messages.ChatMessage) error {
@@ -77,6 +156,13 @@ func (d *MessageHandlerImpl) dispatchOffline(c *gate.Info, message *messages.Cha
 		logger.E("store chat message error %v", err)
 		return err
 	}
+	if d.offline != nil {
+		_, err = d.offline.AppendOffline(message.To, message)
+		if err != nil {
+			logger.E("store offline message error %v", err)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -88,12 +174,32 @@ func (d *MessageHandlerImpl) dispatchOnline(c *gate.Info, msg *messages.ChatMess
 	return d.def.GetClientInterface().EnqueueMessage(c.ID, dispatchMsg)
 }
 
+// dispatchConversation 下发消息给会话中的所有设备, 单聊下发给发送者和接收者的所有设备, 另一方已拉黑 publisher 时只下发给
+// publisher, 频道则由 publisher 发布到频道
+func (d *MessageHandlerImpl) dispatchConversation(chatType int32, publisher string, from string, to string, m *messages.GlideMessage) {
+	if chatType == messages.ChatTypeChannel {
+		pm := subscription_impl.PublishMessage{
+			From:    subscription.SubscriberID(publisher),
+			Message: m,
+			Type:    subscription_impl.TypeNotify,
+		}
+		err := d.def.GetGroupInterface().PublishMessage(subscription.ChanID(to), &pm)
+		if err != nil {
+			logger.E("dispatch %s to channel %s error: %v", m.GetAction(), to, err)
+		}
+		return
+	}
+	peer := peerOf(publisher, from, to)
+	if !d.blocks.IsBlocked(peer, publisher) {
+		d.dispatchAllDevice(peer, m)
+	}
+	d.dispatchAllDevice(publisher, m)
+}
+
 // TODO optimize 2022-6-20 11:18:24
 func (d *MessageHandlerImpl) dispatchAllDevice(uid string, m *messages.GlideMessage) bool {
-	devices := []string{"", "1", "2", "3"}
-
 	var ok = false
-	for _, device := range devices {
+	for _, device := range allDevices {
 		id := gate.NewID("", uid, device)
 		err := d.def.GetClientInterface().EnqueueMessage(id, m)
 		if err != nil {
@@ -106,3 +212,17 @@ func (d *MessageHandlerImpl) dispatchAllDevice(uid string, m *messages.GlideMess
 	}
 	return ok
 }
+
+// dispatchOtherDevices dispatch message to all devices of the user except the specified device self.
+func (d *MessageHandlerImpl) dispatchOtherDevices(self gate.ID, m *messages.GlideMessage) {
+	for _, device := range allDevices {
+		if device == self.Device() {
+			continue
+		}
+		id := gate.NewID("", self.UID(), device)
+		err := d.def.GetClientInterface().EnqueueMessage(id, m)
+		if err != nil && !gate.IsClientNotExist(err) {
+			logger.E("dispatch message error %v", err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/store"
+	"sync"
+	"time"
+)
+
+// chatSeqIdle the segment of the conversation idle for the duration is released, the rest seq of it are returned to the
+// store.
+const chatSeqIdle = time.Minute * 10
+
+// chatPair returns the conversation id of the single chat between two users, same for both participants.
+func chatPair(uid1 string, uid2 string) string {
+	if uid1 > uid2 {
+		uid1, uid2 = uid2, uid1
+	}
+	return uid1 + "_" + uid2
+}
+
+type chatSeqSegment struct {
+	mu     sync.Mutex
+	next   int64
+	end    int64
+	usedAt time.Time
+}
+
+// chatSequencer 单聊会话的 seq 分配器, 按会话从 store.ChatSequenceStore 申请号段, 号段用完后再申请下一段
+type chatSequencer struct {
+	store store.ChatSequenceStore
+
+	mu       sync.Mutex
+	segments map[string]*chatSeqSegment
+	sweepAt  time.Time
+}
+
+func newChatSequencer(s store.ChatSequenceStore) *chatSequencer {
+	return &chatSequencer{
+		store:    s,
+		segments: map[string]*chatSeqSegment{},
+		sweepAt:  time.Now(),
+	}
+}
+
+func (c *chatSequencer) segment(conversation string) *chatSeqSegment {
+	c.mu.Lock()
+
+	now := time.Now()
+	var idle map[string]*chatSeqSegment
+	if now.Sub(c.sweepAt) > chatSeqIdle {
+		for k, s := range c.segments {
+			if now.Sub(s.usedAt) > chatSeqIdle {
+				delete(c.segments, k)
+				if idle == nil {
+					idle = map[string]*chatSeqSegment{}
+				}
+				idle[k] = s
+			}
+		}
+		c.sweepAt = now
+	}
+	s, ok := c.segments[conversation]
+	if !ok {
+		s = &chatSeqSegment{}
+		c.segments[conversation] = s
+	}
+	s.usedAt = now
+	c.mu.Unlock()
+
+	for k, seg := range idle {
+		c.release(k, seg)
+	}
+	return s
+}
+
+// release returns the unused seq of the segment to the store, the seq are skipped if failed.
+func (c *chatSequencer) release(conversation string, s *chatSeqSegment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.end == 0 || s.next > s.end {
+		return
+	}
+	_, err := c.store.ReturnChatSegment(conversation, s.next, s.end)
+	if err != nil {
+		logger.E("return chat seq segment error %v", err)
+	}
+	s.end = 0
+}
+
+// next returns the next seq of the conversation.
+func (c *chatSequencer) next(conversation string) (int64, error) {
+	s := c.segment(conversation)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next > s.end || s.end == 0 {
+		start, length, err := c.store.NextChatSegment(conversation)
+		if err != nil {
+			return 0, err
+		}
+		s.next = start
+		s.end = start + length - 1
+	}
+	seq := s.next
+	s.next++
+	return seq, nil
+}
@@ -0,0 +1,142 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChatSequencer_Next(t *testing.T) {
+	s := newChatSequencer(store.NewMemoryChatSequenceStore(3))
+
+	assert.Equal(t, chatPair("1", "2"), chatPair("2", "1"))
+
+	for i := int64(1); i <= 5; i++ {
+		seq, err := s.next(chatPair("1", "2"))
+		assert.NoError(t, err)
+		assert.Equal(t, i, seq)
+	}
+	seq, _ := s.next(chatPair("1", "3"))
+	assert.Equal(t, int64(1), seq)
+
+	wg := sync.WaitGroup{}
+	seen := sync.Map{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			seq, _ := s.next(chatPair("2", "1"))
+			_, dup := seen.LoadOrStore(seq, true)
+			assert.False(t, dup)
+		}()
+	}
+	wg.Wait()
+	seq, _ = s.next(chatPair("1", "2"))
+	assert.Equal(t, int64(106), seq)
+}
+
+func TestChatSequencer_ReleaseIdle(t *testing.T) {
+	ss := store.NewMemoryChatSequenceStore(10)
+	s := newChatSequencer(ss)
+
+	for i := int64(1); i <= 2; i++ {
+		seq, err := s.next(chatPair("1", "2"))
+		assert.NoError(t, err)
+		assert.Equal(t, i, seq)
+	}
+
+	// the unused seq of the idle segment are returned, the seq continues without gap
+	s.segments[chatPair("1", "2")].usedAt = time.Now().Add(-chatSeqIdle * 2)
+	s.sweepAt = time.Now().Add(-chatSeqIdle * 2)
+	seq, _ := s.next(chatPair("1", "3"))
+	assert.Equal(t, int64(1), seq)
+	seq, _ = s.next(chatPair("1", "2"))
+	assert.Equal(t, int64(3), seq)
+
+	// the unused seq are skipped if another segment allocated after it
+	ok, err := ss.ReturnChatSegment(chatPair("1", "3"), 2, 5)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMessageHandlerImpl_DuplicateAckSeq(t *testing.T) {
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{gate.NewID2("1"): true, gate.NewID2("2"): true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore:      store.NewMemoryMessageStore(0),
+		ChatSequenceStore: store.NewMemoryChatSequenceStore(10),
+		DedupCache:        store.NewMemoryDedupCache(time.Minute),
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	send := func(cliMid string) *messages.AckMessage {
+		id := gate.NewID2("1")
+		acked := len(g.get(id, messages.ActionAckMessage))
+		msg := messages.NewMessage(1, messages.ActionChatMessage, &messages.ChatMessage{
+			CliMid:  cliMid,
+			Seq:     100,
+			Type:    messages.MessageTypeText,
+			Content: "hello",
+		})
+		msg.To = "2"
+		assert.NoError(t, handler.Handle(&gate.Info{ID: id}, msg))
+		assert.Eventually(t, func() bool {
+			return len(g.get(id, messages.ActionAckMessage)) == acked+1
+		}, time.Second, time.Millisecond*10)
+		ack := &messages.AckMessage{}
+		acks := g.get(id, messages.ActionAckMessage)
+		assert.NoError(t, acks[len(acks)-1].Data.Deserialize(ack))
+		return ack
+	}
+
+	first := send("cli_1")
+	assert.Equal(t, int64(1), first.Seq)
+	assert.Equal(t, int64(2), send("cli_2").Seq)
+
+	// the resent message is acked with the mid and seq assigned by server, not the seq of client
+	resent := send("cli_1")
+	assert.Equal(t, first.Mid, resent.Mid)
+	assert.Equal(t, first.Seq, resent.Seq)
+	assert.Len(t, g.get(gate.NewID2("2"), messages.ActionChatMessage), 2)
+}
+
+func TestMessageHandlerImpl_DuplicateConcurrent(t *testing.T) {
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{gate.NewID2("1"): true, gate.NewID2("2"): true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	ms := store.NewMemoryMessageStore(0)
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore:      ms,
+		ChatSequenceStore: store.NewMemoryChatSequenceStore(10),
+		DedupCache:        store.NewMemoryDedupCache(time.Minute),
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	// the resent messages handled concurrently with the first send are stored and delivered once
+	for i := 0; i < 10; i++ {
+		msg := messages.NewMessage(1, messages.ActionChatMessage, &messages.ChatMessage{
+			CliMid:  "cli_1",
+			Type:    messages.MessageTypeText,
+			Content: "hello",
+		})
+		msg.To = "2"
+		assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2("1")}, msg))
+	}
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("2"), messages.ActionChatMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+	time.Sleep(time.Millisecond * 100)
+	assert.Len(t, g.get(gate.NewID2("2"), messages.ActionChatMessage), 1)
+	list, err := ms.ListMessages(&store.HistoryQuery{ChatType: messages.ChatTypeSingle, From: "1", To: "2", Limit: 20})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+}
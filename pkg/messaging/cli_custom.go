@@ -6,12 +6,17 @@ import (
 )
 
 type ClientCustomMessageHandler struct {
+	// blocks drops the message from the sender blocked by the receiver, nil express do not check.
+	blocks *BlockService
 }
 
 func (c *ClientCustomMessageHandler) Handle(h *MessageInterfaceImpl, ci *gate.Info, m *messages.GlideMessage) bool {
 	if m.Action != messages.ActionClientCustom {
 		return false
 	}
+	if c.blocks != nil && c.blocks.IsBlocked(m.To, ci.ID.UID()) {
+		return true
+	}
 	dispatch2AllDevice(h, m.To, m)
 	return true
 }
@@ -0,0 +1,48 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+)
+
+const (
+	// ForbiddenCodeContentType the reason code of the message with unknown or server only type.
+	ForbiddenCodeContentType = "content_type"
+	// ForbiddenCodeContentSize the reason code of the message exceeds the size limit of the type.
+	ForbiddenCodeContentSize = "content_size"
+	// ForbiddenCodeContentInvalid the reason code of the message content does not match the schema of the type.
+	ForbiddenCodeContentInvalid = "content_invalid"
+)
+
+func contentForbiddenCode(err error) string {
+	switch err {
+	case messages.ErrContentUnknownType, messages.ErrContentServerOnly:
+		return ForbiddenCodeContentType
+	case messages.ErrContentTooLarge:
+		return ForbiddenCodeContentSize
+	}
+	return ForbiddenCodeContentInvalid
+}
+
+// validateContent 按消息类型注册表校验客户端发送的消息内容, 校验失败时以 messages.ActionNotifyForbidden 通知发送者, 返回 false
+func (d *MessageHandlerImpl) validateContent(c *gate.Info, m *messages.GlideMessage, msg *messages.ChatMessage) bool {
+	if d.content == nil {
+		return true
+	}
+	err := d.content.ValidateClient(msg.Type, msg.Content)
+	if err == nil {
+		return true
+	}
+	notify := messages.NewMessage(m.GetSeq(), messages.ActionNotifyForbidden, &messages.Forbidden{
+		Code:   contentForbiddenCode(err),
+		Action: string(m.GetAction()),
+		CliMid: msg.CliMid,
+		To:     m.To,
+	})
+	err = d.def.GetClientInterface().EnqueueMessage(c.ID, notify)
+	if err != nil && !gate.IsClientNotExist(err) {
+		logger.E("notify forbidden error %v", err)
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+)
+
+const (
+	defaultConversationLimit = 20
+	maxConversationLimit     = 100
+
+	// maxPreviewLength the max rune count of the last message preview in conversation
+	maxPreviewLength = 64
+)
+
+const (
+	errConversationNotSupported    = "conversation is not supported"
+	errConversationUnauthenticated = "unauthenticated"
+	errConversationInvalidRequest  = "invalid conversation request"
+)
+
+// previewOf returns the preview of the message content.
+func previewOf(content string) string {
+	r := []rune(content)
+	if len(r) > maxPreviewLength {
+		return string(r[:maxPreviewLength])
+	}
+	return content
+}
+
+func lastMessageOf(chatType int32, id string, msg *messages.ChatMessage) *messages.Conversation {
+	preview := previewOf(msg.Content)
+	if isSelfDestructing(msg) {
+		// the content of self-destructing message is not kept in the conversation list
+		preview = ""
+	}
+	return &messages.Conversation{
+		ChatType: chatType,
+		ID:       id,
+		LastMid:  msg.Mid,
+		LastFrom: msg.From,
+		LastType: msg.Type,
+		Preview:  preview,
+		ActiveAt: msg.SendAt,
+	}
+}
+
+// updateChatConversation 更新单聊双方的会话, 接收者的未读数加一, senderOnly 为 true 时只更新发送者的会话
+func (d *MessageHandlerImpl) updateChatConversation(msg *messages.ChatMessage, senderOnly bool) {
+	if d.conversation == nil {
+		return
+	}
+	err := d.conversation.UpdateConversation(msg.From, lastMessageOf(messages.ChatTypeSingle, msg.To, msg), false)
+	if err != nil {
+		logger.E("update conversation error %v", err)
+	}
+	if senderOnly {
+		return
+	}
+	err = d.conversation.UpdateConversation(msg.To, lastMessageOf(messages.ChatTypeSingle, msg.From, msg), true)
+	if err != nil {
+		logger.E("update conversation error %v", err)
+	}
+}
+
+// updateChannelConversation 更新频道所有成员的会话, 除发送者外的成员未读数加一, store 支持时批量更新
+func (d *MessageHandlerImpl) updateChannelConversation(ch string, msg *messages.ChatMessage) {
+	if d.conversation == nil {
+		return
+	}
+	q, ok := d.def.GetGroupInterface().(subscription_impl.SubscriberQuery)
+	if !ok {
+		return
+	}
+	members, err := q.GetSubscribers(subscription.ChanID(ch))
+	if err != nil {
+		logger.E("get channel subscribers error %v", err)
+		return
+	}
+	conv := lastMessageOf(messages.ChatTypeChannel, ch, msg)
+	if batch, ok := d.conversation.(store.ConversationBatchStore); ok {
+		err = batch.UpdateConversations(members, conv, msg.From)
+		if err != nil {
+			logger.E("update conversations error %v", err)
+		}
+		return
+	}
+	for _, member := range members {
+		err = d.conversation.UpdateConversation(member, conv, member != msg.From)
+		if err != nil {
+			logger.E("update conversation error %v", err)
+		}
+	}
+}
+
+// clearUnread 清除用户会话的未读数
+func (d *MessageHandlerImpl) clearUnread(uid string, chatType int32, id string) {
+	if d.conversation == nil {
+		return
+	}
+	err := d.conversation.ClearUnread(uid, chatType, id)
+	if err != nil {
+		logger.E("clear conversation unread error %v", err)
+	}
+}
+
+// handleApiConversations 分页查询会话列表
+func (d *MessageHandlerImpl) handleApiConversations(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.conversation == nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errConversationNotSupported), nil
+	}
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errConversationUnauthenticated), nil
+	}
+	req := new(messages.ConversationQuery)
+	if !d.unmarshalData(c, m, req) || req.Offset < 0 {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errConversationInvalidRequest), nil
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultConversationLimit
+	}
+	if req.Limit > maxConversationLimit {
+		req.Limit = maxConversationLimit
+	}
+
+	list, err := d.conversation.ListConversations(c.ID.UID(), req.Offset, req.Limit+1)
+	if err != nil {
+		logger.E("list conversations error %v", err)
+		return nil, err
+	}
+	result := messages.Conversations{
+		Conversations: list,
+		More:          len(list) > req.Limit,
+	}
+	if result.More {
+		result.Conversations = list[:req.Limit]
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, &result), nil
+}
+
+// handleApiConversationFlags 设置会话置顶和免打扰
+func (d *MessageHandlerImpl) handleApiConversationFlags(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.conversation == nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errConversationNotSupported), nil
+	}
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errConversationUnauthenticated), nil
+	}
+	req := new(messages.ConversationFlags)
+	if !d.unmarshalData(c, m, req) || req.ID == "" {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errConversationInvalidRequest), nil
+	}
+	if req.ChatType != messages.ChatTypeSingle && req.ChatType != messages.ChatTypeChannel {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errUnknownChatType), nil
+	}
+
+	err := d.conversation.SetConversationFlags(c.ID.UID(), req.ChatType, req.ID, &req.Pinned, &req.Muted)
+	if err != nil {
+		logger.E("set conversation flags error %v", err)
+		return nil, err
+	}
+	// sync the flags to other devices of the user
+	d.dispatchOtherDevices(c.ID, messages.NewMessage(0, messages.ActionApiConversationFlags, req))
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, req), nil
+}
@@ -0,0 +1,78 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"time"
+)
+
+const defaultEditWindow = time.Minute * 15
+
+const (
+	errEditNotSupported   = "edit is not supported"
+	errEditPermission     = "permission denied: edit"
+	errEditWindowExceeded = "edit time window exceeded"
+	errEditRecalled       = "message recalled"
+)
+
+// handleEditMessage 编辑消息, 仅消息发送者可在编辑时间窗口内编辑, 编辑作为新的版本保存, 并以编辑事件下发给会话中的所有设备
+func (d *MessageHandlerImpl) handleEditMessage(c *gate.Info, m *messages.GlideMessage) error {
+	edit := new(messages.MessageEdit)
+	if !d.unmarshalData(c, m, edit) {
+		return nil
+	}
+
+	err := d.validateEdit(c, edit)
+	if err != nil {
+		d.enqueueMessage(c.ID, messages.NewMessage(m.GetSeq(), messages.ActionNotifyError, err.Error()))
+		return nil
+	}
+
+	err = d.store.StoreMessageRevision(edit)
+	if err != nil {
+		logger.E("store message revision error %v", err)
+		return err
+	}
+
+	notify := messages.NewMessage(0, messages.ActionMessageEdit, edit)
+	d.dispatchConversation(edit.ChatType, edit.From, edit.From, edit.To, notify)
+	return nil
+}
+
+// validateEdit checks the edit request and fills the edit with the info of the edited message.
+func (d *MessageHandlerImpl) validateEdit(c *gate.Info, edit *messages.MessageEdit) error {
+	if d.history == nil {
+		return errors.New(errEditNotSupported)
+	}
+	if edit.ChatType != messages.ChatTypeSingle && edit.ChatType != messages.ChatTypeChannel {
+		return errors.New(errUnknownChatType)
+	}
+
+	origin, err := d.getMessage(edit.ChatType, edit.Mid, c.ID.UID())
+	if err != nil {
+		return err
+	}
+	if origin.From != c.ID.UID() {
+		return errors.New(errEditPermission)
+	}
+	if origin.Status == messages.MessageStatusRecalled {
+		return errors.New(errEditRecalled)
+	}
+	if time.Since(time.Unix(origin.SendAt, 0)) > d.editWindow {
+		return errors.New(errEditWindowExceeded)
+	}
+	if d.content != nil {
+		err = d.content.ValidateClient(origin.Type, edit.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	edit.From = origin.From
+	edit.To = origin.To
+	edit.Revision = 0
+	edit.EditAt = time.Now().Unix()
+	return nil
+}
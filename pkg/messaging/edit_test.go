@@ -0,0 +1,85 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageHandlerImpl_EditChannelMessage(t *testing.T) {
+	h, g, ms := newChannelTestHandler(t)
+
+	mid := sendChannelMessage(t, h, g, "1", "hello")
+	assert.NotZero(t, mid)
+
+	edit := func(uid string, content string) {
+		msg := messages.NewMessage(2, messages.ActionMessageEdit, &messages.MessageEdit{
+			ChatType: messages.ChatTypeChannel,
+			Mid:      mid,
+			Content:  content,
+		})
+		assert.NoError(t, h.Handle(&gate.Info{ID: gate.NewID2(uid)}, msg))
+	}
+
+	// only the sender can edit
+	edit("2", "hacked")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("2"), messages.ActionNotifyError)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	edit("1", "hello world")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("3"), messages.ActionMessageEdit)) == 1
+	}, time.Second, time.Millisecond*10)
+	edit("1", "hello glide")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("3"), messages.ActionMessageEdit)) == 2
+	}, time.Second, time.Millisecond*10)
+
+	var revisions []int64
+	for _, m := range g.get(gate.NewID2("3"), messages.ActionMessageEdit) {
+		e := messages.MessageEdit{}
+		assert.NoError(t, m.Data.Deserialize(&e))
+		assert.Equal(t, mid, e.Mid)
+		assert.Equal(t, "ch", e.To)
+		revisions = append(revisions, e.Revision)
+	}
+	assert.Equal(t, []int64{1, 2}, revisions)
+
+	stored, err := ms.GetMessage(messages.ChatTypeChannel, mid)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello glide", stored.Content)
+}
+
+func TestMessageHandlerImpl_EditRecalledMessage(t *testing.T) {
+	h, g, ms := newChannelTestHandler(t)
+
+	mid := sendChannelMessage(t, h, g, "1", "hello")
+	msg := messages.NewMessage(2, messages.ActionMessageRecall, &messages.Recall{ChatType: messages.ChatTypeChannel, Mid: mid})
+	assert.NoError(t, h.Handle(&gate.Info{ID: gate.NewID2("1")}, msg))
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("3"), messages.ActionMessageRecall)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	stored, err := ms.GetMessage(messages.ChatTypeChannel, mid)
+	assert.NoError(t, err)
+	assert.Equal(t, messages.MessageStatusRecalled, stored.Status)
+
+	// the recalled message can not be edited, the content is not brought back
+	msg = messages.NewMessage(3, messages.ActionMessageEdit, &messages.MessageEdit{
+		ChatType: messages.ChatTypeChannel,
+		Mid:      mid,
+		Content:  "hello again",
+	})
+	assert.NoError(t, h.Handle(&gate.Info{ID: gate.NewID2("1")}, msg))
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionNotifyError)) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Empty(t, g.get(gate.NewID2("3"), messages.ActionMessageEdit))
+
+	stored, err = ms.GetMessage(messages.ChatTypeChannel, mid)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", stored.Content)
+}
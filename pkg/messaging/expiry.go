@@ -0,0 +1,123 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"time"
+)
+
+const (
+	defaultExpiryScanInterval = time.Second
+
+	// defaultBurnAfterReadTTL the lifetime of the burn-after-read message without TTL if the receiver never read it
+	defaultBurnAfterReadTTL = time.Hour * 24 * 7
+	// expiryScanLimit the max count of expired messages deleted in one scan
+	expiryScanLimit = 500
+)
+
+// isSelfDestructing returns true if the message is deleted after expired or read.
+func isSelfDestructing(msg *messages.ChatMessage) bool {
+	return msg.TTL > 0 || msg.BurnAfterRead
+}
+
+// trackExpiring 记录单聊中有 TTL 或阅后即焚的消息, 到期或接收者已读后删除
+func (d *MessageHandlerImpl) trackExpiring(msg *messages.ChatMessage) {
+	if d.expiry == nil || !isSelfDestructing(msg) {
+		return
+	}
+	ttl := msg.TTL
+	if ttl <= 0 {
+		ttl = int64(defaultBurnAfterReadTTL / time.Second)
+	}
+	err := d.expiry.AddExpiring(&store.ExpiringMessage{
+		ChatType:      messages.ChatTypeSingle,
+		Mid:           msg.Mid,
+		From:          msg.From,
+		To:            msg.To,
+		ExpireAt:      msg.SendAt + ttl,
+		BurnAfterRead: msg.BurnAfterRead,
+	})
+	if err != nil {
+		logger.E("add expiring message error %v", err)
+	}
+}
+
+func (d *MessageHandlerImpl) runExpirySweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		expired, err := d.expiry.ListExpired(time.Now().Unix()+1, expiryScanLimit)
+		if err != nil {
+			logger.E("list expired message error %v", err)
+			continue
+		}
+		for _, m := range expired {
+			d.deleteExpiring(m.ChatType, m.Mid, messages.MessageDeletedExpired)
+		}
+	}
+}
+
+// burnAfterRead deletes the burn-after-read message read by the receiver.
+func (d *MessageHandlerImpl) burnAfterRead(reader string, mid int64) {
+	if d.expiry == nil {
+		return
+	}
+	m, err := d.expiry.GetExpiring(messages.ChatTypeSingle, mid)
+	if err != nil {
+		logger.E("get expiring message error %v", err)
+		return
+	}
+	if m == nil || !m.BurnAfterRead || m.To != reader {
+		return
+	}
+	d.deleteExpiring(messages.ChatTypeSingle, mid, messages.MessageDeletedRead)
+}
+
+// deleteExpiring purges the stored message and notifies all devices of both participants.
+func (d *MessageHandlerImpl) deleteExpiring(chatType int32, mid int64, reason string) {
+	m, err := d.expiry.TakeExpiring(chatType, mid)
+	if err != nil {
+		logger.E("take expiring message error %v", err)
+		return
+	}
+	if m == nil {
+		// deleted by other node
+		return
+	}
+
+	purger, ok := d.store.(store.MessagePurgeStore)
+	if !ok {
+		purger, ok = d.history.(store.MessagePurgeStore)
+	}
+	if ok {
+		err = purger.PurgeMessage(chatType, mid)
+		if err != nil {
+			logger.E("purge message error %v", err)
+		}
+	}
+
+	notify := messages.NewMessage(0, messages.ActionNotifyMessageDeleted, &messages.MessageDeleted{
+		ChatType: m.ChatType,
+		Mid:      m.Mid,
+		From:     m.From,
+		To:       m.To,
+		Reason:   reason,
+	})
+	d.dispatchAllDevice(m.From, notify)
+	d.dispatchAllDevice(m.To, notify)
+}
+
+// isExpired returns true if the self-destructing message is expired or burned, used to drop it from offline messages.
+func (d *MessageHandlerImpl) isExpired(msg *messages.ChatMessage) bool {
+	if !isSelfDestructing(msg) {
+		return false
+	}
+	if msg.TTL > 0 && msg.SendAt+msg.TTL <= time.Now().Unix() {
+		return true
+	}
+	if d.expiry != nil && msg.BurnAfterRead {
+		m, err := d.expiry.GetExpiring(messages.ChatTypeSingle, msg.Mid)
+		return err == nil && m == nil
+	}
+	return false
+}
@@ -4,16 +4,132 @@ import (
 	"github.com/glide-im/glide/pkg/gate"
 	"github.com/glide-im/glide/pkg/logger"
 	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/push"
 	"github.com/glide-im/glide/pkg/store"
 	"github.com/glide-im/glide/pkg/subscription"
+	"time"
 )
 
 var _ Messaging = (*MessageHandlerImpl)(nil)
 
+// allDevices the device types of a user, used to dispatch message to all devices of the user, the bot client connects
+// with gate.DeviceRobot.
+var allDevices = []string{"", "1", "2", "3", gate.DeviceRobot}
+
 type MessageHandlerOptions struct {
 	// MessageStore chat message store
 	MessageStore store.MessageStore
 
+	// HistoryStore used to load the stored message, the recall, edit and history query are not available when nil.
+	HistoryStore store.MessageHistoryStore
+
+	// RecallWindow the max duration after the message sent that the message can be recalled, default 2 minutes.
+	RecallWindow time.Duration
+
+	// EditWindow the max duration after the message sent that the message can be edited, default 15 minutes.
+	EditWindow time.Duration
+
+	// OfflineStore used to save the messages of offline receivers until acknowledged, nil express do not store
+	// offline messages.
+	OfflineStore store.OfflineStore
+
+	// ConversationStore used to maintain the recent conversation list of users, nil express do not maintain.
+	ConversationStore store.ConversationStore
+
+	// ChannelAckStore used to track the acked seq of channel members, nil express do not track.
+	ChannelAckStore store.ChannelAckStore
+
+	// PresenceStore used to save the presence of users and the presence subscriptions, share it between gateway nodes
+	// to query and subscribe presence across nodes, default is an in-memory store.
+	PresenceStore store.PresenceStore
+
+	// MaxPresenceWatching the max count of users a user can subscribe the presence, default 500.
+	MaxPresenceWatching int
+
+	// ExpiryStore used to track the self-destructing messages with TTL or burn-after-read, nil express the fields are
+	// delivered as they are and the messages never expire.
+	ExpiryStore store.MessageExpiryStore
+
+	// ExpiryScanInterval the interval of deleting the expired messages in ExpiryStore, default 1 second.
+	ExpiryScanInterval time.Duration
+
+	// ScheduledStore used to save the scheduled messages, nil express the scheduled message is not supported.
+	ScheduledStore store.ScheduledMessageStore
+
+	// ScheduleScanInterval the interval of loading the due scheduled messages from ScheduledStore, default 1 minute.
+	ScheduleScanInterval time.Duration
+
+	// ChatSequenceStore used to assign the seq of single chat messages for each conversation, nil express keep the seq
+	// sent by client.
+	ChatSequenceStore store.ChatSequenceStore
+
+	// ReactionStore used to save the reactions of messages, nil express the reaction is not supported.
+	ReactionStore store.ReactionStore
+
+	// MaxReactionsPerUser the max count of distinct reactions of a user to a message, default 5.
+	MaxReactionsPerUser int
+
+	// ReadCursorStore used to save the read cursor of users in conversations, nil express read receipts are forwarded
+	// without persistence.
+	ReadCursorStore store.ReadCursorStore
+
+	// ReadReceiptToSender true express forward the read receipt of one-to-one chat to the peer.
+	ReadReceiptToSender bool
+
+	// ChannelReadCount true express publish the read count of the seq to the channel when a member read receipt.
+	ChannelReadCount bool
+
+	// TypingInterval the min interval of typing signals of a sender in a conversation, default 1 second.
+	TypingInterval time.Duration
+
+	// TypingTTL the duration the typing state keeps on the receiving side, default 5 seconds.
+	TypingTTL time.Duration
+
+	// DedupCache used to recognize the chat message resent by client, nil express do not deduplicate.
+	DedupCache store.DedupCache
+
+	// PushBridge pushes the notification of the chat message to the offline receiver, nil express do not push.
+	PushBridge push.Bridge
+
+	// PushTemplates the payload templates of notifications by message type, default push.DefaultTemplate for all types.
+	PushTemplates *push.Templates
+
+	// PushConcurrency the max count of the offline pushes run concurrently, default 32.
+	PushConcurrency int
+
+	// PushQueueSize the max count of the offline pushes waiting to run, the push is dropped when the queue is full,
+	// default 10000.
+	PushQueueSize int
+
+	// PushSettingStore used to save the mute and do-not-disturb settings of offline push, default is an in-memory store.
+	PushSettingStore store.PushSettingStore
+
+	// BlockStore used to save the block lists of users, default is an in-memory store.
+	BlockStore store.BlockStore
+
+	// Broadcaster pushes the announcements of the admin broadcast api to the clients of this node, nil express the api
+	// is not supported.
+	Broadcaster *gate.Broadcaster
+
+	// Admins the uids allowed to call the admin apis, such as the broadcast api.
+	Admins []string
+
+	// BotRate the max messages a bot sends per second, default 5, the in-process bot can override it by BotOptions.
+	BotRate int
+
+	// BotBurst the max messages a bot sends at once, default equals BotRate.
+	BotBurst int
+
+	// ContentRegistry used to validate the content of chat and channel messages by the type, nil express do not
+	// validate.
+	ContentRegistry *messages.ContentRegistry
+
+	// DMPolicy rejects the direct messages between users without relationship, nil express do not restrict.
+	DMPolicy *DMPolicyHandler
+
+	// Moderation checks the content of messages before the other handlers, nil express do not moderate.
+	Moderation *ModerationHandler
+
 	// DontInitDefaultHandler true will not init default action offlineMessageHandler, MessageHandlerImpl.InitDefaultHandler
 	DontInitDefaultHandler bool
 
@@ -25,8 +141,44 @@ type MessageHandlerOptions struct {
 type MessageHandlerImpl struct {
 	def   *MessageInterfaceImpl
 	store store.MessageStore
+	dedup store.DedupCache
+
+	offline      store.OfflineStore
+	conversation store.ConversationStore
+	channelAck   store.ChannelAckStore
+	expiry       store.MessageExpiryStore
+
+	history      store.MessageHistoryStore
+	recallWindow time.Duration
+	editWindow   time.Duration
+
+	reactions    store.ReactionStore
+	maxReactions int
+
+	readCursor          store.ReadCursorStore
+	readReceiptToSender bool
+	channelReadCount    bool
+
+	typingLimiter *typingLimiter
+	typingTTL     time.Duration
+
+	pushBridge    push.Bridge
+	pushTemplates *push.Templates
+	pushSetting   store.PushSettingStore
+	pushQueue     *pushQueue
+
+	chatSeq    *chatSequencer
+	blocks     *BlockService
+	content    *messages.ContentRegistry
+	dmPolicy   *DMPolicyHandler
+	moderation *ModerationHandler
 
 	userState *UserState
+	scheduler *MessageScheduler
+	bots      *bots
+
+	broadcaster *gate.Broadcaster
+	admins      []string
 }
 
 func NewHandlerWithOptions(gateway gate.Gateway, opts *MessageHandlerOptions) (*MessageHandlerImpl, error) {
@@ -40,10 +192,108 @@ func NewHandlerWithOptions(gateway gate.Gateway, opts *MessageHandlerOptions) (*
 	impl.SetNotifyErrorOnServer(opts.NotifyOnErr)
 
 	ret := &MessageHandlerImpl{
-		def:       impl,
-		store:     opts.MessageStore,
-		userState: NewUserState(gateway),
+		def:          impl,
+		store:        opts.MessageStore,
+		dedup:        opts.DedupCache,
+		offline:      opts.OfflineStore,
+		conversation: opts.ConversationStore,
+		channelAck:   opts.ChannelAckStore,
+		expiry:       opts.ExpiryStore,
+		history:      opts.HistoryStore,
+		recallWindow: opts.RecallWindow,
+		editWindow:   opts.EditWindow,
+		reactions:    opts.ReactionStore,
+		maxReactions: opts.MaxReactionsPerUser,
+
+		readCursor:          opts.ReadCursorStore,
+		readReceiptToSender: opts.ReadReceiptToSender,
+		channelReadCount:    opts.ChannelReadCount,
+
+		typingTTL: opts.TypingTTL,
+
+		pushBridge:    opts.PushBridge,
+		pushTemplates: opts.PushTemplates,
+		pushSetting:   opts.PushSettingStore,
+
+		content:    opts.ContentRegistry,
+		dmPolicy:   opts.DMPolicy,
+		moderation: opts.Moderation,
+
+		bots: newBots(opts.BotRate, opts.BotBurst),
+
+		broadcaster: opts.Broadcaster,
+		admins:      opts.Admins,
+	}
+	if ret.recallWindow == 0 {
+		ret.recallWindow = defaultRecallWindow
+	}
+	if ret.editWindow == 0 {
+		ret.editWindow = defaultEditWindow
+	}
+	if ret.maxReactions <= 0 {
+		ret.maxReactions = defaultMaxReactionsPerUser
+	}
+	if ret.typingTTL == 0 {
+		ret.typingTTL = defaultTypingTTL
 	}
+	typingInterval := opts.TypingInterval
+	if typingInterval == 0 {
+		typingInterval = defaultTypingInterval
+	}
+	ret.typingLimiter = newTypingLimiter(typingInterval)
+	if opts.PresenceStore != nil {
+		ret.userState = NewUserStateWithStore(gateway, opts.PresenceStore)
+	} else {
+		ret.userState = NewUserState(gateway)
+	}
+	if opts.MaxPresenceWatching > 0 {
+		ret.userState.maxWatching = opts.MaxPresenceWatching
+	}
+	go ret.userState.runExpireSweeper(presenceExpireInterval)
+	if opts.ExpiryStore != nil {
+		expiryInterval := opts.ExpiryScanInterval
+		if expiryInterval == 0 {
+			expiryInterval = defaultExpiryScanInterval
+		}
+		go ret.runExpirySweeper(expiryInterval)
+	}
+	if opts.ScheduledStore != nil {
+		scanInterval := opts.ScheduleScanInterval
+		if scanInterval == 0 {
+			scanInterval = defaultScheduleScanInterval
+		}
+		ret.scheduler = newMessageScheduler(opts.ScheduledStore, scanInterval, ret.deliverScheduled)
+		ret.scheduler.validate = ret.validateScheduled
+	}
+	if ret.pushTemplates == nil {
+		ret.pushTemplates, _ = push.NewTemplates(push.DefaultTemplate, nil)
+	}
+	if ret.pushSetting == nil {
+		ret.pushSetting = store.NewMemoryPushSettingStore()
+	}
+	if ret.pushBridge != nil {
+		ret.pushQueue = newPushQueue(opts.PushConcurrency, opts.PushQueueSize, ret.pushOffline)
+	}
+	if opts.ChatSequenceStore != nil {
+		ret.chatSeq = newChatSequencer(opts.ChatSequenceStore)
+	}
+	blockStore := opts.BlockStore
+	if blockStore == nil {
+		blockStore = store.NewMemoryBlockStore()
+	}
+	ret.blocks = newBlockService(blockStore)
+	ret.blocks.onChange = ret.userState.refreshWatchers
+	ret.userState.blocks = ret.blocks
+	if opts.DMPolicy != nil {
+		opts.DMPolicy.isBot = func(uid string) bool {
+			return ret.bots.get(uid) != nil
+		}
+		impl.AddHandler(opts.DMPolicy)
+	}
+	if opts.Moderation != nil {
+		impl.AddHandler(opts.Moderation)
+	}
+	impl.AddHandler(ret.bots)
 	if !opts.DontInitDefaultHandler {
 		ret.InitDefaultHandler(nil)
 	}
@@ -58,14 +308,17 @@ func (d *MessageHandlerImpl) InitDefaultHandler(callback func(action messages.Ac
 	m := map[messages.Action]HandlerFunc{
 		messages.ActionChatMessage:     d.handleChatMessage,
 		messages.ActionGroupMessage:    d.handleGroupMsg,
-		messages.ActionApiGroupMembers: d.handleApiGroupMembers,
+		messages.ActionMessageRecall:   d.handleRecallMessage,
+		messages.ActionMessageEdit:     d.handleEditMessage,
+		messages.ActionMessageReact:    d.handleReaction,
 		messages.ActionAckRequest:      d.handleAckRequest,
 		messages.ActionAckGroupMsg:     d.handleAckGroupMsgRequest,
+		messages.ActionAckRead:         d.handleAckRead,
+		messages.ActionNotifyTyping:    d.handleTyping,
 		messages.AckOffline:            d.handleAckOffline,
 		messages.ActionHeartbeat:       d.handleHeartbeat,
 		messages.ActionInternalOnline:  d.handleInternalOnline,
 		messages.ActionInternalOffline: d.handleInternalOffline,
-		messages.ActionApiSubUserState: d.userState.subUserStateApi,
 	}
 	for action, handlerFunc := range m {
 		if callback != nil {
@@ -74,7 +327,28 @@ func (d *MessageHandlerImpl) InitDefaultHandler(callback func(action messages.Ac
 		d.def.AddHandler(NewActionHandler(action, handlerFunc))
 	}
 
-	d.def.AddHandler(&ClientCustomMessageHandler{})
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiGroupMembers, d.handleApiGroupMembers))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiGroupAckCoverage, d.handleApiGroupAckCoverage))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiOfflineSync, d.handleOfflineSync))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiHistory, d.handleApiHistory))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiConversations, d.handleApiConversations))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiConversationFlags, d.handleApiConversationFlags))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiSubUserState, d.userState.subUserStateApi))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiUnsubUserState, d.userState.unsubUserStateApi))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiSchedule, d.handleApiSchedule))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiScheduleEdit, d.handleApiScheduleEdit))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiScheduleCancel, d.handleApiScheduleCancel))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiScheduleList, d.handleApiScheduleList))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiPresenceQuery, d.userState.queryPresenceApi))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiPresenceUpdate, d.userState.updatePresenceApi))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiPresencePrivacy, d.userState.privacyApi))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiPushSetting, d.handleApiPushSetting))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiPushSettingGet, d.handleApiPushSettingGet))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiBlock, d.handleApiBlock))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiBlockList, d.handleApiBlockList))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiBroadcast, d.handleApiBroadcast))
+	d.def.AddHandler(NewActionWithReplyHandler(messages.ActionApiBroadcastProgress, d.handleApiBroadcastProgress))
+	d.def.AddHandler(&ClientCustomMessageHandler{blocks: d.blocks})
 	d.def.AddHandler(NewActionHandler(messages.ActionHeartbeat, handleHeartbeat))
 }
 
@@ -82,6 +356,11 @@ func (d *MessageHandlerImpl) AddHandler(i MessageHandler) {
 	d.def.AddHandler(i)
 }
 
+// Use appends the middlewares wrap the handlers, see MessageInterfaceImpl.Use.
+func (d *MessageHandlerImpl) Use(m ...Middleware) {
+	d.def.Use(m...)
+}
+
 func (d *MessageHandlerImpl) Handle(cInfo *gate.Info, msg *messages.GlideMessage) error {
 	return d.def.Handle(cInfo, msg)
 }
@@ -114,8 +393,7 @@ func (d *MessageHandlerImpl) unmarshalData(c *gate.Info, msg *messages.GlideMess
 }
 
 func dispatch2AllDevice(h *MessageInterfaceImpl, uid string, m *messages.GlideMessage) bool {
-	devices := []string{"", "1", "2", "3"}
-	for _, device := range devices {
+	for _, device := range allDevices {
 		id := gate.NewID("", uid, device)
 		err := h.GetClientInterface().EnqueueMessage(id, m)
 		if err != nil && !gate.IsClientNotExist(err) {
@@ -0,0 +1,120 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+const (
+	errHistoryNotSupported    = "history is not supported"
+	errHistoryInvalidQuery    = "invalid history query"
+	errHistoryPermission      = "permission denied: history"
+	errHistoryUnauthenticated = "unauthenticated"
+)
+
+// handleApiHistory 查询会话历史消息, 单聊为请求者与对方的消息, 频道需要请求者为有读权限的成员
+func (d *MessageHandlerImpl) handleApiHistory(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.history == nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errHistoryNotSupported), nil
+	}
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errHistoryUnauthenticated), nil
+	}
+	req := new(messages.HistoryQuery)
+	if !d.unmarshalData(c, m, req) || req.To == "" {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errHistoryInvalidQuery), nil
+	}
+	if req.ChatType != messages.ChatTypeSingle && req.ChatType != messages.ChatTypeChannel {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errUnknownChatType), nil
+	}
+	if req.ChatType == messages.ChatTypeChannel && !d.canReadChannel(req.To, c.ID.UID()) {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errHistoryPermission), nil
+	}
+	if req.Resume && req.ChatType == messages.ChatTypeChannel && d.channelAck != nil {
+		seq, err := d.channelAck.GetMemberAck(subscription.ChanID(req.To), subscription.SubscriberID(c.ID.UID()))
+		if err != nil {
+			logger.E("get channel member ack error %v", err)
+			return nil, err
+		}
+		req.Cursor = seq
+		req.Direction = messages.HistoryAfter
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultHistoryLimit
+	}
+	if req.Limit > maxHistoryLimit {
+		req.Limit = maxHistoryLimit
+	}
+
+	list, err := d.history.ListMessages(&store.HistoryQuery{
+		ChatType: req.ChatType,
+		From:     c.ID.UID(),
+		To:       req.To,
+		Cursor:   req.Cursor,
+		After:    req.Direction == messages.HistoryAfter,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		logger.E("list history messages error %v", err)
+		return nil, err
+	}
+	for _, msg := range list {
+		// the sender can not perceive the message is blocked by the receiver
+		msg.Status = messages.MessageStatusNormal
+	}
+	d.fillReactions(req.ChatType, list)
+	result := messages.HistoryMessages{
+		Messages: list,
+		More:     len(list) == req.Limit,
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, &result), nil
+}
+
+// getMessage returns the stored message for uid, the message blocked by the receiver is not found for others than the
+// sender.
+func (d *MessageHandlerImpl) getMessage(chatType int32, mid int64, uid string) (*messages.ChatMessage, error) {
+	msg, err := d.history.GetMessage(chatType, mid)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Status == messages.MessageStatusBlocked && msg.From != uid {
+		return nil, errors.New(store.ErrMessageNotFound)
+	}
+	return msg, nil
+}
+
+// canReadChannel returns true if the uid is the member of channel with read permission.
+func (d *MessageHandlerImpl) canReadChannel(ch string, uid string) bool {
+	q, ok := d.def.GetGroupInterface().(subscription_impl.SubscriberQuery)
+	if !ok {
+		return false
+	}
+	info, err := q.GetSubscriber(subscription.ChanID(ch), subscription.SubscriberID(uid))
+	if err != nil {
+		return false
+	}
+	return info.CanRead()
+}
+
+// canWriteChannel returns true if the uid is the member of channel with write permission.
+func (d *MessageHandlerImpl) canWriteChannel(ch string, uid string) bool {
+	q, ok := d.def.GetGroupInterface().(subscription_impl.SubscriberQuery)
+	if !ok {
+		return false
+	}
+	info, err := q.GetSubscriber(subscription.ChanID(ch), subscription.SubscriberID(uid))
+	if err != nil {
+		return false
+	}
+	return info.CanWrite()
+}
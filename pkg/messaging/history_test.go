@@ -0,0 +1,55 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestMessageHandlerImpl_ChannelHistory(t *testing.T) {
+	h, g, _ := newChannelTestHandler(t)
+	for i := 1; i <= 5; i++ {
+		sendChannelMessage(t, h, g, "1", "m"+strconv.Itoa(i))
+	}
+
+	query := func(uid string, q *messages.HistoryQuery) *messages.GlideMessage {
+		q.ChatType = messages.ChatTypeChannel
+		q.To = "ch"
+		reply, err := h.handleApiHistory(&gate.Info{ID: gate.NewID2(uid)}, messages.NewMessage(1, messages.ActionApiHistory, q))
+		assert.NoError(t, err)
+		return reply
+	}
+	page := func(q *messages.HistoryQuery) ([]string, []int64, bool) {
+		reply := query("3", q)
+		assert.Equal(t, messages.Action(messages.ActionApiSuccess), reply.GetAction())
+		result := messages.HistoryMessages{}
+		assert.NoError(t, reply.Data.Deserialize(&result))
+		var contents []string
+		var seqs []int64
+		for _, m := range result.Messages {
+			contents = append(contents, m.Content)
+			seqs = append(seqs, m.Seq)
+		}
+		return contents, seqs, result.More
+	}
+
+	// the latest page, then the pages before the first seq of the previous page, old to new
+	contents, seqs, more := page(&messages.HistoryQuery{Limit: 2})
+	assert.Equal(t, []string{"m4", "m5"}, contents)
+	assert.True(t, more)
+	contents, seqs, _ = page(&messages.HistoryQuery{Cursor: seqs[0], Limit: 2})
+	assert.Equal(t, []string{"m2", "m3"}, contents)
+	contents, seqs, more = page(&messages.HistoryQuery{Cursor: seqs[0], Limit: 2})
+	assert.Equal(t, []string{"m1"}, contents)
+	assert.False(t, more)
+
+	// the page after the seq
+	contents, _, _ = page(&messages.HistoryQuery{Cursor: seqs[0], Direction: messages.HistoryAfter, Limit: 3})
+	assert.Equal(t, []string{"m2", "m3", "m4"}, contents)
+
+	// not a member of the channel
+	reply := query("4", &messages.HistoryQuery{Limit: 2})
+	assert.Equal(t, messages.Action(messages.ActionApiFailed), reply.GetAction())
+}
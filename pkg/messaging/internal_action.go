@@ -1,7 +1,6 @@
 package messaging
 
 import (
-	"github.com/glide-im/glide/config"
 	"github.com/glide-im/glide/internal/world_channel"
 	"github.com/glide-im/glide/pkg/gate"
 	"github.com/glide-im/glide/pkg/logger"
@@ -25,17 +24,11 @@ func (d *MessageHandlerImpl) handleInternalOnline(c *gate.Info, m *messages.Glid
 		defer func() {
 			err, ok := recover().(error)
 			if err != nil && ok {
-				logger.ErrE("push offline message error", err)
+				logger.ErrE("handle user online error", err)
 			}
 		}()
-		go func() {
-			time.Sleep(time.Second * 1)
-			world_channel.OnUserOnline(c.ID)
-		}()
-
-		if config.Common.StoreOfflineMessage {
-			// message_handler.PushOfflineMessage(h, cliInfo.ID.UID())
-		}
+		time.Sleep(time.Second * 1)
+		world_channel.OnUserOnline(c.ID)
 	}()
 	return nil
 }
@@ -88,14 +88,18 @@ func NewActionHandler(action messages.Action, fn HandlerFunc) *ActionHandler {
 }
 
 func (a *ActionHandler) Handle(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
+	handled, err := a.handleWithError(h, cliInfo, message)
+	if err != nil {
+		h.OnHandleMessageError(cliInfo, message, err)
+	}
+	return handled
+}
+
+func (a *ActionHandler) handleWithError(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) (bool, error) {
 	if message.GetAction() == a.action {
-		err := a.fn(cliInfo, message)
-		if err != nil {
-			h.OnHandleMessageError(cliInfo, message, err)
-		}
-		return true
+		return true, a.fn(cliInfo, message)
 	}
-	return false
+	return false, nil
 }
 
 type ReplyHandlerFunc func(cliInfo *gate.Info, message *messages.GlideMessage) (*messages.GlideMessage, error)
@@ -114,15 +118,25 @@ func NewActionWithReplyHandler(action messages.Action, fn ReplyHandlerFunc) *Act
 }
 
 func (rh *ActionWithReplyHandler) Handle(h *MessageInterfaceImpl, cInfo *gate.Info, msg *messages.GlideMessage) bool {
-	if msg.GetAction() == rh.action {
-		r, err := rh.fn(cInfo, msg)
-		if err != nil {
-			h.OnHandleMessageError(cInfo, msg, err)
-		}
+	handled, err := rh.handleWithError(h, cInfo, msg)
+	if err != nil {
+		h.OnHandleMessageError(cInfo, msg, err)
+	}
+	return handled
+}
+
+func (rh *ActionWithReplyHandler) handleWithError(h *MessageInterfaceImpl, cInfo *gate.Info, msg *messages.GlideMessage) (bool, error) {
+	if msg.GetAction() != rh.action {
+		return false, nil
+	}
+	r, err := rh.fn(cInfo, msg)
+	if err != nil {
+		return true, err
+	}
+	if r != nil {
 		_ = h.GetClientInterface().EnqueueMessage(cInfo.ID, r)
-		return true
 	}
-	return false
+	return true, nil
 }
 
 type Options struct {
@@ -143,6 +157,9 @@ type MessageInterfaceImpl struct {
 	// hc message offlineMessageHandler chain
 	hc *handlerChain
 
+	// middlewares wrap the handler chain, the first is the outermost
+	middlewares []Middleware
+
 	subscription subscription.Interface
 	gate         gate.Gateway
 
@@ -177,13 +194,15 @@ func (d *MessageInterfaceImpl) Handle(cInfo *gate.Info, msg *messages.GlideMessa
 	}
 	logger.D("handle message: %s", msg)
 	err := d.execPool.Submit(func() {
-		handled := d.hc.handle(d, cInfo, msg)
-		if !handled {
+		err := d.handleWithMiddlewares(0, cInfo, msg)
+		if err == ErrActionNotHandled {
 			if !msg.GetAction().IsInternal() {
 				r := messages.NewMessage(msg.GetSeq(), messages.ActionNotifyUnknownAction, msg.GetAction())
 				_ = d.gate.EnqueueMessage(cInfo.ID, r)
 			}
 			logger.W("action is not handled: %s", msg.GetAction())
+		} else if err != nil {
+			d.OnHandleMessageError(cInfo, msg, err)
 		}
 	})
 	if err != nil {
@@ -197,6 +216,26 @@ func (d *MessageInterfaceImpl) AddHandler(i MessageHandler) {
 	d.hc.add(i)
 }
 
+// Use appends the middlewares, the middlewares run in the order of registration around the matched handler, the first
+// registered is the outermost. Middlewares should be registered before handling messages.
+func (d *MessageInterfaceImpl) Use(m ...Middleware) {
+	d.middlewares = append(d.middlewares, m...)
+}
+
+// handleWithMiddlewares runs the middleware at index i, the handler chain runs after the last middleware.
+func (d *MessageInterfaceImpl) handleWithMiddlewares(i int, cInfo *gate.Info, msg *messages.GlideMessage) error {
+	if i >= len(d.middlewares) {
+		handled, err := d.hc.handle(d, cInfo, msg)
+		if !handled {
+			return ErrActionNotHandled
+		}
+		return err
+	}
+	return d.middlewares[i](cInfo, msg, func(cInfo *gate.Info, msg *messages.GlideMessage) error {
+		return d.handleWithMiddlewares(i+1, cInfo, msg)
+	})
+}
+
 func (d *MessageInterfaceImpl) SetGate(g gate.Gateway) {
 	d.gate = g
 }
@@ -239,12 +278,25 @@ func (hc *handlerChain) add(i MessageHandler) {
 	}
 }
 
-func (hc handlerChain) handle(h2 *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
-	if hc.h != nil && hc.h.Handle(h2, cliInfo, message) {
-		return true
+// errorReportingHandler is implemented by the handlers which return the error of handling to the chain instead of
+// notifying the client directly, so that the middlewares can observe the error.
+type errorReportingHandler interface {
+	handleWithError(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) (bool, error)
+}
+
+func (hc handlerChain) handle(h2 *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) (bool, error) {
+	if hc.h != nil {
+		if eh, ok := hc.h.(errorReportingHandler); ok {
+			handled, err := eh.handleWithError(h2, cliInfo, message)
+			if handled {
+				return true, err
+			}
+		} else if hc.h.Handle(h2, cliInfo, message) {
+			return true, nil
+		}
 	}
 	if hc.next != nil {
 		return hc.next.handle(h2, cliInfo, message)
 	}
-	return false
+	return false, nil
 }
@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"time"
+)
+
+// ErrActionNotHandled returned by the handler chain when no handler handles the message, the client is notified with
+// messages.ActionNotifyUnknownAction if it is not swallowed by middlewares.
+var ErrActionNotHandled = errors.New("action is not handled")
+
+const errInternal = "internal error"
+
+// MiddlewareNext continues the handling with the next middleware, and the matched handler after the last middleware.
+type MiddlewareNext func(cliInfo *gate.Info, message *messages.GlideMessage) error
+
+// Middleware wraps the handling of every message. A middleware can short-circuit by not calling next, rewrite the
+// message by passing another one to next, and observe or replace the error returned by next. The returned error
+// is notified to the client by MessageInterfaceImpl.OnHandleMessageError.
+type Middleware func(cliInfo *gate.Info, message *messages.GlideMessage, next MiddlewareNext) error
+
+// PreHook called before the handler, returns the message to handle, returns error to stop handling.
+type PreHook func(cliInfo *gate.Info, message *messages.GlideMessage) (*messages.GlideMessage, error)
+
+// PostHook called after the handler with the error of handling, returns the error to report.
+type PostHook func(cliInfo *gate.Info, message *messages.GlideMessage, err error) error
+
+// HookMiddleware returns a Middleware calls the pre hook before and the post hook after the handler, both are optional.
+func HookMiddleware(pre PreHook, post PostHook) Middleware {
+	return func(cliInfo *gate.Info, message *messages.GlideMessage, next MiddlewareNext) error {
+		if pre != nil {
+			m, err := pre(cliInfo, message)
+			if err != nil {
+				return err
+			}
+			if m != nil {
+				message = m
+			}
+		}
+		err := next(cliInfo, message)
+		if post != nil {
+			err = post(cliInfo, message, err)
+		}
+		return err
+	}
+}
+
+// RecoveryMiddleware recovers the panic of handlers, and returns it as an error to notify the client.
+func RecoveryMiddleware() Middleware {
+	return func(cliInfo *gate.Info, message *messages.GlideMessage, next MiddlewareNext) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.E("handle message %s panic: %v", message.GetAction(), r)
+				err = errors.New(errInternal)
+			}
+		}()
+		return next(cliInfo, message)
+	}
+}
+
+// TimingMiddleware reports the duration of handling each message to the fn, used to collect metrics per action.
+func TimingMiddleware(fn func(action messages.Action, d time.Duration, err error)) Middleware {
+	return func(cliInfo *gate.Info, message *messages.GlideMessage, next MiddlewareNext) error {
+		start := time.Now()
+		err := next(cliInfo, message)
+		fn(message.GetAction(), time.Since(start), err)
+		return err
+	}
+}
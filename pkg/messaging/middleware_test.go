@@ -0,0 +1,51 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMessageInterfaceImpl_Use(t *testing.T) {
+	impl, err := NewDefaultImpl(&Options{MaxMessageConcurrency: 1})
+	assert.NoError(t, err)
+
+	var calls []string
+	impl.AddHandler(NewActionHandler(messages.ActionHeartbeat, func(cliInfo *gate.Info, message *messages.GlideMessage) error {
+		calls = append(calls, "handler:"+message.To)
+		return errors.New("handler error")
+	}))
+	impl.Use(
+		func(cliInfo *gate.Info, message *messages.GlideMessage, next MiddlewareNext) error {
+			calls = append(calls, "first")
+			err := next(cliInfo, message)
+			calls = append(calls, "first:"+err.Error())
+			return nil
+		},
+		HookMiddleware(func(cliInfo *gate.Info, message *messages.GlideMessage) (*messages.GlideMessage, error) {
+			calls = append(calls, "second")
+			return messages.NewMessage(0, message.GetAction(), nil), nil
+		}, nil),
+	)
+
+	info := &gate.Info{ID: gate.NewID2("1")}
+	m := messages.NewMessage(0, messages.ActionHeartbeat, nil)
+	m.To = "origin"
+	err = impl.handleWithMiddlewares(0, info, m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "handler:", "first:handler error"}, calls)
+
+	err = impl.handleWithMiddlewares(0, info, messages.NewMessage(0, messages.ActionChatMessage, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "first:"+ErrActionNotHandled.Error(), calls[len(calls)-1])
+
+	// the panic is recovered and reported as error
+	impl.middlewares = nil
+	impl.Use(RecoveryMiddleware(), func(cliInfo *gate.Info, message *messages.GlideMessage, next MiddlewareNext) error {
+		panic("boom")
+	})
+	err = impl.handleWithMiddlewares(0, info, m)
+	assert.EqualError(t, err, errInternal)
+}
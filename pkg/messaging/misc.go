@@ -5,7 +5,9 @@ import (
 	"github.com/glide-im/glide/pkg/messages"
 )
 
+// handleHeartbeat 刷新客户端设备的在线状态
 func (d *MessageHandlerImpl) handleHeartbeat(cInfo *gate.Info, msg *messages.GlideMessage) error {
+	d.userState.onHeartbeat(cInfo.ID)
 	return nil
 }
 
@@ -21,11 +23,3 @@ func (d *MessageHandlerImpl) handleAckRequest(c *gate.Info, msg *messages.GlideM
 	d.dispatchAllDevice(ackMsg.To, ackNotify)
 	return nil
 }
-
-func (d *MessageHandlerImpl) handleAckOffline(c *gate.Info, msg *messages.GlideMessage) error {
-	if c.ID.IsTemp() {
-		return nil
-	}
-	AckOfflineMessage(c.ID.UID())
-	return nil
-}
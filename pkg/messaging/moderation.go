@@ -0,0 +1,309 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModerationPolicy the policy applied to the message matched by a moderation rule.
+type ModerationPolicy int32
+
+const (
+	// ModerationPass the message is delivered as it is.
+	ModerationPass ModerationPolicy = 0
+	// ModerationMask the matched content is replaced with the mask character.
+	ModerationMask ModerationPolicy = 1
+	// ModerationFlag the message is delivered and sent to the review sink.
+	ModerationFlag ModerationPolicy = 2
+	// ModerationBlock the message is dropped and the sender is notified with messages.ActionNotifyForbidden.
+	ModerationBlock ModerationPolicy = 3
+)
+
+const (
+	// ForbiddenCodeModeration the default reason code of the message blocked by moderation.
+	ForbiddenCodeModeration = "moderation"
+
+	defaultMaskChar = '*'
+)
+
+// ModerationRule a keyword or a regular expression rule, the Pattern is used when Keyword is empty.
+type ModerationRule struct {
+	// Keyword matched case-insensitively
+	Keyword string
+	// Pattern regular expression
+	Pattern string
+	Policy  ModerationPolicy
+	// Reason the reason code sent to the sender when blocked, and to the review sink when flagged
+	Reason string
+}
+
+// ModerationVerdict the result of the moderation of a message content.
+type ModerationVerdict struct {
+	Policy ModerationPolicy
+	Reason string
+}
+
+// ContentClassifier is used to classify the message content by an external service, it is called after the rules.
+type ContentClassifier interface {
+	Classify(from string, action messages.Action, content string) (*ModerationVerdict, error)
+}
+
+// ReviewItem the flagged message sent to the review sink.
+type ReviewItem struct {
+	From    string
+	To      string
+	Action  messages.Action
+	Content string
+	Reason  string
+	At      int64
+}
+
+// ReviewSink receives the flagged messages for review.
+type ReviewSink interface {
+	Review(item *ReviewItem)
+}
+
+// LogReviewSink writes the flagged messages to log.
+type LogReviewSink struct{}
+
+func (LogReviewSink) Review(item *ReviewItem) {
+	logger.I("[Moderation] flagged %s from %s to %s, reason: %s, content: %s", item.Action, item.From, item.To, item.Reason, item.Content)
+}
+
+type ModerationOptions struct {
+	Rules []*ModerationRule
+
+	// ActionPolicies overrides the policy of matched rules for the message action, e.g. block instead of mask the
+	// message.cli, nil express use the policy of rules.
+	ActionPolicies map[messages.Action]ModerationPolicy
+
+	// Classifier called after the rules, nil express do not classify.
+	Classifier ContentClassifier
+
+	// ReviewSink receives the flagged messages, default LogReviewSink.
+	ReviewSink ReviewSink
+
+	// MaskChar the character replaces the masked content, default '*'.
+	MaskChar rune
+}
+
+type compiledRule struct {
+	re   *regexp.Regexp
+	rule *ModerationRule
+}
+
+var _ MessageHandler = (*ModerationHandler)(nil)
+
+// ModerationHandler 内容审核, 在消息存储和投递前检查 message.chat, message.group, message.cli 和编辑后的内容, 命中规则的消息按策略
+// 屏蔽部分内容, 送审或拦截, 被拦截的消息不再传递给后续的 MessageHandler. 需要在其他 Handler 之前添加到处理链中.
+type ModerationHandler struct {
+	mu    sync.RWMutex
+	rules []*compiledRule
+
+	actionPolicies map[messages.Action]ModerationPolicy
+	classifier     ContentClassifier
+	sink           ReviewSink
+	maskChar       string
+}
+
+func NewModerationHandler(opts *ModerationOptions) (*ModerationHandler, error) {
+	ret := &ModerationHandler{
+		actionPolicies: opts.ActionPolicies,
+		classifier:     opts.Classifier,
+		sink:           opts.ReviewSink,
+		maskChar:       string(opts.MaskChar),
+	}
+	if ret.sink == nil {
+		ret.sink = LogReviewSink{}
+	}
+	if opts.MaskChar == 0 {
+		ret.maskChar = string(defaultMaskChar)
+	}
+	err := ret.SetRules(opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SetRules replaces the rules, used to reload the rules at runtime, the rules are not changed if any rule is invalid.
+func (m *ModerationHandler) SetRules(rules []*ModerationRule) error {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		var re *regexp.Regexp
+		var err error
+		if rule.Keyword != "" {
+			re, err = regexp.Compile("(?i)" + regexp.QuoteMeta(rule.Keyword))
+		} else if rule.Pattern != "" {
+			re, err = regexp.Compile(rule.Pattern)
+		} else {
+			err = errors.New("empty moderation rule")
+		}
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, &compiledRule{re: re, rule: rule})
+	}
+
+	m.mu.Lock()
+	m.rules = compiled
+	m.mu.Unlock()
+	return nil
+}
+
+// Moderate checks the content with rules and classifier, returns the verdict and the masked content.
+func (m *ModerationHandler) Moderate(from string, action messages.Action, content string) (*ModerationVerdict, string) {
+	verdict := &ModerationVerdict{Policy: ModerationPass}
+	override, hasOverride := m.actionPolicies[action]
+
+	apply := func(policy ModerationPolicy, reason string) {
+		if hasOverride && policy != ModerationPass {
+			policy = override
+		}
+		if policy > verdict.Policy {
+			verdict.Policy = policy
+			verdict.Reason = reason
+		}
+	}
+
+	m.mu.RLock()
+	rules := m.rules
+	m.mu.RUnlock()
+
+	masked := content
+	for _, r := range rules {
+		if !r.re.MatchString(masked) {
+			continue
+		}
+		apply(r.rule.Policy, r.rule.Reason)
+		if r.rule.Policy == ModerationMask && (!hasOverride || override == ModerationMask) {
+			masked = r.re.ReplaceAllStringFunc(masked, func(s string) string {
+				return strings.Repeat(m.maskChar, len([]rune(s)))
+			})
+		}
+	}
+
+	if m.classifier != nil && verdict.Policy != ModerationBlock {
+		v, err := m.classifier.Classify(from, action, content)
+		if err != nil {
+			logger.E("classify content error %v", err)
+		} else if v != nil {
+			apply(v.Policy, v.Reason)
+		}
+	}
+	return verdict, masked
+}
+
+func (m *ModerationHandler) Handle(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
+	switch message.GetAction() {
+	case messages.ActionChatMessage, messages.ActionGroupMessage:
+		return m.handleChatMessage(h, cliInfo, message)
+	case messages.ActionClientCustom:
+		return m.handleClientCustom(h, cliInfo, message)
+	case messages.ActionMessageEdit:
+		return m.handleMessageEdit(h, cliInfo, message)
+	}
+	return false
+}
+
+func (m *ModerationHandler) handleChatMessage(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
+	msg := new(messages.ChatMessage)
+	if message.Data.Deserialize(msg) != nil {
+		// invalid message is handled by the next handlers
+		return false
+	}
+	verdict, masked := m.Moderate(message.From, message.GetAction(), msg.Content)
+	if m.reject(h, cliInfo, message, msg.CliMid, msg.To, msg.Content, verdict) {
+		return true
+	}
+	if masked != msg.Content {
+		msg.Content = masked
+		message.Data = messages.NewData(msg)
+	}
+	return false
+}
+
+func (m *ModerationHandler) handleClientCustom(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
+	msg := new(messages.ClientCustom)
+	if message.Data.Deserialize(msg) != nil {
+		return false
+	}
+	content, isText := msg.Content.(string)
+	if !isText {
+		// the structured content is matched in json form and can not be masked
+		b, err := json.Marshal(msg.Content)
+		if err != nil {
+			return false
+		}
+		content = string(b)
+	}
+	verdict, masked := m.Moderate(message.From, message.GetAction(), content)
+	if !isText && masked != content && verdict.Policy == ModerationMask {
+		verdict.Policy = ModerationBlock
+	}
+	if m.reject(h, cliInfo, message, "", message.To, content, verdict) {
+		return true
+	}
+	if isText && masked != content {
+		msg.Content = masked
+		message.Data = messages.NewData(msg)
+	}
+	return false
+}
+
+func (m *ModerationHandler) handleMessageEdit(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
+	edit := new(messages.MessageEdit)
+	if message.Data.Deserialize(edit) != nil {
+		return false
+	}
+	verdict, masked := m.Moderate(message.From, message.GetAction(), edit.Content)
+	if m.reject(h, cliInfo, message, "", edit.To, edit.Content, verdict) {
+		return true
+	}
+	if masked != edit.Content {
+		edit.Content = masked
+		message.Data = messages.NewData(edit)
+	}
+	return false
+}
+
+// reject notifies the sender if the message is blocked, sends the flagged message to the review sink, returns true if
+// the message is blocked.
+func (m *ModerationHandler) reject(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage,
+	cliMid string, to string, content string, verdict *ModerationVerdict) bool {
+
+	switch verdict.Policy {
+	case ModerationBlock:
+		code := verdict.Reason
+		if code == "" {
+			code = ForbiddenCodeModeration
+		}
+		notify := messages.NewMessage(message.GetSeq(), messages.ActionNotifyForbidden, &messages.Forbidden{
+			Code:   code,
+			Action: string(message.GetAction()),
+			CliMid: cliMid,
+		})
+		err := h.GetClientInterface().EnqueueMessage(cliInfo.ID, notify)
+		if err != nil {
+			logger.E("notify forbidden error %v", err)
+		}
+		return true
+	case ModerationFlag:
+		m.sink.Review(&ReviewItem{
+			From:    message.From,
+			To:      to,
+			Action:  message.GetAction(),
+			Content: content,
+			Reason:  verdict.Reason,
+			At:      time.Now().Unix(),
+		})
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestModerationHandler_Moderate(t *testing.T) {
+	h, err := NewModerationHandler(&ModerationOptions{
+		Rules: []*ModerationRule{
+			{Keyword: "foo", Policy: ModerationMask},
+			{Pattern: `\d{11}`, Policy: ModerationFlag, Reason: "phone"},
+			{Keyword: "spam", Policy: ModerationBlock, Reason: "spam"},
+		},
+		ActionPolicies: map[messages.Action]ModerationPolicy{
+			messages.ActionClientCustom: ModerationBlock,
+		},
+	})
+	assert.NoError(t, err)
+
+	v, masked := h.Moderate("1", messages.ActionChatMessage, "hello FOO")
+	assert.Equal(t, ModerationMask, v.Policy)
+	assert.Equal(t, "hello ***", masked)
+
+	v, _ = h.Moderate("1", messages.ActionChatMessage, "foo 13800000000")
+	assert.Equal(t, ModerationFlag, v.Policy)
+	assert.Equal(t, "phone", v.Reason)
+
+	v, _ = h.Moderate("1", messages.ActionChatMessage, "spam foo")
+	assert.Equal(t, ModerationBlock, v.Policy)
+
+	// the policy is overridden for message.cli
+	v, _ = h.Moderate("1", messages.ActionClientCustom, "foo")
+	assert.Equal(t, ModerationBlock, v.Policy)
+
+	// invalid rules are not applied
+	assert.Error(t, h.SetRules([]*ModerationRule{{Pattern: "("}}))
+	v, _ = h.Moderate("1", messages.ActionChatMessage, "spam")
+	assert.Equal(t, ModerationBlock, v.Policy)
+}
+
+func TestModerationHandler_HandleEdit(t *testing.T) {
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{gate.NewID2("1"): true, gate.NewID2("2"): true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	moderation, err := NewModerationHandler(&ModerationOptions{
+		Rules: []*ModerationRule{
+			{Keyword: "foo", Policy: ModerationMask},
+			{Keyword: "spam", Policy: ModerationBlock, Reason: "spam"},
+		},
+	})
+	assert.NoError(t, err)
+	ms := store.NewMemoryMessageStore(0)
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore: ms,
+		HistoryStore: ms,
+		Moderation:   moderation,
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	msg := messages.NewMessage(1, messages.ActionChatMessage, &messages.ChatMessage{CliMid: "1", Type: messages.MessageTypeText, Content: "hello"})
+	msg.To = "2"
+	assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2("1")}, msg))
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionAckMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+	ack := messages.AckMessage{}
+	assert.NoError(t, g.get(gate.NewID2("1"), messages.ActionAckMessage)[0].Data.Deserialize(&ack))
+
+	edit := func(content string) {
+		msg := messages.NewMessage(2, messages.ActionMessageEdit, &messages.MessageEdit{
+			ChatType: messages.ChatTypeSingle,
+			Mid:      ack.Mid,
+			Content:  content,
+		})
+		assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2("1")}, msg))
+	}
+
+	// the blocked content can not be brought in by editing
+	edit("spam")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionNotifyForbidden)) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Empty(t, g.get(gate.NewID2("2"), messages.ActionMessageEdit))
+
+	edit("hello foo")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("2"), messages.ActionMessageEdit)) == 1
+	}, time.Second, time.Millisecond*10)
+	e := messages.MessageEdit{}
+	assert.NoError(t, g.get(gate.NewID2("2"), messages.ActionMessageEdit)[0].Data.Deserialize(&e))
+	assert.Equal(t, "hello ***", e.Content)
+	stored, err := ms.GetMessage(messages.ChatTypeSingle, ack.Mid)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello ***", stored.Content)
+}
@@ -1,73 +1,93 @@
 package messaging
 
 import (
-	"github.com/glide-im/glide/internal/pkg/db"
 	"github.com/glide-im/glide/pkg/gate"
 	"github.com/glide-im/glide/pkg/logger"
 	"github.com/glide-im/glide/pkg/messages"
-	"time"
+	"sort"
 )
 
 const (
-	KeyRedisOfflineMsgPrefix = "im:msg:offline:"
+	defaultOfflineSyncLimit = 50
+	maxOfflineSyncLimit     = 200
 )
 
-var StoreOfflineMessage = false
+const (
+	errOfflineNotSupported    = "offline message is not supported"
+	errOfflineUnauthenticated = "unauthenticated"
+	errOfflineInvalidRequest  = "invalid offline sync request"
+)
 
-func offlineMessageHandler(_ *MessageHandlerImpl, _ *gate.Info, m *messages.GlideMessage) {
-	if !StoreOfflineMessage {
-		return
+// handleOfflineSync 客户端拉取离线消息, 返回 cursor 之后的一页离线消息, 消息在客户端确认前不会删除
+func (d *MessageHandlerImpl) handleOfflineSync(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.offline == nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errOfflineNotSupported), nil
 	}
-	if m.GetAction() == messages.ActionChatMessage || m.GetAction() == messages.ActionChatMessageResend {
-		c := messages.ChatMessage{}
-		err := m.Data.Deserialize(&c)
-		if err != nil {
-			logger.E("deserialize chat message error: %v", err)
-			return
-		}
-		bytes, err := messages.JsonCodec.Encode(m)
-		if err != nil {
-			logger.E("deserialize chat message error: %v", err)
-			return
-		}
-		storeOfflineMessage(m.To, string(bytes))
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errOfflineUnauthenticated), nil
+	}
+	req := new(messages.OfflineSync)
+	if !d.unmarshalData(c, m, req) {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errOfflineInvalidRequest), nil
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultOfflineSyncLimit
+	}
+	if req.Limit > maxOfflineSyncLimit {
+		req.Limit = maxOfflineSyncLimit
 	}
-}
-
-func storeOfflineMessage(to string, msg string) {
-	key := KeyRedisOfflineMsgPrefix + to
-	db.Redis.SAdd(key, msg)
-	// TODO 2022-6-22 16:56:57 do not reset expire on new offline message arrived
-	// use fixed time segment save offline msg reset segment only.
-	db.Redis.Expire(key, time.Hour*24*2)
-}
 
-func PushOfflineMessage(h *MessageInterfaceImpl, id string) {
-	key := KeyRedisOfflineMsgPrefix + id
-	members, err := db.Redis.SMembers(key).Result()
+	list, err := d.offline.RangeOffline(c.ID.UID(), req.Cursor, req.Limit+1)
 	if err != nil {
-		logger.ErrE("push offline msg error", err)
-		return
+		logger.E("range offline message error %v", err)
+		return nil, err
 	}
-	for _, member := range members {
-		msg := messages.NewEmptyMessage()
-		err := messages.JsonCodec.Decode([]byte(member), msg)
-		if err != nil {
-			logger.ErrE("deserialize redis offline msg error", err)
+
+	more := len(list) > req.Limit
+	if more {
+		list = list[:req.Limit]
+	}
+	result := messages.OfflineMessages{
+		Cursor: req.Cursor,
+		More:   more,
+	}
+	for _, om := range list {
+		if om.Cursor > result.Cursor {
+			result.Cursor = om.Cursor
+		}
+		// the expired messages are skipped, and removed when the cursor acked
+		if om.Message != nil && d.isExpired(om.Message) {
 			continue
 		}
-		id2 := gate.NewID2(id)
-		_ = h.GetClientInterface().EnqueueMessage(id2, msg)
+		result.Messages = append(result.Messages, om)
 	}
-
-	// AckOfflineMessage(id)
+	sort.SliceStable(result.Messages, func(i, j int) bool {
+		mi, mj := result.Messages[i].Message, result.Messages[j].Message
+		if mi.SendAt != mj.SendAt {
+			return mi.SendAt < mj.SendAt
+		}
+		return mi.Seq < mj.Seq
+	})
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, &result), nil
 }
 
-func AckOfflineMessage(id string) {
-	key := KeyRedisOfflineMsgPrefix + id
-	result, err := db.Redis.Del(key).Result()
+// handleAckOffline 客户端确认收到离线消息, 仅删除 cursor 及之前的离线消息
+func (d *MessageHandlerImpl) handleAckOffline(c *gate.Info, m *messages.GlideMessage) error {
+	if d.offline == nil || c.ID.IsTemp() {
+		return nil
+	}
+	ack := new(messages.OfflineAck)
+	if !d.unmarshalData(c, m, ack) {
+		return nil
+	}
+	if ack.Cursor <= 0 {
+		return nil
+	}
+	count, err := d.offline.AckOffline(c.ID.UID(), ack.Cursor)
 	if err != nil {
 		logger.ErrE("remove offline message error", err)
+		return err
 	}
-	logger.I("user %s ack %d offline messages", id, result)
+	logger.I("user %s ack %d offline messages", c.ID.UID(), count)
+	return nil
 }
@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageHandlerImpl_OfflineSync(t *testing.T) {
+	offline := store.NewMemoryOfflineStore()
+	d := &MessageHandlerImpl{offline: offline}
+	c := &gate.Info{ID: gate.NewID2("1")}
+
+	sync := func(cursor int64, limit int) *messages.OfflineMessages {
+		m := messages.NewMessage(1, messages.ActionApiOfflineSync, &messages.OfflineSync{Cursor: cursor, Limit: limit})
+		reply, err := d.handleOfflineSync(c, m)
+		assert.NoError(t, err)
+		assert.Equal(t, messages.Action(messages.ActionApiSuccess), reply.GetAction())
+		result := &messages.OfflineMessages{}
+		assert.NoError(t, reply.Data.Deserialize(result))
+		return result
+	}
+	mids := func(result *messages.OfflineMessages) []int64 {
+		var ret []int64
+		for _, om := range result.Messages {
+			ret = append(ret, om.Message.Mid)
+		}
+		return ret
+	}
+
+	// nothing to sync, the cursor is kept
+	result := sync(0, 2)
+	assert.Empty(t, result.Messages)
+	assert.Zero(t, result.Cursor)
+	assert.False(t, result.More)
+
+	now := time.Now().Unix()
+	for i := int64(1); i <= 5; i++ {
+		_, err := offline.AppendOffline("1", &messages.ChatMessage{Mid: i, From: "2", To: "1", SendAt: now, Seq: i})
+		assert.NoError(t, err)
+	}
+
+	// page by the cursor of the previous page
+	result = sync(0, 2)
+	assert.Equal(t, []int64{1, 2}, mids(result))
+	assert.True(t, result.More)
+	result = sync(result.Cursor, 2)
+	assert.Equal(t, []int64{3, 4}, mids(result))
+	assert.True(t, result.More)
+	last := sync(result.Cursor, 2)
+	assert.Equal(t, []int64{5}, mids(last))
+	assert.False(t, last.More)
+
+	// the messages are kept until acked, only the messages not after the cursor are removed
+	ack := func(cursor int64) {
+		m := messages.NewMessage(0, messages.AckOffline, &messages.OfflineAck{Cursor: cursor})
+		assert.NoError(t, d.handleAckOffline(c, m))
+	}
+	ack(0)
+	count, _ := offline.CountOffline("1")
+	assert.Equal(t, int64(5), count)
+	ack(result.Cursor)
+	count, _ = offline.CountOffline("1")
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, []int64{5}, mids(sync(0, 2)))
+
+	// the synced cursor is kept when there is nothing after it
+	result = sync(last.Cursor, 2)
+	assert.Empty(t, result.Messages)
+	assert.Equal(t, last.Cursor, result.Cursor)
+
+	// the temp client can not sync
+	temp, _ := gate.GenTempID("")
+	reply, err := d.handleOfflineSync(&gate.Info{ID: temp}, messages.NewMessage(1, messages.ActionApiOfflineSync, &messages.OfflineSync{}))
+	assert.NoError(t, err)
+	assert.Equal(t, messages.Action(messages.ActionApiFailed), reply.GetAction())
+}
@@ -0,0 +1,190 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/push"
+	"github.com/glide-im/glide/pkg/store"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	errPushNotSupported   = "push is not supported"
+	errPushInvalidSetting = "invalid push setting"
+
+	minutesOfDay = 24 * 60
+
+	defaultPushConcurrency = 32
+	defaultPushQueueSize   = 10000
+)
+
+// pushQueue runs the offline pushes by a fixed count of workers, the push is dropped and counted when the queue is
+// full, so that a slow push bridge does not pile up goroutines.
+type pushQueue struct {
+	queue   chan *messages.ChatMessage
+	dropped int64
+}
+
+func newPushQueue(workers int, size int, push func(msg *messages.ChatMessage)) *pushQueue {
+	if workers <= 0 {
+		workers = defaultPushConcurrency
+	}
+	if size <= 0 {
+		size = defaultPushQueueSize
+	}
+	q := &pushQueue{
+		queue: make(chan *messages.ChatMessage, size),
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for msg := range q.queue {
+				push(msg)
+			}
+		}()
+	}
+	return q
+}
+
+// submit queues the push, returns false if the queue is full and the push is dropped.
+func (q *pushQueue) submit(msg *messages.ChatMessage) bool {
+	select {
+	case q.queue <- msg:
+		return true
+	default:
+		atomic.AddInt64(&q.dropped, 1)
+		return false
+	}
+}
+
+// submitPush pushes the notification of the message to the offline receiver in background.
+func (d *MessageHandlerImpl) submitPush(msg *messages.ChatMessage) {
+	if d.pushQueue == nil {
+		return
+	}
+	if !d.pushQueue.submit(msg) {
+		logger.W("push queue is full, the push of message %d is dropped, %d dropped in total", msg.Mid, d.PushDropped())
+	}
+}
+
+// PushDropped returns the count of the offline pushes dropped because the push queue is full.
+func (d *MessageHandlerImpl) PushDropped() int64 {
+	if d.pushQueue == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&d.pushQueue.dropped)
+}
+
+// collapseKey returns the collapse key of the conversation, the notifications of a conversation replace each other.
+func collapseKey(chatType int32, id string) string {
+	return strconv.Itoa(int(chatType)) + "_" + id
+}
+
+// inDoNotDisturb returns true if the time is in the do-not-disturb period of the setting.
+func inDoNotDisturb(s *messages.PushSetting, now time.Time) bool {
+	if s.DndStart == s.DndEnd {
+		return false
+	}
+	t := now.UTC().Add(time.Duration(s.TzOffset) * time.Minute)
+	m := int32(t.Hour()*60 + t.Minute())
+	if s.DndStart < s.DndEnd {
+		return m >= s.DndStart && m < s.DndEnd
+	}
+	return m >= s.DndStart || m < s.DndEnd
+}
+
+// pushOffline 接收者不在线, 通过 push.Bridge 推送通知, 用户关闭推送或会话免打扰时不推送, 勿扰时段内静默推送只更新角标
+func (d *MessageHandlerImpl) pushOffline(msg *messages.ChatMessage) {
+	if d.pushBridge == nil {
+		return
+	}
+	setting, err := d.pushSetting.GetPushSetting(msg.To)
+	if err != nil {
+		logger.E("get push setting error %v", err)
+		return
+	}
+	if setting.Muted {
+		return
+	}
+
+	var badge int64
+	if bs, ok := d.conversation.(store.ConversationBadgeStore); ok {
+		c, err := bs.GetConversation(msg.To, messages.ChatTypeSingle, msg.From)
+		if err != nil {
+			logger.E("get conversation error %v", err)
+		} else if c != nil && c.Muted {
+			return
+		}
+		badge, err = bs.TotalUnread(msg.To)
+		if err != nil {
+			logger.E("get total unread error %v", err)
+		}
+	}
+
+	content := previewOf(msg.Content)
+	if isSelfDestructing(msg) {
+		content = ""
+	}
+	title, body, err := d.pushTemplates.Render(&push.TemplateData{
+		ChatType: messages.ChatTypeSingle,
+		From:     msg.From,
+		To:       msg.To,
+		Type:     msg.Type,
+		Content:  content,
+	})
+	if err != nil {
+		logger.E("render push template error %v", err)
+		return
+	}
+	err = d.pushBridge.Push(&push.Notification{
+		Uid:         msg.To,
+		CollapseKey: collapseKey(messages.ChatTypeSingle, msg.From),
+		Title:       title,
+		Body:        body,
+		Badge:       badge,
+		Silent:      inDoNotDisturb(setting, time.Now()),
+		ChatType:    messages.ChatTypeSingle,
+		From:        msg.From,
+		Mid:         msg.Mid,
+		Type:        msg.Type,
+	})
+	if err != nil {
+		logger.E("push offline notification error %v", err)
+	}
+}
+
+// handleApiPushSetting 设置离线推送的免打扰
+func (d *MessageHandlerImpl) handleApiPushSetting(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.pushBridge == nil || c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPushNotSupported), nil
+	}
+	req := new(messages.PushSetting)
+	if !d.unmarshalData(c, m, req) {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPushInvalidSetting), nil
+	}
+	if req.DndStart < 0 || req.DndStart >= minutesOfDay || req.DndEnd < 0 || req.DndEnd >= minutesOfDay ||
+		req.TzOffset < -14*60 || req.TzOffset > 14*60 {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPushInvalidSetting), nil
+	}
+	err := d.pushSetting.SetPushSetting(c.ID.UID(), req)
+	if err != nil {
+		logger.E("set push setting error %v", err)
+		return nil, err
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, req), nil
+}
+
+// handleApiPushSettingGet 查询离线推送设置
+func (d *MessageHandlerImpl) handleApiPushSettingGet(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.pushBridge == nil || c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPushNotSupported), nil
+	}
+	s, err := d.pushSetting.GetPushSetting(c.ID.UID())
+	if err != nil {
+		logger.E("get push setting error %v", err)
+		return nil, err
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, s), nil
+}
@@ -0,0 +1,87 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/push"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageHandlerImpl_pushOffline(t *testing.T) {
+	bridge := push.NewMockBridge()
+	templates, _ := push.NewTemplates(push.DefaultTemplate, nil)
+	conv := store.NewMemoryConversationStore()
+	settings := store.NewMemoryPushSettingStore()
+	d := &MessageHandlerImpl{
+		conversation:  conv,
+		pushBridge:    bridge,
+		pushTemplates: templates,
+		pushSetting:   settings,
+	}
+
+	msg := &messages.ChatMessage{Mid: 1, From: "1", To: "2", Content: "hello", SendAt: time.Now().Unix()}
+	_ = conv.UpdateConversation("2", lastMessageOf(messages.ChatTypeSingle, "1", msg), true)
+	_ = conv.UpdateConversation("2", lastMessageOf(messages.ChatTypeSingle, "3", msg), true)
+	d.pushOffline(msg)
+
+	n := bridge.Notifications()
+	assert.Len(t, n, 1)
+	assert.Equal(t, "hello", n[0].Body)
+	assert.Equal(t, int64(2), n[0].Badge)
+	assert.Equal(t, collapseKey(messages.ChatTypeSingle, "1"), n[0].CollapseKey)
+	assert.False(t, n[0].Silent)
+
+	// do-not-disturb all day except the next minute
+	now := time.Now().UTC()
+	next := int32(now.Hour()*60+now.Minute()+1) % minutesOfDay
+	_ = settings.SetPushSetting("2", &messages.PushSetting{DndStart: (next + 1) % minutesOfDay, DndEnd: next})
+	d.pushOffline(msg)
+	assert.True(t, bridge.Notifications()[1].Silent)
+
+	muted := true
+	_ = conv.SetConversationFlags("2", messages.ChatTypeSingle, "1", nil, &muted)
+	d.pushOffline(msg)
+	_ = settings.SetPushSetting("2", &messages.PushSetting{Muted: true})
+	d.pushOffline(&messages.ChatMessage{Mid: 2, From: "3", To: "2"})
+	assert.Len(t, bridge.Notifications(), 2)
+}
+
+func TestInDoNotDisturb(t *testing.T) {
+	at := func(h, m int) time.Time {
+		return time.Date(2022, 1, 1, h, m, 0, 0, time.UTC)
+	}
+	night := &messages.PushSetting{DndStart: 22 * 60, DndEnd: 7 * 60}
+	assert.True(t, inDoNotDisturb(night, at(23, 0)))
+	assert.True(t, inDoNotDisturb(night, at(6, 59)))
+	assert.False(t, inDoNotDisturb(night, at(7, 0)))
+
+	// 22:00-07:00 in UTC+8
+	night.TzOffset = 8 * 60
+	assert.True(t, inDoNotDisturb(night, at(15, 0)))
+	assert.False(t, inDoNotDisturb(night, at(23, 0)))
+
+	assert.False(t, inDoNotDisturb(&messages.PushSetting{}, at(12, 0)))
+}
+
+func TestPushQueue_Submit(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	q := newPushQueue(1, 1, func(msg *messages.ChatMessage) {
+		started <- struct{}{}
+		<-release
+	})
+
+	assert.True(t, q.submit(&messages.ChatMessage{Mid: 1}))
+	<-started
+	// the worker is busy, the second waits in the queue, and the third is dropped
+	assert.True(t, q.submit(&messages.ChatMessage{Mid: 2}))
+	assert.False(t, q.submit(&messages.ChatMessage{Mid: 3}))
+	assert.Equal(t, int64(1), q.dropped)
+
+	// the queued push runs after the worker is free
+	close(release)
+	<-started
+	assert.Empty(t, q.queue)
+}
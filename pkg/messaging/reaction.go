@@ -0,0 +1,135 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"time"
+)
+
+const (
+	// defaultMaxReactionsPerUser the default max count of distinct reactions of a user to a message
+	defaultMaxReactionsPerUser = 5
+	// maxEmojiLength the max bytes of the emoji, allows the short code of custom emoji
+	maxEmojiLength = 64
+	// reactionUsers the max count of users returned in each reaction summary
+	reactionUsers = 10
+)
+
+const (
+	errReactionNotSupported = "reaction is not supported"
+	errReactionInvalid      = "invalid reaction"
+	errReactionPermission   = "permission denied: reaction"
+	errReactionLimit        = "too many reactions"
+)
+
+// handleReaction 添加或取消对消息的表情回应, 单聊仅会话双方, 频道仅有读权限的成员可回应, 成功后通知会话中的所有设备
+func (d *MessageHandlerImpl) handleReaction(c *gate.Info, m *messages.GlideMessage) error {
+	reaction := new(messages.Reaction)
+	if !d.unmarshalData(c, m, reaction) {
+		return nil
+	}
+
+	err := d.validateReaction(c, reaction)
+	if err != nil {
+		d.enqueueMessage(c.ID, messages.NewMessage(m.GetSeq(), messages.ActionNotifyError, err.Error()))
+		return nil
+	}
+	if reaction.ChatType == messages.ChatTypeSingle && d.blocks.IsBlocked(peerOf(reaction.Uid, reaction.From, reaction.To), reaction.Uid) {
+		// 单聊另一方已拉黑回应者, 回应不保存不通知, 与未改变的回应一样没有回执
+		return nil
+	}
+
+	var changed bool
+	if reaction.Remove {
+		changed, err = d.reactions.RemoveReaction(reaction.ChatType, reaction.Mid, reaction.Uid, reaction.Emoji)
+	} else {
+		changed, err = d.reactions.AddReaction(reaction.ChatType, reaction.Mid, reaction.Uid, reaction.Emoji, d.maxReactions)
+	}
+	if store.IsReactionLimit(err) {
+		d.enqueueMessage(c.ID, messages.NewMessage(m.GetSeq(), messages.ActionNotifyError, errReactionLimit))
+		return nil
+	}
+	if err != nil {
+		logger.E("update reaction error %v", err)
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	summaries, err := d.reactions.GetReactions(reaction.ChatType, reactionUsers, reaction.Mid)
+	if err != nil {
+		logger.E("get reactions error %v", err)
+		return err
+	}
+	reaction.Reactions = summaries[reaction.Mid]
+	notify := messages.NewMessage(0, messages.ActionNotifyReaction, reaction)
+	d.dispatchConversation(reaction.ChatType, reaction.Uid, reaction.From, reaction.To, notify)
+	return nil
+}
+
+// validateReaction checks the reaction request and fills the reaction with the info of the reacted message.
+func (d *MessageHandlerImpl) validateReaction(c *gate.Info, reaction *messages.Reaction) error {
+	if d.reactions == nil || d.history == nil {
+		return errors.New(errReactionNotSupported)
+	}
+	if reaction.ChatType != messages.ChatTypeSingle && reaction.ChatType != messages.ChatTypeChannel {
+		return errors.New(errUnknownChatType)
+	}
+	if reaction.Mid <= 0 || reaction.Emoji == "" || len(reaction.Emoji) > maxEmojiLength {
+		return errors.New(errReactionInvalid)
+	}
+
+	uid := c.ID.UID()
+	origin, err := d.getMessage(reaction.ChatType, reaction.Mid, uid)
+	if err != nil {
+		return err
+	}
+	switch reaction.ChatType {
+	case messages.ChatTypeSingle:
+		if origin.From != uid && origin.To != uid {
+			return errors.New(errReactionPermission)
+		}
+	case messages.ChatTypeChannel:
+		if !d.canReadChannel(origin.To, uid) {
+			return errors.New(errReactionPermission)
+		}
+	}
+
+	reaction.Uid = uid
+	reaction.From = origin.From
+	reaction.To = origin.To
+	reaction.ReactAt = time.Now().Unix()
+	reaction.Reactions = nil
+	return nil
+}
+
+// fillReactions sets the aggregated reactions of the messages, the messages are returned without reactions if failed.
+func (d *MessageHandlerImpl) fillReactions(chatType int32, list []*messages.ChatMessage) {
+	if d.reactions == nil || len(list) == 0 {
+		return
+	}
+	mids := make([]int64, 0, len(list))
+	for _, msg := range list {
+		mids = append(mids, msg.Mid)
+	}
+	summaries, err := d.reactions.GetReactions(chatType, reactionUsers, mids...)
+	if err != nil {
+		logger.E("get reactions error %v", err)
+		return
+	}
+	for _, msg := range list {
+		msg.Reactions = summaries[msg.Mid]
+	}
+}
+
+// peerOf returns the other participant of the single chat between from and to.
+func peerOf(uid string, from string, to string) string {
+	if uid == to {
+		return from
+	}
+	return to
+}
@@ -0,0 +1,53 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageHandlerImpl_ReactChannelMessage(t *testing.T) {
+	h, g, _ := newChannelTestHandler(t)
+
+	mid1 := sendChannelMessage(t, h, g, "1", "hello")
+	mid2 := sendChannelMessage(t, h, g, "2", "world")
+	assert.NotEqual(t, mid1, mid2)
+
+	react := func(uid string, mid int64, emoji string) {
+		msg := messages.NewMessage(2, messages.ActionMessageReact, &messages.Reaction{
+			ChatType: messages.ChatTypeChannel,
+			Mid:      mid,
+			Emoji:    emoji,
+		})
+		assert.NoError(t, h.Handle(&gate.Info{ID: gate.NewID2(uid)}, msg))
+	}
+	notified := func(n int) {
+		assert.Eventually(t, func() bool {
+			return len(g.get(gate.NewID2("1"), messages.ActionNotifyReaction)) == n
+		}, time.Second, time.Millisecond*10)
+	}
+
+	react("3", mid1, "+1")
+	notified(1)
+	react("2", mid1, "+1")
+	notified(2)
+	react("3", mid2, "heart")
+	notified(3)
+
+	// the reactions are aggregated per message
+	summaries, err := h.reactions.GetReactions(messages.ChatTypeChannel, 10, mid1, mid2)
+	assert.NoError(t, err)
+	assert.Len(t, summaries[mid1], 1)
+	assert.Equal(t, "+1", summaries[mid1][0].Emoji)
+	assert.Equal(t, int64(2), summaries[mid1][0].Count)
+	assert.Len(t, summaries[mid2], 1)
+	assert.Equal(t, "heart", summaries[mid2][0].Emoji)
+
+	// the message without mid can not be reacted
+	react("3", 0, "+1")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("3"), messages.ActionNotifyError)) == 1
+	}, time.Second, time.Millisecond*10)
+}
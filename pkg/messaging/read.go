@@ -0,0 +1,85 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"strconv"
+)
+
+const errReadPermission = "permission denied: read"
+
+// conversationID returns the id of conversation with peer uid or channel id, used as key of conversation in stores.
+func conversationID(chatType int32, to string) string {
+	return strconv.Itoa(int(chatType)) + "_" + to
+}
+
+// handleAckRead 已读回执, 推进读者在会话中的已读位置, 同步给读者的其他设备, 并按配置通知单聊对方或频道已读人数,
+// 频道仅有读权限的成员可回执
+func (d *MessageHandlerImpl) handleAckRead(c *gate.Info, m *messages.GlideMessage) error {
+	receipt := new(messages.ReadReceipt)
+	if !d.unmarshalData(c, m, receipt) {
+		return nil
+	}
+	if receipt.To == "" {
+		receipt.To = m.To
+	}
+	if receipt.ChatType != messages.ChatTypeSingle && receipt.ChatType != messages.ChatTypeChannel {
+		return errors.New(errUnknownChatType)
+	}
+	receipt.From = c.ID.UID()
+	receipt.ReadCount = 0
+	if receipt.ChatType == messages.ChatTypeChannel && !d.canReadChannel(receipt.To, receipt.From) {
+		d.enqueueMessage(c.ID, messages.NewMessage(m.GetSeq(), messages.ActionNotifyError, errReadPermission))
+		return nil
+	}
+
+	d.clearUnread(receipt.From, receipt.ChatType, receipt.To)
+	if receipt.ChatType == messages.ChatTypeSingle && receipt.Mid != 0 {
+		d.burnAfterRead(receipt.From, receipt.Mid)
+	}
+
+	conversation := conversationID(receipt.ChatType, receipt.To)
+	if d.readCursor != nil {
+		advanced, err := d.readCursor.UpdateReadCursor(receipt.From, conversation, receipt.Seq)
+		if err != nil {
+			logger.E("update read cursor error %v", err)
+			return err
+		}
+		if !advanced {
+			return nil
+		}
+		if receipt.ChatType == messages.ChatTypeChannel && d.channelReadCount {
+			receipt.ReadCount, err = d.readCursor.CountReaders(conversation, receipt.Seq, nil)
+			if err != nil {
+				logger.E("count channel readers error %v", err)
+			}
+		}
+	}
+
+	notify := messages.NewMessage(0, messages.ActionNotifyRead, receipt)
+	d.dispatchOtherDevices(c.ID, notify)
+
+	switch receipt.ChatType {
+	case messages.ChatTypeSingle:
+		if d.readReceiptToSender {
+			d.dispatchAllDevice(receipt.To, notify)
+		}
+	case messages.ChatTypeChannel:
+		if d.channelReadCount && receipt.ReadCount > 0 {
+			pm := subscription_impl.PublishMessage{
+				From:    subscription.SubscriberID(receipt.From),
+				Message: notify,
+				Type:    subscription_impl.TypeNotify,
+			}
+			err := d.def.GetGroupInterface().PublishMessage(subscription.ChanID(receipt.To), &pm)
+			if err != nil {
+				logger.E("publish channel read count error %v", err)
+			}
+		}
+	}
+	return nil
+}
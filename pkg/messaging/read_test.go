@@ -0,0 +1,71 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageHandlerImpl_AckReadChannel(t *testing.T) {
+	h, g, _ := newChannelTestHandler(t)
+	cursors := store.NewMemoryReadCursorStore()
+	h.readCursor = cursors
+	h.channelReadCount = true
+	g.online[gate.NewID2("4")] = true
+
+	ack := func(uid string, seq int64) {
+		msg := messages.NewMessage(1, messages.ActionAckRead, &messages.ReadReceipt{ChatType: messages.ChatTypeChannel, To: "ch", Seq: seq})
+		assert.NoError(t, h.Handle(&gate.Info{ID: gate.NewID2(uid)}, msg))
+	}
+
+	// the user not in the channel can not move the read cursor
+	ack("4", 10)
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("4"), messages.ActionNotifyError)) == 1
+	}, time.Second, time.Millisecond*10)
+	seq, err := cursors.GetReadCursor("4", conversationID(messages.ChatTypeChannel, "ch"))
+	assert.NoError(t, err)
+	assert.Zero(t, seq)
+	assert.Empty(t, g.get(gate.NewID2("1"), messages.ActionNotifyRead))
+
+	ack("3", 10)
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionNotifyRead)) == 1
+	}, time.Second, time.Millisecond*10)
+	receipt := messages.ReadReceipt{}
+	assert.NoError(t, g.get(gate.NewID2("1"), messages.ActionNotifyRead)[0].Data.Deserialize(&receipt))
+	assert.Equal(t, "3", receipt.From)
+	assert.Equal(t, int64(1), receipt.ReadCount)
+}
+
+func TestMessageHandlerImpl_GroupAckCoverage(t *testing.T) {
+	h, _, _ := newChannelTestHandler(t)
+	cursors := store.NewMemoryReadCursorStore()
+	h.readCursor = cursors
+	h.channelAck = store.NewMemoryChannelAckStore()
+	sub := subscription_impl.NewSubscribeWrap(h.def.GetGroupInterface().(subscription.Subscribe))
+	assert.NoError(t, sub.Subscribe("ch", "admin", &subscription_impl.SubscriberOptions{Perm: subscription_impl.PermRead | subscription_impl.PermAdmin}))
+
+	conversation := conversationID(messages.ChatTypeChannel, "ch")
+	_, _ = cursors.UpdateReadCursor("1", conversation, 10)
+	_, _ = cursors.UpdateReadCursor("3", conversation, 10)
+	// the users not in the channel, such as who left, are not counted
+	_, _ = cursors.UpdateReadCursor("4", conversation, 10)
+	_, _ = cursors.UpdateReadCursor("5", conversation, 10)
+	_, _ = cursors.UpdateReadCursor("6", conversation, 10)
+
+	msg := messages.NewMessage(1, messages.ActionApiGroupAckCoverage, &messages.GroupAckCoverage{Seq: 10})
+	msg.To = "ch"
+	reply, err := h.handleApiGroupAckCoverage(&gate.Info{ID: gate.NewID2("admin")}, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, messages.Action(messages.ActionApiSuccess), reply.GetAction())
+	result := messages.GroupAckCoverage{}
+	assert.NoError(t, reply.Data.Deserialize(&result))
+	assert.Equal(t, int64(4), result.Total)
+	assert.Equal(t, int64(2), result.Read)
+}
@@ -0,0 +1,92 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"time"
+)
+
+const defaultRecallWindow = time.Minute * 2
+
+const (
+	errRecallNotSupported   = "recall is not supported"
+	errRecallPermission     = "permission denied: recall"
+	errRecallWindowExceeded = "recall time window exceeded"
+	errRecallRecalled       = "message already recalled"
+	errUnknownChatType      = "unknown chat type"
+)
+
+// handleRecallMessage 撤回消息, 仅消息发送者(或频道管理员)可在撤回时间窗口内撤回, 撤回后通知会话中的所有设备
+func (d *MessageHandlerImpl) handleRecallMessage(c *gate.Info, m *messages.GlideMessage) error {
+	recall := new(messages.Recall)
+	if !d.unmarshalData(c, m, recall) {
+		return nil
+	}
+
+	err := d.validateRecall(c, recall)
+	if err != nil {
+		d.enqueueMessage(c.ID, messages.NewMessage(m.GetSeq(), messages.ActionNotifyError, err.Error()))
+		return nil
+	}
+
+	err = d.store.RecallMessage(recall)
+	if err != nil {
+		logger.E("recall message error %v", err)
+		return err
+	}
+
+	notify := messages.NewMessage(0, messages.ActionMessageRecall, recall)
+	d.dispatchConversation(recall.ChatType, recall.RecallBy, recall.From, recall.To, notify)
+	return nil
+}
+
+// validateRecall checks the recall request and fills the recall with the info of the recalled message.
+func (d *MessageHandlerImpl) validateRecall(c *gate.Info, recall *messages.Recall) error {
+	if d.history == nil {
+		return errors.New(errRecallNotSupported)
+	}
+	if recall.ChatType != messages.ChatTypeSingle && recall.ChatType != messages.ChatTypeChannel {
+		return errors.New(errUnknownChatType)
+	}
+
+	origin, err := d.getMessage(recall.ChatType, recall.Mid, c.ID.UID())
+	if err != nil {
+		return err
+	}
+	if time.Since(time.Unix(origin.SendAt, 0)) > d.recallWindow {
+		return errors.New(errRecallWindowExceeded)
+	}
+
+	by := c.ID.UID()
+	if origin.From != by {
+		if recall.ChatType != messages.ChatTypeChannel || !d.isChannelAdmin(origin.To, by) {
+			return errors.New(errRecallPermission)
+		}
+	}
+	if origin.Status == messages.MessageStatusRecalled {
+		return errors.New(errRecallRecalled)
+	}
+
+	recall.From = origin.From
+	recall.To = origin.To
+	recall.RecallBy = by
+	recall.RecallAt = time.Now().Unix()
+	return nil
+}
+
+// isChannelAdmin returns true if the uid is the admin of the channel.
+func (d *MessageHandlerImpl) isChannelAdmin(ch string, uid string) bool {
+	q, ok := d.def.GetGroupInterface().(subscription_impl.SubscriberQuery)
+	if !ok {
+		return false
+	}
+	info, err := q.GetSubscriber(subscription.ChanID(ch), subscription.SubscriberID(uid))
+	if err != nil {
+		return false
+	}
+	return info.IsAdmin()
+}
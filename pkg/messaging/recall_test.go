@@ -0,0 +1,114 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// newChannelTestHandler returns the handler with the channel "ch" subscribed by the writers "1", "2" and the reader "3",
+// the messages are stored in the memory store.
+func newChannelTestHandler(t *testing.T) (*MessageHandlerImpl, *mockBotGateway, *store.MemoryMessageStore) {
+	g := &mockBotGateway{
+		online: map[gate.ID]bool{
+			gate.NewID2("1"): true,
+			gate.NewID2("2"): true,
+			gate.NewID2("3"): true,
+		},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	ms := store.NewMemoryMessageStore(0)
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore:  ms,
+		HistoryStore:  ms,
+		ReactionStore: store.NewMemoryReactionStore(),
+	})
+	assert.NoError(t, err)
+
+	sub := subscription_impl.NewSubscription(ms, ms)
+	sub.SetGateInterface(g)
+	handler.SetSubscription(sub)
+	handler.SetGate(g)
+
+	w := subscription_impl.NewSubscribeWrap(sub)
+	assert.NoError(t, w.CreateChannel("ch", &subscription.ChanInfo{}))
+	for uid, perm := range map[string]subscription_impl.Permission{
+		"1": subscription_impl.PermRead | subscription_impl.PermWrite,
+		"2": subscription_impl.PermRead | subscription_impl.PermWrite,
+		"3": subscription_impl.PermRead,
+	} {
+		assert.NoError(t, w.Subscribe("ch", subscription.SubscriberID(uid), &subscription_impl.SubscriberOptions{Perm: perm}))
+	}
+	return handler, g, ms
+}
+
+// sendChannelMessage sends the message to channel "ch" from uid, returns the mid in the ack.
+func sendChannelMessage(t *testing.T, h *MessageHandlerImpl, g *mockBotGateway, uid string, content string) int64 {
+	id := gate.NewID2(uid)
+	acked := len(g.get(id, messages.ActionAckMessage))
+	msg := messages.NewMessage(1, messages.ActionGroupMessage, &messages.ChatMessage{
+		CliMid:  content,
+		Type:    messages.MessageTypeText,
+		Content: content,
+	})
+	msg.To = "ch"
+	assert.NoError(t, h.Handle(&gate.Info{ID: id}, msg))
+
+	assert.Eventually(t, func() bool {
+		return len(g.get(id, messages.ActionAckMessage)) == acked+1
+	}, time.Second, time.Millisecond*10)
+	acks := g.get(id, messages.ActionAckMessage)
+	ack := messages.AckMessage{}
+	assert.NoError(t, acks[len(acks)-1].Data.Deserialize(&ack))
+	return ack.Mid
+}
+
+func TestMessageHandlerImpl_RecallChannelMessage(t *testing.T) {
+	h, g, ms := newChannelTestHandler(t)
+
+	mid := sendChannelMessage(t, h, g, "1", "hello")
+	assert.NotZero(t, mid)
+	assert.NotEqual(t, mid, sendChannelMessage(t, h, g, "2", "world"))
+
+	stored, err := ms.GetMessage(messages.ChatTypeChannel, mid)
+	assert.NoError(t, err)
+	assert.Equal(t, "ch", stored.To)
+	assert.Equal(t, "1", stored.From)
+
+	recall := func(uid string) {
+		msg := messages.NewMessage(2, messages.ActionMessageRecall, &messages.Recall{ChatType: messages.ChatTypeChannel, Mid: mid})
+		assert.NoError(t, h.Handle(&gate.Info{ID: gate.NewID2(uid)}, msg))
+	}
+
+	// only the sender can recall
+	recall("2")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("2"), messages.ActionNotifyError)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	recall("1")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("3"), messages.ActionMessageRecall)) == 1
+	}, time.Second, time.Millisecond*10)
+	notify := messages.Recall{}
+	assert.NoError(t, g.get(gate.NewID2("3"), messages.ActionMessageRecall)[0].Data.Deserialize(&notify))
+	assert.Equal(t, mid, notify.Mid)
+	assert.Equal(t, "1", notify.RecallBy)
+
+	// the recalled message can not be recalled again, the notification is not sent again
+	recall("1")
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionNotifyError)) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Len(t, g.get(gate.NewID2("3"), messages.ActionMessageRecall), 1)
+
+	list, err := ms.ListMessages(&store.HistoryQuery{ChatType: messages.ChatTypeChannel, To: "ch", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "world", list[0].Content)
+}
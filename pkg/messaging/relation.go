@@ -0,0 +1,241 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"sync"
+)
+
+// RelationKind the kind of the relationship allows users to send direct messages to each other.
+type RelationKind int32
+
+const (
+	// RelationContact the contacts, the relationship is mutual.
+	RelationContact RelationKind = 1
+	// RelationChannel the channels joined by the user, the users share a channel are related.
+	RelationChannel RelationKind = 2
+	// RelationAllow the users allowed to send direct messages to the user.
+	RelationAllow RelationKind = 3
+)
+
+// ForbiddenCodeRelationship the reason code of the direct message rejected by the DMPolicyHandler.
+const ForbiddenCodeRelationship = "relationship"
+
+// RelationProvider provides the relationships of users for the DMPolicyHandler.
+type RelationProvider interface {
+
+	// IsContact returns true if uid and other are contacts.
+	IsContact(uid string, other string) (bool, error)
+
+	// ShareChannel returns true if uid and other are members of the same channel.
+	ShareChannel(uid string, other string) (bool, error)
+
+	// IsAllowed returns true if uid allows other to send direct messages.
+	IsAllowed(uid string, other string) (bool, error)
+}
+
+var _ RelationProvider = (*RelationCache)(nil)
+
+// RelationCache is an in-memory RelationProvider, the relationships are pushed by the business service through rpc.
+type RelationCache struct {
+	mu        sync.RWMutex
+	relations map[RelationKind]map[string]map[string]struct{}
+}
+
+func NewRelationCache() *RelationCache {
+	return &RelationCache{
+		relations: map[RelationKind]map[string]map[string]struct{}{
+			RelationContact: {},
+			RelationChannel: {},
+			RelationAllow:   {},
+		},
+	}
+}
+
+func (r *RelationCache) add(kind RelationKind, uid string, target string) {
+	m := r.relations[kind]
+	set, ok := m[uid]
+	if !ok {
+		set = map[string]struct{}{}
+		m[uid] = set
+	}
+	set[target] = struct{}{}
+}
+
+func (r *RelationCache) remove(kind RelationKind, uid string, target string) {
+	m := r.relations[kind]
+	set := m[uid]
+	delete(set, target)
+	if len(set) == 0 {
+		delete(m, uid)
+	}
+}
+
+func (r *RelationCache) addAll(kind RelationKind, uid string, targets []string) {
+	for _, t := range targets {
+		if t == "" || t == uid {
+			continue
+		}
+		r.add(kind, uid, t)
+		if kind == RelationContact {
+			r.add(kind, t, uid)
+		}
+	}
+}
+
+func (r *RelationCache) removeAll(kind RelationKind, uid string, targets []string) {
+	for _, t := range targets {
+		r.remove(kind, uid, t)
+		if kind == RelationContact {
+			r.remove(kind, t, uid)
+		}
+	}
+}
+
+// AddRelations adds the targets to the relations of uid, the contacts are added to both sides.
+func (r *RelationCache) AddRelations(kind RelationKind, uid string, targets ...string) {
+	if _, ok := r.relations[kind]; !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addAll(kind, uid, targets)
+}
+
+// RemoveRelations removes the targets from the relations of uid, the contacts are removed from both sides.
+func (r *RelationCache) RemoveRelations(kind RelationKind, uid string, targets ...string) {
+	if _, ok := r.relations[kind]; !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeAll(kind, uid, targets)
+}
+
+// SetRelations replaces the relations of uid with targets, the readers never see the relations partly replaced.
+func (r *RelationCache) SetRelations(kind RelationKind, uid string, targets ...string) {
+	if _, ok := r.relations[kind]; !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var old []string
+	for t := range r.relations[kind][uid] {
+		old = append(old, t)
+	}
+	r.removeAll(kind, uid, old)
+	r.addAll(kind, uid, targets)
+}
+
+// GetRelations returns the relations of uid.
+func (r *RelationCache) GetRelations(kind RelationKind, uid string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []string
+	for t := range r.relations[kind][uid] {
+		result = append(result, t)
+	}
+	return result
+}
+
+func (r *RelationCache) has(kind RelationKind, uid string, target string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.relations[kind][uid][target]
+	return ok
+}
+
+func (r *RelationCache) IsContact(uid string, other string) (bool, error) {
+	return r.has(RelationContact, uid, other), nil
+}
+
+func (r *RelationCache) ShareChannel(uid string, other string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m := r.relations[RelationChannel]
+	a, b := m[uid], m[other]
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for ch := range a {
+		if _, ok := b[ch]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *RelationCache) IsAllowed(uid string, other string) (bool, error) {
+	return r.has(RelationAllow, uid, other), nil
+}
+
+var _ MessageHandler = (*DMPolicyHandler)(nil)
+
+// DMPolicyHandler 单聊关系限制, 只允许联系人, 加入了同一频道的用户, 或接收者白名单中的用户发送 message.chat 和 message.cli,
+// 被拒绝的消息通过 messages.ActionNotifyDMRejected 通知发送者, 不再传递给后续的 MessageHandler. 机器人发送和发给机器人的消息
+// 不受限制, 查询关系出错时拒绝.
+type DMPolicyHandler struct {
+	provider RelationProvider
+
+	// isBot returns true if the uid is an in-process bot, set by the MessageHandlerImpl.
+	isBot func(uid string) bool
+}
+
+func NewDMPolicyHandler(provider RelationProvider) *DMPolicyHandler {
+	return &DMPolicyHandler{
+		provider: provider,
+	}
+}
+
+// Allow returns true if from can send direct messages to to.
+func (p *DMPolicyHandler) Allow(from string, to string) (bool, error) {
+	if from == to {
+		return true, nil
+	}
+	ok, err := p.provider.IsContact(from, to)
+	if err != nil || ok {
+		return ok, err
+	}
+	ok, err = p.provider.IsAllowed(to, from)
+	if err != nil || ok {
+		return ok, err
+	}
+	return p.provider.ShareChannel(from, to)
+}
+
+func (p *DMPolicyHandler) Handle(h *MessageInterfaceImpl, cliInfo *gate.Info, message *messages.GlideMessage) bool {
+	switch message.GetAction() {
+	case messages.ActionChatMessage, messages.ActionChatMessageResend, messages.ActionClientCustom:
+	default:
+		return false
+	}
+	if cliInfo.ID.IsRobot() || (p.isBot != nil && p.isBot(message.To)) {
+		return false
+	}
+	ok, err := p.Allow(message.From, message.To)
+	if err != nil {
+		logger.E("check direct message relationship error %v", err)
+	}
+	if ok {
+		return false
+	}
+
+	var cliMid string
+	if message.GetAction() != messages.ActionClientCustom {
+		cm := messages.ChatMessage{}
+		_ = message.Data.Deserialize(&cm)
+		cliMid = cm.CliMid
+	}
+	notify := messages.NewMessage(message.GetSeq(), messages.ActionNotifyDMRejected, &messages.Forbidden{
+		Code:   ForbiddenCodeRelationship,
+		Action: string(message.GetAction()),
+		CliMid: cliMid,
+		To:     message.To,
+	})
+	err = h.GetClientInterface().EnqueueMessage(cliInfo.ID, notify)
+	if err != nil {
+		logger.E("notify dm rejected error %v", err)
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDMPolicyHandler_Allow(t *testing.T) {
+	cache := NewRelationCache()
+	policy := NewDMPolicyHandler(cache)
+
+	allow := func(from string, to string) bool {
+		ok, err := policy.Allow(from, to)
+		assert.NoError(t, err)
+		return ok
+	}
+	assert.False(t, allow("1", "2"))
+
+	cache.AddRelations(RelationContact, "1", "2")
+	assert.True(t, allow("1", "2"))
+	assert.True(t, allow("2", "1"))
+	cache.RemoveRelations(RelationContact, "2", "1")
+	assert.False(t, allow("1", "2"))
+
+	cache.AddRelations(RelationAllow, "2", "1")
+	assert.True(t, allow("1", "2"))
+	assert.False(t, allow("2", "1"))
+	cache.SetRelations(RelationAllow, "2")
+	assert.False(t, allow("1", "2"))
+
+	cache.SetRelations(RelationChannel, "1", "c1", "c2")
+	cache.SetRelations(RelationChannel, "2", "c2")
+	assert.True(t, allow("1", "2"))
+	cache.SetRelations(RelationChannel, "2", "c3")
+	assert.False(t, allow("2", "1"))
+}
+
+func TestDMPolicyHandler_Handle(t *testing.T) {
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{gate.NewID2("1"): true, gate.NewID2("2"): true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	cache := NewRelationCache()
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore: &store.IdleMessageStore{},
+		DMPolicy:     NewDMPolicyHandler(cache),
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	send := func() {
+		msg := messages.NewMessage(1, messages.ActionChatMessage, &messages.ChatMessage{CliMid: "c", Content: "hi"})
+		msg.To = "2"
+		assert.NoError(t, handler.Handle(&gate.Info{ID: gate.NewID2("1")}, msg))
+	}
+
+	send()
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("1"), messages.ActionNotifyDMRejected)) == 1
+	}, time.Second, time.Millisecond*10)
+	rejected := messages.Forbidden{}
+	_ = g.get(gate.NewID2("1"), messages.ActionNotifyDMRejected)[0].Data.Deserialize(&rejected)
+	assert.Equal(t, ForbiddenCodeRelationship, rejected.Code)
+	assert.Equal(t, "c", rejected.CliMid)
+	assert.Equal(t, "2", rejected.To)
+	assert.Empty(t, g.get(gate.NewID2("2"), messages.ActionChatMessage))
+
+	cache.AddRelations(RelationContact, "1", "2")
+	send()
+	assert.Eventually(t, func() bool {
+		return len(g.get(gate.NewID2("2"), messages.ActionChatMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+}
@@ -0,0 +1,380 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/glide-im/glide/pkg/timingwheel"
+	"sync"
+	"time"
+)
+
+const (
+	defaultScheduleScanInterval = time.Minute
+
+	// maxScheduleAhead the max duration between the schedule time and the deliver time
+	maxScheduleAhead = time.Hour * 24 * 30
+	// maxScheduledPerUser the max count of undelivered scheduled messages of a user
+	maxScheduledPerUser = 100
+	// scheduleScanLimit the max count of due messages loaded in one scan
+	scheduleScanLimit = 1000
+)
+
+var (
+	ErrScheduledNotFound   = errors.New("scheduled message not found")
+	ErrScheduleInvalid     = errors.New("invalid scheduled message")
+	ErrScheduleTooMany     = errors.New("too many scheduled messages")
+	ErrScheduleUnavailable = errors.New("scheduled message is not supported")
+	ErrScheduleForbidden   = errors.New("permission denied: scheduled message")
+	ErrScheduleModeration  = errors.New("scheduled message is blocked by moderation")
+)
+
+// MessageScheduler 定时消息, 消息保存在 store.ScheduledMessageStore 中, 即将到期的消息由时间轮触发投递, 定期扫描 store 加载
+// 即将到期的消息, 进程重启后也能投递. 多个节点共享 store 时, 同一条消息只会由取得该消息的节点投递一次.
+type MessageScheduler struct {
+	store   store.ScheduledMessageStore
+	deliver func(m *messages.ScheduledMessage)
+	// validate checks the message can be sent by the sender when scheduled and edited, nil express no check
+	validate func(m *messages.ScheduledMessage) error
+
+	tw           *timingwheel.TimingWheel
+	scanInterval time.Duration
+
+	mu    sync.Mutex
+	tasks map[int64]*timingwheel.Task
+}
+
+func newMessageScheduler(s store.ScheduledMessageStore, scanInterval time.Duration, deliver func(m *messages.ScheduledMessage)) *MessageScheduler {
+	ret := &MessageScheduler{
+		store:        s,
+		deliver:      deliver,
+		tw:           timingwheel.NewTimingWheel(time.Second, 3, 20),
+		scanInterval: scanInterval,
+		tasks:        map[int64]*timingwheel.Task{},
+	}
+	go ret.run()
+	return ret
+}
+
+// horizon the messages deliver in the horizon are armed in the timing wheel, the others are loaded by the next scan.
+func (s *MessageScheduler) horizon() time.Duration {
+	return s.scanInterval * 2
+}
+
+func (s *MessageScheduler) run() {
+	s.scan()
+	ticker := time.NewTicker(s.scanInterval)
+	for range ticker.C {
+		s.scan()
+	}
+}
+
+// scan loads the messages deliver in the horizon from store, the overdue messages since restart are delivered at once.
+func (s *MessageScheduler) scan() {
+	due, err := s.store.ListDue(time.Now().Add(s.horizon()).Unix(), scheduleScanLimit)
+	if err != nil {
+		logger.E("list due scheduled message error %v", err)
+		return
+	}
+	for _, m := range due {
+		s.arm(m, false)
+	}
+}
+
+// arm adds the message to the timing wheel, the armed message is replaced when replace is true.
+func (s *MessageScheduler) arm(m *messages.ScheduledMessage, replace bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.tasks[m.ID]; ok {
+		if !replace {
+			return
+		}
+		t.Cancel()
+		delete(s.tasks, m.ID)
+	}
+	delay := time.Until(time.Unix(m.DeliverAt, 0))
+	if delay > s.horizon() {
+		return
+	}
+	id := m.ID
+	if delay <= 0 {
+		go s.fire(id)
+		return
+	}
+	t := s.tw.After(delay)
+	t.Callback(func() {
+		s.fire(id)
+	})
+	s.tasks[id] = t
+}
+
+func (s *MessageScheduler) disarm(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[id]; ok {
+		t.Cancel()
+		delete(s.tasks, id)
+	}
+}
+
+func (s *MessageScheduler) fire(id int64) {
+	s.mu.Lock()
+	delete(s.tasks, id)
+	s.mu.Unlock()
+
+	m, err := s.store.GetScheduled(id)
+	if err != nil {
+		logger.E("get scheduled message error %v", err)
+		return
+	}
+	if m == nil {
+		// cancelled or delivered by other node
+		return
+	}
+	if m.DeliverAt > time.Now().Unix() {
+		// edited to a later time by other node
+		s.arm(m, true)
+		return
+	}
+	m, err = s.store.TakeScheduled(id)
+	if err != nil {
+		logger.E("take scheduled message error %v", err)
+		return
+	}
+	if m != nil {
+		s.deliver(m)
+	}
+}
+
+func validateDeliverAt(deliverAt int64) bool {
+	now := time.Now()
+	return deliverAt > now.Unix() && deliverAt <= now.Add(maxScheduleAhead).Unix()
+}
+
+// Schedule saves the message and delivers it at m.DeliverAt, the m.Message.From is the sender.
+func (s *MessageScheduler) Schedule(m *messages.ScheduledMessage) (*messages.ScheduledMessage, error) {
+	if m.Message == nil || m.Message.From == "" || m.Message.To == "" || !validateDeliverAt(m.DeliverAt) {
+		return nil, ErrScheduleInvalid
+	}
+	if m.ChatType != messages.ChatTypeSingle && m.ChatType != messages.ChatTypeChannel {
+		return nil, ErrScheduleInvalid
+	}
+	list, err := s.store.ListScheduled(m.Message.From)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) >= maxScheduledPerUser {
+		return nil, ErrScheduleTooMany
+	}
+	if s.validate != nil {
+		err = s.validate(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.ID = 0
+	m.CreateAt = time.Now().Unix()
+	m.Message.Mid = 0
+	id, err := s.store.SaveScheduled(m)
+	if err != nil {
+		return nil, err
+	}
+	m.ID = id
+	s.arm(m, true)
+	return m, nil
+}
+
+// Edit changes the content or the deliver time of the undelivered message of uid.
+func (s *MessageScheduler) Edit(uid string, edit *messages.ScheduledEdit) (*messages.ScheduledMessage, error) {
+	m, err := s.store.GetScheduled(edit.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || m.Message == nil || m.Message.From != uid {
+		return nil, ErrScheduledNotFound
+	}
+	if edit.DeliverAt != 0 {
+		if !validateDeliverAt(edit.DeliverAt) {
+			return nil, ErrScheduleInvalid
+		}
+		m.DeliverAt = edit.DeliverAt
+	}
+	if edit.Content != "" {
+		m.Message.Content = edit.Content
+		if s.validate != nil {
+			err = s.validate(m)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	ok, err := s.store.UpdateScheduled(m)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrScheduledNotFound
+	}
+	s.arm(m, true)
+	return m, nil
+}
+
+// Cancel removes the undelivered message of uid.
+func (s *MessageScheduler) Cancel(uid string, id int64) error {
+	m, err := s.store.GetScheduled(id)
+	if err != nil {
+		return err
+	}
+	if m == nil || m.Message == nil || m.Message.From != uid {
+		return ErrScheduledNotFound
+	}
+	m, err = s.store.TakeScheduled(id)
+	if err != nil {
+		return err
+	}
+	s.disarm(id)
+	if m == nil {
+		return ErrScheduledNotFound
+	}
+	return nil
+}
+
+// List returns the undelivered messages of uid.
+func (s *MessageScheduler) List(uid string) ([]*messages.ScheduledMessage, error) {
+	return s.store.ListScheduled(uid)
+}
+
+// Scheduler returns the scheduler of the scheduled messages, nil if the ScheduledStore is not configured.
+func (d *MessageHandlerImpl) Scheduler() *MessageScheduler {
+	return d.scheduler
+}
+
+// validateScheduled 检查发送者是否可以发送定时消息, 频道需要写权限, 单聊需要满足单聊关系限制, 内容需要通过类型校验和审核.
+// 不检查黑名单, 发给拉黑了发送者的用户的消息和其他消息一样投递后仅发送者可见, 发送者无法感知被拉黑
+func (d *MessageHandlerImpl) validateScheduled(m *messages.ScheduledMessage) error {
+	msg := m.Message
+	var action messages.Action = messages.ActionChatMessage
+	switch m.ChatType {
+	case messages.ChatTypeChannel:
+		action = messages.ActionGroupMessage
+		if !d.canWriteChannel(msg.To, msg.From) {
+			return ErrScheduleForbidden
+		}
+	case messages.ChatTypeSingle:
+		if d.dmPolicy != nil && d.bots.get(msg.To) == nil {
+			ok, err := d.dmPolicy.Allow(msg.From, msg.To)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrScheduleForbidden
+			}
+		}
+	}
+	if d.content != nil {
+		err := d.content.ValidateClient(msg.Type, msg.Content)
+		if err != nil {
+			return err
+		}
+	}
+	if d.moderation != nil {
+		verdict, _ := d.moderation.Moderate(msg.From, action, msg.Content)
+		if verdict.Policy == ModerationBlock {
+			return ErrScheduleModeration
+		}
+	}
+	return nil
+}
+
+// deliverScheduled 投递到期的定时消息, 以发送者的身份经过完整的消息处理流程, 使用内部设备的 ID, 回执不会发送给发送者的客户端,
+// 发送者的所有设备和其他消息一样收到消息的同步. 投递前再次检查, 发送者在定时后失去权限时以错误通知发送者的所有设备
+func (d *MessageHandlerImpl) deliverScheduled(m *messages.ScheduledMessage) {
+	err := d.validateScheduled(m)
+	if err != nil {
+		logger.D("scheduled message %d rejected %v", m.ID, err)
+		notify := messages.NewMessage(0, messages.ActionNotifyError, fmt.Sprintf("scheduled message %d: %s", m.ID, err.Error()))
+		d.dispatchAllDevice(m.Message.From, notify)
+		return
+	}
+	var action messages.Action = messages.ActionChatMessage
+	if m.ChatType == messages.ChatTypeChannel {
+		action = messages.ActionGroupMessage
+	}
+	msg := messages.NewMessage(0, action, m.Message)
+	msg.To = m.Message.To
+	err = d.def.Handle(&gate.Info{ID: gate.NewID("", m.Message.From, gate.DeviceInternal)}, msg)
+	if err != nil {
+		logger.E("deliver scheduled message %d error %v", m.ID, err)
+	}
+}
+
+// scheduleReply returns the reply of the error of the scheduler, the validation errors are replied to client.
+func scheduleReply(seq int64, data interface{}, err error) (*messages.GlideMessage, error) {
+	switch err {
+	case nil:
+		return messages.NewMessage(seq, messages.ActionApiSuccess, data), nil
+	case ErrScheduledNotFound, ErrScheduleInvalid, ErrScheduleTooMany, ErrScheduleUnavailable, ErrScheduleForbidden,
+		ErrScheduleModeration, messages.ErrContentUnknownType, messages.ErrContentTooLarge, messages.ErrContentInvalid,
+		messages.ErrContentServerOnly:
+		return messages.NewMessage(seq, messages.ActionApiFailed, err.Error()), nil
+	}
+	logger.E("scheduled message error %v", err)
+	return nil, err
+}
+
+// handleApiSchedule 客户端发送定时消息
+func (d *MessageHandlerImpl) handleApiSchedule(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.scheduler == nil || c.ID.IsTemp() {
+		return scheduleReply(m.GetSeq(), nil, ErrScheduleUnavailable)
+	}
+	req := new(messages.ScheduledMessage)
+	if !d.unmarshalData(c, m, req) || req.Message == nil {
+		return scheduleReply(m.GetSeq(), nil, ErrScheduleInvalid)
+	}
+	req.Message.From = c.ID.UID()
+	if req.Message.To == "" {
+		req.Message.To = m.To
+	}
+	result, err := d.scheduler.Schedule(req)
+	return scheduleReply(m.GetSeq(), result, err)
+}
+
+// handleApiScheduleEdit 修改未投递的定时消息
+func (d *MessageHandlerImpl) handleApiScheduleEdit(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.scheduler == nil || c.ID.IsTemp() {
+		return scheduleReply(m.GetSeq(), nil, ErrScheduleUnavailable)
+	}
+	req := new(messages.ScheduledEdit)
+	if !d.unmarshalData(c, m, req) {
+		return scheduleReply(m.GetSeq(), nil, ErrScheduleInvalid)
+	}
+	result, err := d.scheduler.Edit(c.ID.UID(), req)
+	return scheduleReply(m.GetSeq(), result, err)
+}
+
+// handleApiScheduleCancel 取消未投递的定时消息
+func (d *MessageHandlerImpl) handleApiScheduleCancel(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.scheduler == nil || c.ID.IsTemp() {
+		return scheduleReply(m.GetSeq(), nil, ErrScheduleUnavailable)
+	}
+	req := new(messages.ScheduledEdit)
+	if !d.unmarshalData(c, m, req) {
+		return scheduleReply(m.GetSeq(), nil, ErrScheduleInvalid)
+	}
+	err := d.scheduler.Cancel(c.ID.UID(), req.ID)
+	return scheduleReply(m.GetSeq(), req, err)
+}
+
+// handleApiScheduleList 查询未投递的定时消息
+func (d *MessageHandlerImpl) handleApiScheduleList(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if d.scheduler == nil || c.ID.IsTemp() {
+		return scheduleReply(m.GetSeq(), nil, ErrScheduleUnavailable)
+	}
+	list, err := d.scheduler.List(c.ID.UID())
+	return scheduleReply(m.GetSeq(), &messages.ScheduledMessages{Messages: list}, err)
+}
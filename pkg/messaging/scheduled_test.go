@@ -0,0 +1,117 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageScheduler_Schedule(t *testing.T) {
+	delivered := make(chan *messages.ScheduledMessage, 2)
+	s := newMessageScheduler(store.NewMemoryScheduledMessageStore(), time.Second*5, func(m *messages.ScheduledMessage) {
+		delivered <- m
+	})
+
+	deliverAt := time.Now().Add(time.Second * 2).Unix()
+	m1, err := s.Schedule(&messages.ScheduledMessage{
+		ChatType:  messages.ChatTypeSingle,
+		Message:   &messages.ChatMessage{From: "1", To: "2", Content: "hello"},
+		DeliverAt: deliverAt,
+	})
+	assert.NoError(t, err)
+	m2, err := s.Schedule(&messages.ScheduledMessage{
+		ChatType:  messages.ChatTypeSingle,
+		Message:   &messages.ChatMessage{From: "1", To: "3", Content: "hello"},
+		DeliverAt: deliverAt,
+	})
+	assert.NoError(t, err)
+
+	_, err = s.Edit("1", &messages.ScheduledEdit{ID: m1.ID, Content: "edited"})
+	assert.NoError(t, err)
+	assert.Equal(t, ErrScheduledNotFound, s.Cancel("2", m2.ID))
+	assert.NoError(t, s.Cancel("1", m2.ID))
+
+	select {
+	case m := <-delivered:
+		assert.Equal(t, m1.ID, m.ID)
+		assert.Equal(t, "edited", m.Message.Content)
+	case <-time.After(time.Second * 5):
+		t.Fatal("scheduled message is not delivered")
+	}
+	list, _ := s.List("1")
+	assert.Empty(t, list)
+
+	_, err = s.Schedule(&messages.ScheduledMessage{
+		ChatType:  messages.ChatTypeSingle,
+		Message:   &messages.ChatMessage{From: "1", To: "2"},
+		DeliverAt: time.Now().Unix() - 1,
+	})
+	assert.Equal(t, ErrScheduleInvalid, err)
+}
+
+func TestMessageHandlerImpl_DeliverScheduled(t *testing.T) {
+	sender, receiver := gate.NewID2("1"), gate.NewID2("2")
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{sender: true, receiver: true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore: store.NewMemoryMessageStore(0),
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	handler.deliverScheduled(&messages.ScheduledMessage{
+		ID:       1,
+		ChatType: messages.ChatTypeSingle,
+		Message:  &messages.ChatMessage{From: "1", To: "2", Type: messages.MessageTypeText, Content: "hello"},
+	})
+	assert.Eventually(t, func() bool {
+		return len(g.get(receiver, messages.ActionChatMessage)) == 1
+	}, time.Second, time.Millisecond*10)
+
+	// the sender is not acked as if the client sent the message
+	time.Sleep(time.Millisecond * 50)
+	assert.Empty(t, g.get(sender, messages.ActionAckMessage))
+	chat := messages.ChatMessage{}
+	assert.NoError(t, g.get(receiver, messages.ActionChatMessage)[0].Data.Deserialize(&chat))
+	assert.Equal(t, "1", chat.From)
+	assert.NotZero(t, chat.Mid)
+}
+
+func TestMessageHandlerImpl_ScheduleChannel(t *testing.T) {
+	h, g, _ := newChannelTestHandler(t)
+	h.scheduler = newMessageScheduler(store.NewMemoryScheduledMessageStore(), time.Minute, h.deliverScheduled)
+	h.scheduler.validate = h.validateScheduled
+
+	schedule := func(uid string) *messages.GlideMessage {
+		req := messages.NewMessage(1, messages.ActionApiSchedule, &messages.ScheduledMessage{
+			ChatType:  messages.ChatTypeChannel,
+			Message:   &messages.ChatMessage{To: "ch", Type: messages.MessageTypeText, Content: "hello"},
+			DeliverAt: time.Now().Add(time.Hour).Unix(),
+		})
+		reply, err := h.handleApiSchedule(&gate.Info{ID: gate.NewID2(uid)}, req)
+		assert.NoError(t, err)
+		return reply
+	}
+
+	// the reader can not schedule the message to the channel
+	reply := schedule("3")
+	assert.Equal(t, messages.Action(messages.ActionApiFailed), reply.GetAction())
+	list, _ := h.scheduler.List("3")
+	assert.Empty(t, list)
+
+	assert.Equal(t, messages.Action(messages.ActionApiSuccess), schedule("1").GetAction())
+
+	// the sender lost the write permission before delivery is notified
+	h.deliverScheduled(&messages.ScheduledMessage{
+		ID:       1,
+		ChatType: messages.ChatTypeChannel,
+		Message:  &messages.ChatMessage{From: "3", To: "ch", Type: messages.MessageTypeText, Content: "hello"},
+	})
+	assert.Len(t, g.get(gate.NewID2("3"), messages.ActionNotifyError), 1)
+	assert.Empty(t, g.get(gate.NewID2("1"), messages.ActionGroupMessage))
+}
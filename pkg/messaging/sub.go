@@ -6,6 +6,30 @@ import (
 	"github.com/glide-im/glide/pkg/messages"
 	"github.com/glide-im/glide/pkg/subscription"
 	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"sort"
+	"strconv"
+)
+
+const (
+	defaultGroupMembersLimit = 50
+	maxGroupMembersLimit     = 200
+)
+
+const (
+	errGroupMembersNotSupported = "group members is not supported"
+	errGroupMembersInvalidQuery = "invalid group members query"
+	errGroupMembersPermission   = "permission denied: group members"
+	errGroupAckNotSupported     = "group ack is not supported"
+	errGroupAckInvalidQuery     = "invalid group ack query"
+	errGroupAckPermission       = "permission denied: group ack"
+)
+
+const (
+	memberRoleSystem = "system"
+	memberRoleBot    = "bot"
+	memberRoleAdmin  = "admin"
+	memberRoleMember = "member"
+	memberRoleReader = "reader"
 )
 
 // handleGroupMsg 分发群消息
@@ -18,6 +42,13 @@ func (d *MessageHandlerImpl) handleGroupMsg(c *gate.Info, msg *messages.GlideMes
 	if e != nil {
 		return e
 	}
+	if !d.validateContent(c, msg, &cm) {
+		return nil
+	}
+	cm.From = msg.From
+	cm.To = msg.To
+	cm.Status = messages.MessageStatusNormal
+	msg.Data = messages.NewData(&cm)
 
 	m := subscription_impl.PublishMessage{
 		From:    subscription.SubscriberID(msg.From),
@@ -31,32 +62,161 @@ func (d *MessageHandlerImpl) handleGroupMsg(c *gate.Info, msg *messages.GlideMes
 		notify := messages.NewMessage(msg.GetSeq(), messages.ActionNotifyError, err.Error())
 		d.enqueueMessage(c.ID, notify)
 	} else {
-		_ = d.ackChatMessage(c, &cm)
+		// the published message carries the seq and mid assigned by the channel
+		published := messages.ChatMessage{}
+		if msg.Data.Deserialize(&published) != nil {
+			_ = d.ackChatMessage(c, &cm)
+			return nil
+		}
+		_ = d.ackChatMessage(c, &published)
+		d.updateChannelConversation(msg.To, &published)
+		d.dispatchChannelBots(msg.To, msg.From, &published)
 	}
 
 	return nil
 }
 
-func (d *MessageHandlerImpl) handleApiGroupMembers(c *gate.Info, msg *messages.GlideMessage) error {
-	//id := subscription.ChanID(msg.To)
-	//
-	//cm := messages.ChatMessage{}
-	//e := msg.Data.Deserialize(&cm)
-	//if e != nil {
-	//	return e
-	//}
+// handleApiGroupMembers 分页查询频道成员及其角色和在线状态, 仅有读权限的频道成员可查询
+func (d *MessageHandlerImpl) handleApiGroupMembers(c *gate.Info, msg *messages.GlideMessage) (*messages.GlideMessage, error) {
+	q, ok := d.def.GetGroupInterface().(subscription_impl.SubscriberQuery)
+	if !ok {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, errGroupMembersNotSupported), nil
+	}
+	req := new(messages.GroupMembersQuery)
+	if !d.unmarshalData(c, msg, req) || req.Offset < 0 {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, errGroupMembersInvalidQuery), nil
+	}
+	if !d.canReadChannel(msg.To, c.ID.UID()) {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, errGroupMembersPermission), nil
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultGroupMembersLimit
+	}
+	if req.Limit > maxGroupMembersLimit {
+		req.Limit = maxGroupMembersLimit
+	}
 
-	return nil
+	ch := subscription.ChanID(msg.To)
+	ids, err := q.GetSubscribers(ch)
+	if err != nil {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, err.Error()), nil
+	}
+	sort.Strings(ids)
+
+	result := messages.GroupMembers{
+		Total: len(ids),
+	}
+	if req.Offset < len(ids) {
+		page := ids[req.Offset:]
+		if len(page) > req.Limit {
+			page = page[:req.Limit]
+			result.More = true
+		}
+		for _, id := range page {
+			info, err := q.GetSubscriber(ch, subscription.SubscriberID(id))
+			if err != nil {
+				// unsubscribed after listed
+				continue
+			}
+			result.Members = append(result.Members, &messages.GroupMember{
+				Uid:    id,
+				Role:   roleOf(info),
+				Perm:   int64(info.Perm),
+				Online: d.isUserOnline(id),
+			})
+		}
+	}
+	return messages.NewMessage(msg.GetSeq(), messages.ActionApiSuccess, &result), nil
 }
 
+// roleOf returns the role name of the subscriber in channel.
+func roleOf(info *subscription_impl.SubscriberInfo) string {
+	switch {
+	case info.IsSystem():
+		return memberRoleSystem
+	case info.IsBot():
+		return memberRoleBot
+	case info.IsAdmin():
+		return memberRoleAdmin
+	case info.CanWrite():
+		return memberRoleMember
+	default:
+		return memberRoleReader
+	}
+}
+
+// isUserOnline returns true if any device of the user is connected to the gateway.
+func (d *MessageHandlerImpl) isUserOnline(uid string) bool {
+	g, ok := d.def.GetClientInterface().(gate.DefaultGateway)
+	if !ok {
+		return false
+	}
+	for _, device := range allDevices {
+		if g.IsOnline(gate.NewID("", uid, device)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleAckGroupMsgRequest 频道成员确认收到消息, 推进成员在频道中的已确认 seq
 func (d *MessageHandlerImpl) handleAckGroupMsgRequest(c *gate.Info, msg *messages.GlideMessage) error {
 	ack := new(messages.AckGroupMessage)
 	if !d.unmarshalData(c, msg, ack) {
 		return nil
 	}
-	//err := msgdao.UpdateGroupMemberMsgState(ack.Gid, 0, ack.Mid, ack.Seq)
-	//if err != nil {
-	//
-	//}
+	if d.channelAck == nil || ack.Seq <= 0 {
+		return nil
+	}
+	ch := msg.To
+	if ack.Gid != 0 {
+		ch = strconv.FormatInt(ack.Gid, 10)
+	}
+	if !d.canReadChannel(ch, c.ID.UID()) {
+		return nil
+	}
+	_, err := d.channelAck.UpdateMemberAck(subscription.ChanID(ch), subscription.SubscriberID(c.ID.UID()), ack.Seq)
+	if err != nil {
+		logger.E("update channel member ack error %v", err)
+		return err
+	}
 	return nil
 }
+
+// handleApiGroupAckCoverage 频道管理员查询消息的送达和已读人数
+func (d *MessageHandlerImpl) handleApiGroupAckCoverage(c *gate.Info, msg *messages.GlideMessage) (*messages.GlideMessage, error) {
+	q, ok := d.def.GetGroupInterface().(subscription_impl.SubscriberQuery)
+	if !ok || d.channelAck == nil {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, errGroupAckNotSupported), nil
+	}
+	req := new(messages.GroupAckCoverage)
+	if !d.unmarshalData(c, msg, req) || req.Seq <= 0 {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, errGroupAckInvalidQuery), nil
+	}
+	if !d.isChannelAdmin(msg.To, c.ID.UID()) {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, errGroupAckPermission), nil
+	}
+
+	ch := subscription.ChanID(msg.To)
+	members, err := q.GetSubscribers(ch)
+	if err != nil {
+		return messages.NewMessage(msg.GetSeq(), messages.ActionApiFailed, err.Error()), nil
+	}
+	result := messages.GroupAckCoverage{
+		Seq:   req.Seq,
+		Total: int64(len(members)),
+	}
+	result.Delivered, err = d.channelAck.CountAcked(ch, req.Seq, members)
+	if err != nil {
+		logger.E("count channel acked error %v", err)
+		return nil, err
+	}
+	if d.readCursor != nil {
+		result.Read, err = d.readCursor.CountReaders(conversationID(messages.ChatTypeChannel, msg.To), req.Seq, members)
+		if err != nil {
+			logger.E("count channel readers error %v", err)
+			return nil, err
+		}
+	}
+	return messages.NewMessage(msg.GetSeq(), messages.ActionApiSuccess, &result), nil
+}
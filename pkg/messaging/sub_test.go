@@ -0,0 +1,57 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMessageHandlerImpl_ApiGroupMembers(t *testing.T) {
+	h, g, _ := newChannelTestHandler(t)
+	g.mu.Lock()
+	g.online[gate.NewID2("2")] = false
+	g.mu.Unlock()
+
+	query := func(uid string, offset int, limit int) *messages.GlideMessage {
+		req := messages.NewMessage(1, messages.ActionApiGroupMembers, &messages.GroupMembersQuery{Offset: offset, Limit: limit})
+		req.To = "ch"
+		reply, err := h.handleApiGroupMembers(&gate.Info{ID: gate.NewID2(uid)}, req)
+		assert.NoError(t, err)
+		return reply
+	}
+	members := func(reply *messages.GlideMessage) *messages.GroupMembers {
+		assert.Equal(t, messages.Action(messages.ActionApiSuccess), reply.GetAction())
+		result := messages.GroupMembers{}
+		assert.NoError(t, reply.Data.Deserialize(&result))
+		return &result
+	}
+
+	// sorted by uid, with role and online state
+	result := members(query("3", 0, 2))
+	assert.Equal(t, 3, result.Total)
+	assert.True(t, result.More)
+	assert.Len(t, result.Members, 2)
+	assert.Equal(t, "1", result.Members[0].Uid)
+	assert.True(t, result.Members[0].Online)
+	assert.Equal(t, memberRoleMember, result.Members[0].Role)
+	assert.Equal(t, "2", result.Members[1].Uid)
+	assert.False(t, result.Members[1].Online)
+
+	result = members(query("1", 2, 2))
+	assert.False(t, result.More)
+	assert.Len(t, result.Members, 1)
+	assert.Equal(t, "3", result.Members[0].Uid)
+	assert.Equal(t, memberRoleReader, result.Members[0].Role)
+
+	// the offset out of range returns the total only, the limit over the max is capped
+	result = members(query("1", 3, 0))
+	assert.Equal(t, 3, result.Total)
+	assert.Empty(t, result.Members)
+	result = members(query("1", 0, maxGroupMembersLimit+1))
+	assert.Len(t, result.Members, 3)
+	assert.False(t, result.More)
+
+	assert.Equal(t, messages.Action(messages.ActionApiFailed), query("1", -1, 0).GetAction())
+	assert.Equal(t, messages.Action(messages.ActionApiFailed), query("4", 0, 0).GetAction())
+}
@@ -0,0 +1,97 @@
+package messaging
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/glide-im/glide/pkg/subscription/subscription_impl"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTypingInterval = time.Second
+	defaultTypingTTL      = time.Second * 5
+)
+
+// typingLimiter limits the typing signal frequency of each sender in each conversation.
+type typingLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+	sweepAt  time.Time
+}
+
+func newTypingLimiter(interval time.Duration) *typingLimiter {
+	return &typingLimiter{
+		interval: interval,
+		last:     map[string]time.Time{},
+		sweepAt:  time.Now(),
+	}
+}
+
+// allow returns true if the sender does not send typing signal to the conversation in the interval.
+func (t *typingLimiter) allow(from string, conversation string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.sweepAt) > t.interval*10 {
+		for k, at := range t.last {
+			if now.Sub(at) > t.interval {
+				delete(t.last, k)
+			}
+		}
+		t.sweepAt = now
+	}
+
+	key := from + ":" + conversation
+	if at, ok := t.last[key]; ok && now.Sub(at) < t.interval {
+		return false
+	}
+	t.last[key] = now
+	return true
+}
+
+// handleTyping 正在输入, 不存储, 超过频率限制或被对方拉黑的直接丢弃, 单聊转发给对方的所有设备, 频道以通知发布到频道
+func (d *MessageHandlerImpl) handleTyping(c *gate.Info, m *messages.GlideMessage) error {
+	typing := new(messages.Typing)
+	if !d.unmarshalData(c, m, typing) {
+		return nil
+	}
+	if typing.To == "" {
+		typing.To = m.To
+	}
+	if typing.ChatType != messages.ChatTypeSingle && typing.ChatType != messages.ChatTypeChannel {
+		return errors.New(errUnknownChatType)
+	}
+	typing.From = c.ID.UID()
+	typing.TTL = int64(d.typingTTL / time.Second)
+
+	// the stop signal is always forwarded, the receiver need not wait for the ttl.
+	if typing.Typing && !d.typingLimiter.allow(typing.From, conversationID(typing.ChatType, typing.To)) {
+		return nil
+	}
+
+	notify := messages.NewMessage(0, messages.ActionNotifyTyping, typing)
+	switch typing.ChatType {
+	case messages.ChatTypeSingle:
+		if d.blocks.IsBlocked(typing.To, typing.From) {
+			return nil
+		}
+		d.dispatchAllDevice(typing.To, notify)
+	case messages.ChatTypeChannel:
+		pm := subscription_impl.PublishMessage{
+			From:    subscription.SubscriberID(typing.From),
+			Message: notify,
+			Type:    subscription_impl.TypeNotify,
+		}
+		err := d.def.GetGroupInterface().PublishMessage(subscription.ChanID(typing.To), &pm)
+		if err != nil {
+			logger.D("publish typing to channel %s error: %v", typing.To, err)
+		}
+	}
+	return nil
+}
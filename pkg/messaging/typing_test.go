@@ -0,0 +1,60 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageHandlerImpl_HandleTyping(t *testing.T) {
+	g := &mockBotGateway{
+		online:   map[gate.ID]bool{gate.NewID2("1"): true, gate.NewID2("2"): true, gate.NewID2("3"): true},
+		received: map[gate.ID][]*messages.GlideMessage{},
+	}
+	handler, err := NewHandlerWithOptions(g, &MessageHandlerOptions{
+		MessageStore:   store.NewMemoryMessageStore(0),
+		TypingInterval: time.Millisecond * 100,
+	})
+	assert.NoError(t, err)
+	handler.SetGate(g)
+
+	typing := func(from string, to string, typing bool) {
+		msg := messages.NewMessage(0, messages.ActionNotifyTyping, &messages.Typing{
+			ChatType: messages.ChatTypeSingle,
+			To:       to,
+			Typing:   typing,
+		})
+		assert.NoError(t, handler.handleTyping(&gate.Info{ID: gate.NewID2(from)}, msg))
+	}
+
+	// the signals in the interval are dropped, the stop signal is always forwarded
+	typing("1", "2", true)
+	typing("1", "2", true)
+	typing("1", "2", false)
+	received := g.get(gate.NewID2("2"), messages.ActionNotifyTyping)
+	assert.Len(t, received, 2)
+	notify := messages.Typing{}
+	assert.NoError(t, received[0].Data.Deserialize(&notify))
+	assert.Equal(t, "1", notify.From)
+	assert.True(t, notify.Typing)
+	assert.Equal(t, int64(defaultTypingTTL/time.Second), notify.TTL)
+
+	// the limit is per conversation
+	typing("1", "3", true)
+	assert.Len(t, g.get(gate.NewID2("3"), messages.ActionNotifyTyping), 1)
+
+	time.Sleep(time.Millisecond * 150)
+	typing("1", "2", true)
+	assert.Len(t, g.get(gate.NewID2("2"), messages.ActionNotifyTyping), 3)
+
+	// the typing to the user blocked the sender is dropped
+	_, err = handler.Blocks().Block("3", []string{"1"})
+	assert.NoError(t, err)
+	time.Sleep(time.Millisecond * 150)
+	typing("1", "3", true)
+	typing("1", "3", false)
+	assert.Len(t, g.get(gate.NewID2("3"), messages.ActionNotifyTyping), 1)
+}
@@ -4,126 +4,484 @@ import (
 	"github.com/glide-im/glide/pkg/gate"
 	"github.com/glide-im/glide/pkg/logger"
 	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
 	"sync"
 	"time"
 )
 
-type UserStateData struct {
-	Uid    string `json:"uid,omitempty"`
-	Online bool   `json:"online,omitempty" json:"online,omitempty"`
-}
+const (
+	// maxStatusTextLength the max rune count of the status text
+	maxStatusTextLength = 128
+	// maxPresenceQuery the max count of users in one presence query
+	maxPresenceQuery = 100
+	// maxPresenceAllowList the max count of users in the presence allow list
+	maxPresenceAllowList = 1000
+	// defaultMaxWatching the default max count of users a user can subscribe the presence
+	defaultMaxWatching = 500
+	// presenceExpireInterval the interval of finding the users whose devices expired without heartbeat
+	presenceExpireInterval = time.Second * 30
+)
+
+const (
+	errPresenceUnauthenticated = "unauthenticated"
+	errPresenceInvalidRequest  = "invalid presence request"
+	errPresenceTooManyWatching = "too many presence subscriptions"
+)
 
 type StateSubscribeData struct {
 	Uids []string `json:"uids,omitempty"`
+	// Replace true express replace the current subscriptions with Uids, otherwise add Uids to the subscriptions.
+	Replace bool `json:"replace,omitempty"`
 }
 
+// UserState 用户在线状态, 状态和订阅关系保存在 store.PresenceStore 中, 使用共享的 store 时多个网关节点间的状态一致,
+// 状态变化时通知订阅者, 订阅在订阅者所有设备都下线后失效. store 实现了 store.PresencePublisher 时, 状态变化发布到所有节点,
+// 由每个节点通知连接在本节点的订阅者, 否则只通知本节点的订阅者. 设备由心跳刷新, 超过 store.PresenceDeviceTTL 未刷新视为离线,
+// 定期清理过期的设备, 用户所有设备过期时和下线一样通知订阅者.
 type UserState struct {
-	subscribers map[string]map[string]byte
-	mySubs      map[string]map[string]byte
-
-	mu      *sync.Mutex
+	store   store.PresenceStore
 	gateway gate.Gateway
 
+	// publisher publishes the presence changes to all nodes, nil express notify the watchers of this node only.
+	publisher store.PresencePublisher
+
+	// maxWatching the max count of users a user can subscribe
+	maxWatching int
+
+	// blocks hides the presence from the users blocked, nil express do not check.
+	blocks *BlockService
+
+	// mu serializes the state changes on this node, so that the changed state is compared with the right previous one
+	mu sync.Mutex
+
 	logStateAt int64
 }
 
 func NewUserState(gateway gate.Gateway) *UserState {
-	return &UserState{
-		subscribers: map[string]map[string]byte{},
-		mySubs:      map[string]map[string]byte{},
+	return NewUserStateWithStore(gateway, store.NewMemoryPresenceStore())
+}
+
+func NewUserStateWithStore(gateway gate.Gateway, presenceStore store.PresenceStore) *UserState {
+	u := &UserState{
+		store:       presenceStore,
 		gateway:     gateway,
-		mu:          &sync.Mutex{},
+		maxWatching: defaultMaxWatching,
+	}
+	if publisher, ok := presenceStore.(store.PresencePublisher); ok {
+		err := publisher.SubscribePresence(u.onPresenceEvent)
+		if err != nil {
+			logger.E("subscribe presence events error, only the watchers of this node are notified: %v", err)
+		} else {
+			u.publisher = publisher
+		}
 	}
+	return u
+}
+
+// deviceKey returns the key of the client in the devices of user, the same device type may connect to different gateways.
+func deviceKey(id gate.ID) string {
+	return id.Gateway() + ":" + id.Device()
 }
 
 func (u *UserState) onUserOnline(id gate.ID) {
-	u.mu.Lock()
-	defer u.mu.Unlock()
+	if id.IsTemp() {
+		return
+	}
+	u.setDeviceState(id, messages.PresenceOnline)
 
-	mySubList, ok := u.subscribers[id.UID()]
-	if !ok {
-		mySubList = map[string]byte{}
-		u.subscribers[id.UID()] = mySubList
+	var s = time.Now().Unix() - u.logStateAt
+	if s > 900 {
+		u.logStateAt = time.Now().Unix()
+		logger.D("[UserState] user online: %s", id)
 	}
-	u.notifyOnline(id, mySubList)
 }
 
 func (u *UserState) onUserOffline(id gate.ID) {
-	u.mu.Lock()
-	defer u.mu.Unlock()
+	if id.IsTemp() {
+		return
+	}
+	p := u.setDeviceState(id, messages.PresenceOffline)
+	if p == nil || p.State != messages.PresenceOffline {
+		return
+	}
+	u.releaseWatching(id.UID())
+}
 
-	mySubList, ok := u.subscribers[id.UID()]
-	if !ok || len(mySubList) == 0 {
+// releaseWatching releases the subscriptions of the user after all devices offline, both the watching list of the user
+// and the user in the watchers of each watched user are removed.
+func (u *UserState) releaseWatching(myId string) {
+	watching, err := u.store.GetWatching(myId)
+	if err != nil {
+		logger.E("get watching users error %v", err)
+		return
+	}
+	if len(watching) == 0 {
 		return
 	}
-	u.notifyOffline(id, mySubList)
+	err = u.store.RemoveWatching(myId, watching...)
+	if err != nil {
+		logger.E("remove watching users error %v", err)
+	}
+}
 
-	myId := id.UID()
-	sub, ok := u.mySubs[myId]
+// onHeartbeat refreshes the device of the client, the expired device is set online again.
+func (u *UserState) onHeartbeat(id gate.ID) {
+	if id.IsTemp() {
+		return
+	}
+	ok, err := u.store.RefreshDevice(id.UID(), deviceKey(id))
+	if err != nil {
+		logger.E("refresh device error %v", err)
+		return
+	}
 	if !ok {
+		u.setDeviceState(id, messages.PresenceOnline)
+	}
+}
+
+// runExpireSweeper notifies the subscribers of the users whose devices expired without heartbeat, such as the clients
+// of the crashed nodes, the users are offline as the last device disconnected.
+func (u *UserState) runExpireSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		u.expireDevices()
+	}
+}
+
+func (u *UserState) expireDevices() {
+	u.mu.Lock()
+	uids, err := u.store.ExpireDevices()
+	u.mu.Unlock()
+	if err != nil {
+		logger.E("expire presence devices error %v", err)
+	}
+	for _, uid := range uids {
+		p, err := u.store.GetPresence(uid)
+		if err != nil {
+			logger.E("get presence error %v", err)
+			continue
+		}
+		if p.State != messages.PresenceOffline {
+			// online again after expired
+			continue
+		}
+		u.publish(p, false)
+		u.releaseWatching(uid)
+	}
+}
+
+// setDeviceState updates the state of device, notifies the subscribers if the aggregated state changed, returns the
+// presence after updated.
+func (u *UserState) setDeviceState(id gate.ID, state int32) *messages.Presence {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	uid := id.UID()
+	before, err := u.store.GetPresence(uid)
+	if err != nil {
+		logger.E("get presence error %v", err)
+		return nil
+	}
+	err = u.store.SetDeviceState(uid, deviceKey(id), state)
+	if err != nil {
+		logger.E("set device state error %v", err)
+		return nil
+	}
+	after, err := u.store.GetPresence(uid)
+	if err != nil {
+		logger.E("get presence error %v", err)
+		return nil
+	}
+	if before.State != after.State {
+		u.publish(after, false)
+	}
+	return after
+}
+
+// canSee returns true if the presence of uid is visible to viewer, the presence is hidden by the privacy setting or
+// the block list of uid.
+func (u *UserState) canSee(viewer string, uid string) (bool, error) {
+	if u.blocks != nil && u.blocks.IsBlocked(uid, viewer) {
+		return false, nil
+	}
+	return store.CanSeePresence(u.store, viewer, uid)
+}
+
+// presenceFor returns the presence of uid seen by viewer, the hidden presence is always offline without detail.
+func (u *UserState) presenceFor(viewer string, uid string) (*messages.Presence, error) {
+	visible, err := u.canSee(viewer, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return &messages.Presence{Uid: uid}, nil
+	}
+	return u.store.GetPresence(uid)
+}
+
+// publish notifies the watchers connected to all nodes through the publisher, the watchers of this node are notified
+// directly if there is no publisher or failed to publish.
+func (u *UserState) publish(p *messages.Presence, all bool) {
+	if u.publisher != nil {
+		err := u.publisher.PublishPresence(&store.PresenceEvent{Presence: p, Refresh: all})
+		if err == nil {
+			return
+		}
+		logger.E("publish presence error %v", err)
+	}
+	u.notifyWatchers(p, all)
+}
+
+// onPresenceEvent notifies the watchers of this node the presence published by any node.
+func (u *UserState) onPresenceEvent(e *store.PresenceEvent) {
+	u.notifyWatchers(e.Presence, e.Refresh)
+}
+
+// notifyWatchers sends the presence to the subscribers who can see it, the hidden presence is sent to others when
+// all is true, used to notify the subscribers after the privacy changed.
+func (u *UserState) notifyWatchers(p *messages.Presence, all bool) {
+	watchers, err := u.store.GetWatchers(p.Uid)
+	if err != nil {
+		logger.E("get presence watchers error %v", err)
+		return
+	}
+	if len(watchers) == 0 {
 		return
 	}
-	for uid := range sub {
-		target, ok2 := u.subscribers[uid]
-		if !ok2 {
+	notify := messages.NewMessage(0, messages.ActionNotifyUserState, p)
+	hidden := messages.NewMessage(0, messages.ActionNotifyUserState, &messages.Presence{Uid: p.Uid})
+	for _, watcher := range watchers {
+		visible, err := u.canSee(watcher, p.Uid)
+		if err != nil {
+			logger.E("check presence privacy error %v", err)
 			continue
 		}
-		delete(target, uid)
+		if visible {
+			u.sendToAllDevice(watcher, notify)
+		} else if all {
+			u.sendToAllDevice(watcher, hidden)
+		}
+	}
+}
+
+// refreshWatchers notifies the subscribers of uid the presence they can see, used after the visibility changed.
+func (u *UserState) refreshWatchers(uid string) {
+	p, err := u.store.GetPresence(uid)
+	if err != nil {
+		logger.E("get presence error %v", err)
+		return
+	}
+	u.publish(p, true)
+}
+
+func (u *UserState) sendToAllDevice(uid string, m *messages.GlideMessage) {
+	if u.gateway == nil {
+		return
+	}
+	for _, device := range allDevices {
+		err := u.gateway.EnqueueMessage(gate.NewID("", uid, device), m)
+		if err != nil && !gate.IsClientNotExist(err) {
+			logger.E("dispatch presence error %v", err)
+		}
 	}
-	delete(u.mySubs, myId)
 }
 
-func (u *UserState) subUserStateApi(c *gate.Info, m *messages.GlideMessage) error {
+// subUserStateApi 订阅用户的在线状态, Replace 为 true 时以 Uids 替换当前的订阅, 订阅成功后返回被订阅用户当前的状态,
+// 之后被订阅用户的状态变化时通知订阅者
+func (u *UserState) subUserStateApi(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceUnauthenticated), nil
+	}
 	data := StateSubscribeData{}
 	err := m.Data.Deserialize(&data)
 	if err != nil {
-		return err
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
 	}
 
 	myId := c.ID.UID()
-	u.mu.Lock()
-	defer u.mu.Unlock()
+	subs := map[string]struct{}{}
+	for _, uid := range data.Uids {
+		if uid != "" && uid != myId {
+			subs[uid] = struct{}{}
+		}
+	}
 
-	mySubs, ok := u.mySubs[myId]
-	if !ok {
-		mySubs = map[string]byte{}
-		u.mySubs[myId] = mySubs
+	watching, err := u.store.GetWatching(myId)
+	if err != nil {
+		logger.E("get watching users error %v", err)
+		return nil, err
+	}
+	var removed []string
+	count := len(subs)
+	for _, uid := range watching {
+		if _, ok := subs[uid]; ok {
+			continue
+		}
+		if data.Replace {
+			removed = append(removed, uid)
+		} else {
+			count++
+		}
+	}
+	if count > u.maxWatching {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceTooManyWatching), nil
 	}
 
-	for _, uid := range data.Uids {
-		subscribers, ok := u.subscribers[uid]
-		if !ok {
-			subscribers = map[string]byte{}
-			u.subscribers[uid] = subscribers
+	if len(removed) > 0 {
+		err = u.store.RemoveWatching(myId, removed...)
+		if err != nil {
+			logger.E("remove watching users error %v", err)
+			return nil, err
+		}
+	}
+	uids := make([]string, 0, len(subs))
+	for uid := range subs {
+		uids = append(uids, uid)
+	}
+	if len(uids) > 0 {
+		err = u.store.AddWatching(myId, uids...)
+		if err != nil {
+			logger.E("add watching users error %v", err)
+			return nil, err
+		}
+	}
+
+	// snapshot of the subscribed users, the client need not wait for the next state change
+	result := messages.PresenceList{}
+	for _, uid := range uids {
+		p, err := u.presenceFor(myId, uid)
+		if err != nil {
+			logger.E("get presence error %v", err)
+			return nil, err
 		}
-		u.subscribers[uid][myId] = 0
-		mySubs[uid] = 0
+		result.Presences = append(result.Presences, p)
 	}
-	return nil
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, &result), nil
 }
 
-func (u *UserState) notifyOnline(src gate.ID, to map[string]byte) {
-	notify := messages.NewMessage(0, messages.ActionNotifyUserState, UserStateData{
-		Uid:    src.UID(),
-		Online: false,
-	})
-	for uid := range to {
-		_ = u.gateway.EnqueueMessage(gate.NewID2(uid), notify)
+// unsubUserStateApi 取消订阅用户的在线状态, Uids 为空时取消所有订阅
+func (u *UserState) unsubUserStateApi(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceUnauthenticated), nil
+	}
+	data := StateSubscribeData{}
+	err := m.Data.Deserialize(&data)
+	if err != nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
 	}
 
-	var s = time.Now().Unix() - u.logStateAt
-	if s > 900 {
-		u.logStateAt = time.Now().Unix()
-		logger.D("[UserState] online users: %d, subscribes: %d", len(u.mySubs), len(u.subscribers))
+	myId := c.ID.UID()
+	uids := data.Uids
+	if len(uids) == 0 {
+		uids, err = u.store.GetWatching(myId)
+		if err != nil {
+			logger.E("get watching users error %v", err)
+			return nil, err
+		}
 	}
+	if len(uids) > 0 {
+		err = u.store.RemoveWatching(myId, uids...)
+		if err != nil {
+			logger.E("remove watching users error %v", err)
+			return nil, err
+		}
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, nil), nil
+}
+
+// queryPresenceApi 查询用户的在线状态, 对查询者隐藏的用户返回离线
+func (u *UserState) queryPresenceApi(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceUnauthenticated), nil
+	}
+	req := new(messages.PresenceQuery)
+	err := m.Data.Deserialize(req)
+	if err != nil || len(req.Uids) > maxPresenceQuery {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
+	}
+	result := messages.PresenceList{}
+	for _, uid := range req.Uids {
+		p, err := u.presenceFor(c.ID.UID(), uid)
+		if err != nil {
+			logger.E("get presence error %v", err)
+			return nil, err
+		}
+		result.Presences = append(result.Presences, p)
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, &result), nil
 }
 
-func (u *UserState) notifyOffline(src gate.ID, to map[string]byte) {
-	notify := messages.NewMessage(0, messages.ActionNotifyUserState, UserStateData{
-		Uid:    src.UID(),
-		Online: true,
-	})
-	for uid := range to {
-		_ = u.gateway.EnqueueMessage(gate.NewID2(uid), notify)
+// updatePresenceApi 设置当前设备的在线或离开状态, 以及用户的状态文本
+func (u *UserState) updatePresenceApi(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceUnauthenticated), nil
+	}
+	req := new(messages.PresenceUpdate)
+	err := m.Data.Deserialize(req)
+	if err != nil || len([]rune(req.StatusText)) > maxStatusTextLength {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
+	}
+	if req.State != 0 && req.State != messages.PresenceOnline && req.State != messages.PresenceAway {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
+	}
+
+	uid := c.ID.UID()
+	before, err := u.store.GetPresence(uid)
+	if err != nil {
+		return nil, err
+	}
+	if before.StatusText != req.StatusText {
+		err = u.store.SetStatusText(uid, req.StatusText)
+		if err != nil {
+			logger.E("set status text error %v", err)
+			return nil, err
+		}
+	}
+	var after *messages.Presence
+	if req.State != 0 {
+		after = u.setDeviceState(c.ID, req.State)
+	} else {
+		after, err = u.store.GetPresence(uid)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if after == nil {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
+	}
+	// the state change is notified in setDeviceState
+	if before.StatusText != after.StatusText && before.State == after.State {
+		u.publish(after, false)
+	}
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, after), nil
+}
+
+// privacyApi 设置谁可以看到自己的在线状态, 并将设置后可见的状态通知订阅者
+func (u *UserState) privacyApi(c *gate.Info, m *messages.GlideMessage) (*messages.GlideMessage, error) {
+	if c.ID.IsTemp() {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceUnauthenticated), nil
+	}
+	req := new(messages.PresencePrivacy)
+	err := m.Data.Deserialize(req)
+	if err != nil || len(req.AllowList) > maxPresenceAllowList {
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
+	}
+	switch req.Privacy {
+	case messages.PresencePrivacyEveryone, messages.PresencePrivacyAllowList, messages.PresencePrivacyNobody:
+	default:
+		return messages.NewMessage(m.GetSeq(), messages.ActionApiFailed, errPresenceInvalidRequest), nil
+	}
+
+	uid := c.ID.UID()
+	err = u.store.SetPrivacy(uid, req.Privacy, req.AllowList)
+	if err != nil {
+		logger.E("set presence privacy error %v", err)
+		return nil, err
+	}
+	p, err := u.store.GetPresence(uid)
+	if err != nil {
+		return nil, err
 	}
+	u.publish(p, true)
+	return messages.NewMessage(m.GetSeq(), messages.ActionApiSuccess, req), nil
 }
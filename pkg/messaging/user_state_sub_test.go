@@ -0,0 +1,93 @@
+package messaging
+
+import (
+	"github.com/glide-im/glide/pkg/gate"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// sharedPresenceStore is a store.PresenceStore shared by nodes, the events are published to all nodes synchronously.
+type sharedPresenceStore struct {
+	*store.MemoryPresenceStore
+	subscribers []func(e *store.PresenceEvent)
+}
+
+func (s *sharedPresenceStore) PublishPresence(e *store.PresenceEvent) error {
+	for _, fn := range s.subscribers {
+		fn(e)
+	}
+	return nil
+}
+
+func (s *sharedPresenceStore) SubscribePresence(fn func(e *store.PresenceEvent)) error {
+	s.subscribers = append(s.subscribers, fn)
+	return nil
+}
+
+func TestUserState_NotifyOtherNode(t *testing.T) {
+	s := &sharedPresenceStore{MemoryPresenceStore: store.NewMemoryPresenceStore()}
+	watcher := gate.NewID("", "2", "")
+	g1 := &mockBotGateway{online: map[gate.ID]bool{}, received: map[gate.ID][]*messages.GlideMessage{}}
+	g2 := &mockBotGateway{online: map[gate.ID]bool{watcher: true}, received: map[gate.ID][]*messages.GlideMessage{}}
+	node1 := NewUserStateWithStore(g1, s)
+	NewUserStateWithStore(g2, s)
+
+	_ = s.AddWatching("2", "1")
+	node1.onUserOnline(gate.NewID("node1", "1", "1"))
+
+	// the watcher connected to the other node is notified
+	notified := g2.get(watcher, messages.ActionNotifyUserState)
+	assert.Len(t, notified, 1)
+	p := messages.Presence{}
+	assert.NoError(t, notified[0].Data.Deserialize(&p))
+	assert.Equal(t, "1", p.Uid)
+	assert.Equal(t, messages.PresenceOnline, p.State)
+
+	// the heartbeat refreshes the device without notifying
+	node1.onHeartbeat(gate.NewID("node1", "1", "1"))
+	assert.Len(t, g2.get(watcher, messages.ActionNotifyUserState), 1)
+}
+
+// expiringPresenceStore expires all devices of the users in expiring.
+type expiringPresenceStore struct {
+	*store.MemoryPresenceStore
+	expiring map[string][]string
+}
+
+func (s *expiringPresenceStore) ExpireDevices() ([]string, error) {
+	var uids []string
+	for uid, devices := range s.expiring {
+		for _, device := range devices {
+			_ = s.SetDeviceState(uid, device, messages.PresenceOffline)
+		}
+		uids = append(uids, uid)
+	}
+	s.expiring = nil
+	return uids, nil
+}
+
+func TestUserState_ExpireDevices(t *testing.T) {
+	s := &expiringPresenceStore{MemoryPresenceStore: store.NewMemoryPresenceStore()}
+	watcher := gate.NewID2("2")
+	g := &mockBotGateway{online: map[gate.ID]bool{watcher: true}, received: map[gate.ID][]*messages.GlideMessage{}}
+	u := NewUserStateWithStore(g, s)
+
+	id := gate.NewID("node1", "1", "1")
+	u.onUserOnline(id)
+	_ = s.AddWatching("2", "1")
+	_ = s.AddWatching("1", "3")
+
+	// the user whose devices expired without heartbeat is offline as disconnected
+	s.expiring = map[string][]string{"1": {deviceKey(id)}}
+	u.expireDevices()
+	notified := g.get(watcher, messages.ActionNotifyUserState)
+	assert.Len(t, notified, 1)
+	p := messages.Presence{}
+	assert.NoError(t, notified[0].Data.Deserialize(&p))
+	assert.Equal(t, "1", p.Uid)
+	assert.Equal(t, messages.PresenceOffline, p.State)
+	watching, _ := s.GetWatching("1")
+	assert.Empty(t, watching)
+}
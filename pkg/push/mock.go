@@ -0,0 +1,30 @@
+package push
+
+import "sync"
+
+var _ Bridge = (*MockBridge)(nil)
+
+// MockBridge records the notifications pushed, used for tests.
+type MockBridge struct {
+	mu            sync.Mutex
+	notifications []*Notification
+}
+
+func NewMockBridge() *MockBridge {
+	return &MockBridge{}
+}
+
+func (m *MockBridge) Push(n *Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *n
+	m.notifications = append(m.notifications, &c)
+	return nil
+}
+
+// Notifications returns the notifications pushed.
+func (m *MockBridge) Notifications() []*Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Notification{}, m.notifications...)
+}
@@ -0,0 +1,113 @@
+package push
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// Notification the push notification of a message sent to an offline user.
+type Notification struct {
+	// Uid the receiver
+	Uid string `json:"uid"`
+	// CollapseKey the notifications with the same key replace each other on the device, one key per conversation
+	CollapseKey string `json:"collapse_key,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Body        string `json:"body,omitempty"`
+	// Badge the total unread count of the receiver
+	Badge int64 `json:"badge"`
+	// Silent true express the receiver is in do-not-disturb, the notification updates the badge without alerting
+	Silent bool `json:"silent,omitempty"`
+
+	ChatType int32  `json:"chat_type,omitempty"`
+	From     string `json:"from,omitempty"`
+	Mid      int64  `json:"mid,omitempty"`
+	Type     int32  `json:"type,omitempty"`
+}
+
+// Bridge delivers the notifications to the third-party push service, implementations should be safe for concurrent use.
+type Bridge interface {
+	Push(n *Notification) error
+}
+
+// Template the payload template of a message type, Title and Body are text/template executed with TemplateData.
+type Template struct {
+	Title string
+	Body  string
+}
+
+// TemplateData the data to execute the Template.
+type TemplateData struct {
+	ChatType int32
+	From     string
+	To       string
+	Type     int32
+	// Content the message content, empty for the message should not be previewed
+	Content string
+}
+
+// DefaultTemplate used for the message type without template.
+var DefaultTemplate = Template{
+	Title: "{{.From}}",
+	Body:  "{{if .Content}}{{.Content}}{{else}}[New message]{{end}}",
+}
+
+type parsedTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+func parseTemplate(t Template) (*parsedTemplate, error) {
+	title, err := template.New("title").Parse(t.Title)
+	if err != nil {
+		return nil, err
+	}
+	body, err := template.New("body").Parse(t.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &parsedTemplate{title: title, body: body}, nil
+}
+
+// Templates renders the title and the body of notifications by message type.
+type Templates struct {
+	def    *parsedTemplate
+	byType map[int32]*parsedTemplate
+}
+
+// NewTemplates parses the templates, def is used for the types not in byType.
+func NewTemplates(def Template, byType map[int32]Template) (*Templates, error) {
+	ret := &Templates{
+		byType: map[int32]*parsedTemplate{},
+	}
+	var err error
+	ret.def, err = parseTemplate(def)
+	if err != nil {
+		return nil, err
+	}
+	for typ, t := range byType {
+		ret.byType[typ], err = parseTemplate(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// Render returns the title and the body of the message of type.
+func (t *Templates) Render(data *TemplateData) (string, string, error) {
+	p, ok := t.byType[data.Type]
+	if !ok {
+		p = t.def
+	}
+	title := bytes.Buffer{}
+	err := p.title.Execute(&title, data)
+	if err != nil {
+		return "", "", err
+	}
+	body := bytes.Buffer{}
+	err = p.body.Execute(&body, data)
+	if err != nil {
+		return "", "", err
+	}
+	return title.String(), body.String(), nil
+}
@@ -0,0 +1,54 @@
+package push
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := NewTemplates(DefaultTemplate, map[int32]Template{
+		2: {Title: "{{.From}}", Body: "[Image]"},
+	})
+	assert.NoError(t, err)
+
+	title, body, err := templates.Render(&TemplateData{From: "1", Type: 1, Content: "hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", title)
+	assert.Equal(t, "hello", body)
+
+	_, body, _ = templates.Render(&TemplateData{From: "1", Type: 2, Content: "http://img"})
+	assert.Equal(t, "[Image]", body)
+
+	_, body, _ = templates.Render(&TemplateData{From: "1", Type: 1})
+	assert.Equal(t, "[New message]", body)
+}
+
+func TestWebhookBridge_Push(t *testing.T) {
+	received := make(chan *Notification, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, Sign("secret", body), r.Header.Get(HeaderSignature))
+		n := new(Notification)
+		_ = json.Unmarshal(body, n)
+		received <- n
+	}))
+	defer srv.Close()
+
+	err := NewWebhookBridge(srv.URL, "secret", time.Second).Push(&Notification{Uid: "2", Badge: 3})
+	assert.NoError(t, err)
+	n := <-received
+	assert.Equal(t, "2", n.Uid)
+	assert.Equal(t, int64(3), n.Badge)
+
+	err = NewWebhookBridge(srv.URL+"/404", "", time.Second).Push(&Notification{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package push
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HeaderSignature the header of the hex encoded HMAC-SHA256 of the request body signed with the webhook secret.
+const HeaderSignature = "X-Glide-Signature"
+
+var _ Bridge = (*WebhookBridge)(nil)
+
+// WebhookBridge posts the notification as json to the push service.
+type WebhookBridge struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookBridge returns a WebhookBridge posts to url, the request is signed when secret is not empty.
+func NewWebhookBridge(url string, secret string, timeout time.Duration) *WebhookBridge {
+	return &WebhookBridge{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *WebhookBridge) Push(n *Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set(HeaderSignature, Sign(w.secret, body))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("push webhook responses %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature of the body, used by the push service to verify the request.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+var _ BlockStore = (*MemoryBlockStore)(nil)
+
+// MemoryBlockStore is an in-memory BlockStore.
+type MemoryBlockStore struct {
+	mu      sync.RWMutex
+	blocked map[string]map[string]struct{}
+}
+
+func NewMemoryBlockStore() *MemoryBlockStore {
+	return &MemoryBlockStore{
+		blocked: map[string]map[string]struct{}{},
+	}
+}
+
+func (m *MemoryBlockStore) Block(uid string, blocked ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	set, ok := m.blocked[uid]
+	if !ok {
+		set = map[string]struct{}{}
+		m.blocked[uid] = set
+	}
+	for _, b := range blocked {
+		set[b] = struct{}{}
+	}
+	return nil
+}
+
+func (m *MemoryBlockStore) Unblock(uid string, blocked ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	set := m.blocked[uid]
+	for _, b := range blocked {
+		delete(set, b)
+	}
+	if len(set) == 0 {
+		delete(m.blocked, uid)
+	}
+	return nil
+}
+
+func (m *MemoryBlockStore) IsBlocked(uid string, other string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.blocked[uid][other]
+	return ok, nil
+}
+
+func (m *MemoryBlockStore) GetBlocked(uid string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var result []string
+	for b := range m.blocked[uid] {
+		result = append(result, b)
+	}
+	sort.Strings(result)
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"github.com/go-redis/redis"
+	"sort"
+)
+
+const KeyRedisBlockPrefix = "im:block:"
+
+var _ BlockStore = (*RedisBlockStore)(nil)
+
+// RedisBlockStore is a BlockStore backed by redis, the block list of each user is saved in a set.
+type RedisBlockStore struct {
+	client *redis.Client
+}
+
+func NewRedisBlockStore(client *redis.Client) *RedisBlockStore {
+	return &RedisBlockStore{
+		client: client,
+	}
+}
+
+func (r *RedisBlockStore) Block(uid string, blocked ...string) error {
+	if len(blocked) == 0 {
+		return nil
+	}
+	members := make([]interface{}, 0, len(blocked))
+	for _, b := range blocked {
+		members = append(members, b)
+	}
+	return r.client.SAdd(KeyRedisBlockPrefix+uid, members...).Err()
+}
+
+func (r *RedisBlockStore) Unblock(uid string, blocked ...string) error {
+	if len(blocked) == 0 {
+		return nil
+	}
+	members := make([]interface{}, 0, len(blocked))
+	for _, b := range blocked {
+		members = append(members, b)
+	}
+	return r.client.SRem(KeyRedisBlockPrefix+uid, members...).Err()
+}
+
+func (r *RedisBlockStore) IsBlocked(uid string, other string) (bool, error) {
+	return r.client.SIsMember(KeyRedisBlockPrefix+uid, other).Result()
+}
+
+func (r *RedisBlockStore) GetBlocked(uid string) ([]string, error) {
+	result, err := r.client.SMembers(KeyRedisBlockPrefix + uid).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(result)
+	return result, nil
+}
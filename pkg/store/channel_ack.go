@@ -0,0 +1,40 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/subscription"
+)
+
+var _ ChannelAckStore = (*MemoryChannelAckStore)(nil)
+
+// MemoryChannelAckStore is an in-memory ChannelAckStore.
+type MemoryChannelAckStore struct {
+	cursors *MemoryReadCursorStore
+}
+
+func NewMemoryChannelAckStore() *MemoryChannelAckStore {
+	return &MemoryChannelAckStore{
+		cursors: NewMemoryReadCursorStore(),
+	}
+}
+
+func (m *MemoryChannelAckStore) UpdateMemberAck(ch subscription.ChanID, member subscription.SubscriberID, seq int64) (bool, error) {
+	return m.cursors.UpdateReadCursor(string(member), string(ch), seq)
+}
+
+func (m *MemoryChannelAckStore) GetMemberAck(ch subscription.ChanID, member subscription.SubscriberID) (int64, error) {
+	return m.cursors.GetReadCursor(string(member), string(ch))
+}
+
+func (m *MemoryChannelAckStore) CountAcked(ch subscription.ChanID, seq int64, members []string) (int64, error) {
+	var count int64
+	for _, member := range members {
+		acked, err := m.cursors.GetReadCursor(member, string(ch))
+		if err != nil {
+			return 0, err
+		}
+		if acked >= seq {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryChannelAckStore_CountAcked(t *testing.T) {
+	s := NewMemoryChannelAckStore()
+
+	_, _ = s.UpdateMemberAck("ch", "1", 5)
+	_, _ = s.UpdateMemberAck("ch", "2", 3)
+	_, _ = s.UpdateMemberAck("ch", "3", 8)
+	_, _ = s.UpdateMemberAck("other", "1", 10)
+
+	count, err := s.CountAcked("ch", 4, []string{"1", "2", "3", "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// the member "3" left the channel
+	count, err = s.CountAcked("ch", 4, []string{"1", "2", "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
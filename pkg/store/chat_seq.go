@@ -0,0 +1,41 @@
+package store
+
+import "sync"
+
+var _ ChatSequenceStore = (*MemoryChatSequenceStore)(nil)
+
+// MemoryChatSequenceStore is an in-memory ChatSequenceStore, the seq restarts from 1 after restart.
+type MemoryChatSequenceStore struct {
+	mu      sync.Mutex
+	segment int64
+	seq     map[string]int64
+}
+
+// NewMemoryChatSequenceStore returns a MemoryChatSequenceStore allocates segment seq at once, 1 if segment <= 0.
+func NewMemoryChatSequenceStore(segment int64) *MemoryChatSequenceStore {
+	if segment <= 0 {
+		segment = 1
+	}
+	return &MemoryChatSequenceStore{
+		segment: segment,
+		seq:     map[string]int64{},
+	}
+}
+
+func (m *MemoryChatSequenceStore) NextChatSegment(conversation string) (int64, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	start := m.seq[conversation] + 1
+	m.seq[conversation] += m.segment
+	return start, m.segment, nil
+}
+
+func (m *MemoryChatSequenceStore) ReturnChatSegment(conversation string, next int64, end int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seq[conversation] != end {
+		return false, nil
+	}
+	m.seq[conversation] = next - 1
+	return true, nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import "github.com/go-redis/redis"
+
+const KeyRedisChatSeqPrefix = "im:chat:seq:"
+
+// returnChatSegmentScript resets the last allocated seq to ARGV[1] if it is still ARGV[2].
+var returnChatSegmentScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1])
+return 1
+`)
+
+var _ ChatSequenceStore = (*RedisChatSequenceStore)(nil)
+
+// RedisChatSequenceStore is a ChatSequenceStore backed by redis, the last allocated seq of each conversation is saved
+// in a key. The segment greater than 1 reduces the round trips to redis, but the seq of a conversation is monotonic
+// and gap-free only if one node allocates seq for it, use 1 when the participants may connect to different nodes.
+type RedisChatSequenceStore struct {
+	client  *redis.Client
+	segment int64
+}
+
+// NewRedisChatSequenceStore returns a RedisChatSequenceStore allocates segment seq at once, 1 if segment <= 0.
+func NewRedisChatSequenceStore(client *redis.Client, segment int64) *RedisChatSequenceStore {
+	if segment <= 0 {
+		segment = 1
+	}
+	return &RedisChatSequenceStore{
+		client:  client,
+		segment: segment,
+	}
+}
+
+func (r *RedisChatSequenceStore) NextChatSegment(conversation string) (int64, int64, error) {
+	end, err := r.client.IncrBy(KeyRedisChatSeqPrefix+conversation, r.segment).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	return end - r.segment + 1, r.segment, nil
+}
+
+func (r *RedisChatSequenceStore) ReturnChatSegment(conversation string, next int64, end int64) (bool, error) {
+	n, err := returnChatSegmentScript.Run(r.client, []string{KeyRedisChatSeqPrefix + conversation}, next-1, end).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
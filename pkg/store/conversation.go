@@ -0,0 +1,155 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var _ ConversationStore = (*MemoryConversationStore)(nil)
+var _ ConversationBadgeStore = (*MemoryConversationStore)(nil)
+var _ ConversationBatchStore = (*MemoryConversationStore)(nil)
+
+// conversationKey returns the key of conversation in the conversation list of a user.
+func conversationKey(chatType int32, id string) string {
+	return strconv.Itoa(int(chatType)) + "_" + id
+}
+
+// sortConversations sorts the conversations, pinned first, then by the activity time desc.
+func sortConversations(list []*messages.Conversation) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Pinned != list[j].Pinned {
+			return list[i].Pinned
+		}
+		return list[i].ActiveAt > list[j].ActiveAt
+	})
+}
+
+// MemoryConversationStore is an in-memory ConversationStore.
+type MemoryConversationStore struct {
+	mu sync.RWMutex
+	// conversations uid => conversation key => conversation
+	conversations map[string]map[string]*messages.Conversation
+}
+
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{
+		conversations: map[string]map[string]*messages.Conversation{},
+	}
+}
+
+// get returns the conversation of uid, creates if not exist, the caller must hold the lock.
+func (m *MemoryConversationStore) get(uid string, chatType int32, id string) *messages.Conversation {
+	list, ok := m.conversations[uid]
+	if !ok {
+		list = map[string]*messages.Conversation{}
+		m.conversations[uid] = list
+	}
+	key := conversationKey(chatType, id)
+	c, ok := list[key]
+	if !ok {
+		c = &messages.Conversation{ChatType: chatType, ID: id}
+		list[key] = c
+	}
+	return c
+}
+
+func (m *MemoryConversationStore) UpdateConversation(uid string, conv *messages.Conversation, unread bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.update(uid, conv, unread)
+	return nil
+}
+
+func (m *MemoryConversationStore) UpdateConversations(uids []string, conv *messages.Conversation, sender string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, uid := range uids {
+		m.update(uid, conv, uid != sender)
+	}
+	return nil
+}
+
+// update updates the last message of the conversation of uid, the caller must hold the lock.
+func (m *MemoryConversationStore) update(uid string, conv *messages.Conversation, unread bool) {
+	c := m.get(uid, conv.ChatType, conv.ID)
+	c.LastMid = conv.LastMid
+	c.LastFrom = conv.LastFrom
+	c.LastType = conv.LastType
+	c.Preview = conv.Preview
+	c.ActiveAt = conv.ActiveAt
+	if unread {
+		c.Unread++
+	}
+}
+
+func (m *MemoryConversationStore) ClearUnread(uid string, chatType int32, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.conversations[uid][conversationKey(chatType, id)]
+	if ok {
+		c.Unread = 0
+	}
+	return nil
+}
+
+func (m *MemoryConversationStore) SetConversationFlags(uid string, chatType int32, id string, pinned *bool, muted *bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.get(uid, chatType, id)
+	if pinned != nil {
+		c.Pinned = *pinned
+	}
+	if muted != nil {
+		c.Muted = *muted
+	}
+	return nil
+}
+
+func (m *MemoryConversationStore) ListConversations(uid string, offset int, limit int) ([]*messages.Conversation, error) {
+	m.mu.RLock()
+	list := make([]*messages.Conversation, 0, len(m.conversations[uid]))
+	for _, c := range m.conversations[uid] {
+		cp := *c
+		list = append(list, &cp)
+	}
+	m.mu.RUnlock()
+
+	sortConversations(list)
+	if offset >= len(list) {
+		return nil, nil
+	}
+	list = list[offset:]
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (m *MemoryConversationStore) GetConversation(uid string, chatType int32, id string) (*messages.Conversation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.conversations[uid][conversationKey(chatType, id)]
+	if !ok {
+		return nil, nil
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (m *MemoryConversationStore) TotalUnread(uid string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var total int64
+	for _, c := range m.conversations[uid] {
+		total += c.Unread
+	}
+	return total, nil
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+	"strconv"
+)
+
+const (
+	KeyRedisConversationPrefix     = "im:conv:"
+	KeyRedisConversationListPrefix = "im:conv:list:"
+)
+
+// conversationBatchSize the max count of conversations updated in a pipeline.
+const conversationBatchSize = 500
+
+// pinnedScoreBoost is added to the score of pinned conversations in the conversation list, keeps them at the top.
+const pinnedScoreBoost = 1e11
+
+// updateConversationScript updates the last message of the conversation hash, and the score in the conversation list.
+// KEYS: conversation hash, conversation list; ARGV: key, chat_type, id, last_mid, last_from, last_type, preview, active_at, unread
+var updateConversationScript = redis.NewScript(`
+redis.call('HMSET', KEYS[1], 'chat_type', ARGV[2], 'id', ARGV[3], 'last_mid', ARGV[4], 'last_from', ARGV[5],
+	'last_type', ARGV[6], 'preview', ARGV[7], 'active_at', ARGV[8])
+if ARGV[9] == '1' then
+	redis.call('HINCRBY', KEYS[1], 'unread', 1)
+end
+local score = tonumber(ARGV[8])
+if redis.call('HGET', KEYS[1], 'pinned') == '1' then
+	score = score + tonumber(ARGV[10])
+end
+redis.call('ZADD', KEYS[2], score, ARGV[1])
+return 1
+`)
+
+// setConversationFlagsScript sets the flags of the conversation hash, and updates the score in the conversation list.
+// KEYS: conversation hash, conversation list; ARGV: key, chat_type, id, pinned, muted, boost, the empty flag is kept
+var setConversationFlagsScript = redis.NewScript(`
+redis.call('HMSET', KEYS[1], 'chat_type', ARGV[2], 'id', ARGV[3])
+if ARGV[4] ~= '' then
+	redis.call('HSET', KEYS[1], 'pinned', ARGV[4])
+end
+if ARGV[5] ~= '' then
+	redis.call('HSET', KEYS[1], 'muted', ARGV[5])
+end
+local score = tonumber(redis.call('HGET', KEYS[1], 'active_at') or '0')
+if redis.call('HGET', KEYS[1], 'pinned') == '1' then
+	score = score + tonumber(ARGV[6])
+end
+redis.call('ZADD', KEYS[2], score, ARGV[1])
+return 1
+`)
+
+var _ ConversationStore = (*RedisConversationStore)(nil)
+var _ ConversationBadgeStore = (*RedisConversationStore)(nil)
+var _ ConversationBatchStore = (*RedisConversationStore)(nil)
+
+// RedisConversationStore is a ConversationStore backed by redis, each conversation is saved in a hash, the conversation
+// list of a user is a sorted set scored by activity time.
+type RedisConversationStore struct {
+	client *redis.Client
+}
+
+func NewRedisConversationStore(client *redis.Client) *RedisConversationStore {
+	return &RedisConversationStore{
+		client: client,
+	}
+}
+
+func redisBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func (r *RedisConversationStore) UpdateConversation(uid string, conv *messages.Conversation, unread bool) error {
+	key := conversationKey(conv.ChatType, conv.ID)
+	keys := []string{KeyRedisConversationPrefix + uid + ":" + key, KeyRedisConversationListPrefix + uid}
+	return updateConversationScript.Run(r.client, keys, key, conv.ChatType, conv.ID, conv.LastMid, conv.LastFrom,
+		conv.LastType, conv.Preview, conv.ActiveAt, redisBool(unread), pinnedScoreBoost).Err()
+}
+
+// UpdateConversations updates the conversations in pipelines of conversationBatchSize.
+func (r *RedisConversationStore) UpdateConversations(uids []string, conv *messages.Conversation, sender string) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	err := updateConversationScript.Load(r.client).Err()
+	if err != nil {
+		return err
+	}
+	key := conversationKey(conv.ChatType, conv.ID)
+	for start := 0; start < len(uids); start += conversationBatchSize {
+		end := start + conversationBatchSize
+		if end > len(uids) {
+			end = len(uids)
+		}
+		pipe := r.client.Pipeline()
+		for _, uid := range uids[start:end] {
+			keys := []string{KeyRedisConversationPrefix + uid + ":" + key, KeyRedisConversationListPrefix + uid}
+			updateConversationScript.EvalSha(pipe, keys, key, conv.ChatType, conv.ID, conv.LastMid, conv.LastFrom,
+				conv.LastType, conv.Preview, conv.ActiveAt, redisBool(uid != sender), pinnedScoreBoost)
+		}
+		_, err = pipe.Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RedisConversationStore) ClearUnread(uid string, chatType int32, id string) error {
+	key := KeyRedisConversationPrefix + uid + ":" + conversationKey(chatType, id)
+	exist, err := r.client.Exists(key).Result()
+	if err != nil || exist == 0 {
+		return err
+	}
+	return r.client.HSet(key, "unread", 0).Err()
+}
+
+func (r *RedisConversationStore) SetConversationFlags(uid string, chatType int32, id string, pinned *bool, muted *bool) error {
+	key := conversationKey(chatType, id)
+	keys := []string{KeyRedisConversationPrefix + uid + ":" + key, KeyRedisConversationListPrefix + uid}
+	return setConversationFlagsScript.Run(r.client, keys, key, chatType, id, redisFlag(pinned), redisFlag(muted),
+		pinnedScoreBoost).Err()
+}
+
+// redisFlag returns the empty string for the nil flag.
+func redisFlag(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return redisBool(*b)
+}
+
+func (r *RedisConversationStore) ListConversations(uid string, offset int, limit int) ([]*messages.Conversation, error) {
+	keys, err := r.client.ZRevRange(KeyRedisConversationListPrefix+uid, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, pipe.HGetAll(KeyRedisConversationPrefix+uid+":"+key))
+	}
+	_, err = pipe.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*messages.Conversation
+	for _, cmd := range cmds {
+		h := cmd.Val()
+		if len(h) == 0 {
+			continue
+		}
+		result = append(result, conversationFromHash(h))
+	}
+	return result, nil
+}
+
+func conversationFromHash(h map[string]string) *messages.Conversation {
+	chatType, _ := strconv.ParseInt(h["chat_type"], 10, 32)
+	lastType, _ := strconv.ParseInt(h["last_type"], 10, 32)
+	c := &messages.Conversation{
+		ChatType: int32(chatType),
+		ID:       h["id"],
+		LastFrom: h["last_from"],
+		LastType: int32(lastType),
+		Preview:  h["preview"],
+		Pinned:   h["pinned"] == "1",
+		Muted:    h["muted"] == "1",
+	}
+	c.LastMid, _ = strconv.ParseInt(h["last_mid"], 10, 64)
+	c.ActiveAt, _ = strconv.ParseInt(h["active_at"], 10, 64)
+	c.Unread, _ = strconv.ParseInt(h["unread"], 10, 64)
+	return c
+}
+
+func (r *RedisConversationStore) GetConversation(uid string, chatType int32, id string) (*messages.Conversation, error) {
+	h, err := r.client.HGetAll(KeyRedisConversationPrefix + uid + ":" + conversationKey(chatType, id)).Result()
+	if err != nil || len(h) == 0 {
+		return nil, err
+	}
+	return conversationFromHash(h), nil
+}
+
+func (r *RedisConversationStore) TotalUnread(uid string) (int64, error) {
+	keys, err := r.client.ZRange(KeyRedisConversationListPrefix+uid, 0, -1).Result()
+	if err != nil || len(keys) == 0 {
+		return 0, err
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, pipe.HGet(KeyRedisConversationPrefix+uid+":"+key, "unread"))
+	}
+	_, err = pipe.Exec()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	var total int64
+	for _, cmd := range cmds {
+		n, _ := strconv.ParseInt(cmd.Val(), 10, 64)
+		total += n
+	}
+	return total, nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryConversationStore_ListConversations(t *testing.T) {
+	s := NewMemoryConversationStore()
+
+	_ = s.UpdateConversation("1", &messages.Conversation{ChatType: messages.ChatTypeSingle, ID: "2", ActiveAt: 1}, true)
+	_ = s.UpdateConversation("1", &messages.Conversation{ChatType: messages.ChatTypeSingle, ID: "3", ActiveAt: 2}, true)
+	_ = s.UpdateConversation("1", &messages.Conversation{ChatType: messages.ChatTypeChannel, ID: "4", ActiveAt: 3}, false)
+	_ = s.UpdateConversation("1", &messages.Conversation{ChatType: messages.ChatTypeSingle, ID: "2", ActiveAt: 4}, true)
+	pinned, muted := true, false
+	_ = s.SetConversationFlags("1", messages.ChatTypeSingle, "3", &pinned, &muted)
+
+	list, err := s.ListConversations("1", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	// pinned first, then by activity time desc
+	assert.Equal(t, "3", list[0].ID)
+	assert.Equal(t, "2", list[1].ID)
+	assert.Equal(t, int64(2), list[1].Unread)
+	assert.Equal(t, "4", list[2].ID)
+
+	_ = s.ClearUnread("1", messages.ChatTypeSingle, "2")
+	list, err = s.ListConversations("1", 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, int64(0), list[0].Unread)
+}
+
+func TestConversationStore_UpdateConversations(t *testing.T) {
+	prefix := "test_conv_" + time.Now().Format("150405.000") + "_"
+	uids := []string{prefix + "1", prefix + "2", prefix + "3"}
+	test := func(t *testing.T, s interface {
+		ConversationBatchStore
+		ConversationBadgeStore
+	}) {
+		conv := &messages.Conversation{ChatType: messages.ChatTypeChannel, ID: "ch", LastMid: 1, LastFrom: uids[0], ActiveAt: 1}
+		assert.NoError(t, s.UpdateConversations(uids, conv, uids[0]))
+		conv.LastMid = 2
+		assert.NoError(t, s.UpdateConversations(uids, conv, uids[0]))
+
+		// the unread count increases except for the sender
+		for i, uid := range uids {
+			c, err := s.GetConversation(uid, messages.ChatTypeChannel, "ch")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), c.LastMid)
+			if i == 0 {
+				assert.Zero(t, c.Unread)
+			} else {
+				assert.Equal(t, int64(2), c.Unread)
+			}
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryConversationStore())
+	})
+	t.Run("redis", func(t *testing.T) {
+		client := newTestRedisClient(t)
+		defer func() {
+			for _, uid := range uids {
+				client.Del(KeyRedisConversationPrefix+uid+":"+conversationKey(messages.ChatTypeChannel, "ch"), KeyRedisConversationListPrefix+uid)
+			}
+		}()
+		test(t, NewRedisConversationStore(client))
+	})
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupReserveTTL the expiry of the reservation of the message being handled, the message can be handled again after
+// the reservation expired, in case the node crashed before the message stored.
+const dedupReserveTTL = time.Second * 30
+
+// DedupCache caches the server message id and seq of received chat messages, keyed by the sender and the client message
+// id. It is used to recognize the message resent by client, which does not receive the `ack.message` of the first send.
+type DedupCache interface {
+
+	// Reserve atomically reserves the message identified by sender and cliMid for the first send, returns true if
+	// reserved. Returns false with the cached mid and seq if the message is received before, the mid is 0 if the first
+	// send is still being handled.
+	Reserve(from string, cliMid string) (bool, int64, int64, error)
+
+	// Release removes the reservation of the message failed to handle, so that the message resent is handled again.
+	Release(from string, cliMid string) error
+
+	// GetMid returns the server message id and seq of the message identified by sender and cliMid,
+	// returns 0 if the message is not cached or expired.
+	GetMid(from string, cliMid string) (int64, int64, error)
+
+	// PutMid caches the server message id and seq of the message identified by sender and cliMid, replaces the
+	// reservation.
+	PutMid(from string, cliMid string, mid int64, seq int64) error
+}
+
+var _ DedupCache = (*MemoryDedupCache)(nil)
+
+type dedupEntry struct {
+	mid      int64
+	seq      int64
+	expireAt time.Time
+}
+
+// MemoryDedupCache is an in-memory DedupCache, entries are expired after ttl.
+type MemoryDedupCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*dedupEntry
+
+	sweepAt time.Time
+}
+
+func NewMemoryDedupCache(ttl time.Duration) *MemoryDedupCache {
+	return &MemoryDedupCache{
+		ttl:     ttl,
+		entries: map[string]*dedupEntry{},
+		sweepAt: time.Now(),
+	}
+}
+
+func (m *MemoryDedupCache) GetMid(from string, cliMid string) (int64, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := dedupKey(from, cliMid)
+	e, ok := m.entries[key]
+	if !ok {
+		return 0, 0, nil
+	}
+	if e.expireAt.Before(time.Now()) {
+		delete(m.entries, key)
+		return 0, 0, nil
+	}
+	return e.mid, e.seq, nil
+}
+
+func (m *MemoryDedupCache) Reserve(from string, cliMid string) (bool, int64, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	key := dedupKey(from, cliMid)
+	e, ok := m.entries[key]
+	if ok && !e.expireAt.Before(now) {
+		return false, e.mid, e.seq, nil
+	}
+	m.entries[key] = &dedupEntry{expireAt: now.Add(dedupReserveTTL)}
+	return true, 0, 0, nil
+}
+
+func (m *MemoryDedupCache) Release(from string, cliMid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, dedupKey(from, cliMid))
+	return nil
+}
+
+func (m *MemoryDedupCache) PutMid(from string, cliMid string, mid int64, seq int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.entries[dedupKey(from, cliMid)] = &dedupEntry{
+		mid:      mid,
+		seq:      seq,
+		expireAt: now.Add(m.ttl),
+	}
+
+	// remove expired entries at most once per ttl
+	if now.Sub(m.sweepAt) > m.ttl {
+		m.sweepAt = now
+		for k, e := range m.entries {
+			if e.expireAt.Before(now) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	return nil
+}
+
+func dedupKey(from string, cliMid string) string {
+	return from + ":" + cliMid
+}
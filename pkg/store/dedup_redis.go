@@ -0,0 +1,84 @@
+package store
+
+import (
+	"github.com/go-redis/redis"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KeyRedisDedupPrefix = "im:msg:dedup:"
+)
+
+var _ DedupCache = (*RedisDedupCache)(nil)
+
+// RedisDedupCache is a DedupCache backed by redis, shared by all im service instances.
+type RedisDedupCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisDedupCache(client *redis.Client, ttl time.Duration) *RedisDedupCache {
+	return &RedisDedupCache{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func (r *RedisDedupCache) GetMid(from string, cliMid string) (int64, int64, error) {
+	v, err := r.client.Get(KeyRedisDedupPrefix + dedupKey(from, cliMid)).Result()
+	if err == redis.Nil {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseDedupValue(v)
+}
+
+func (r *RedisDedupCache) Reserve(from string, cliMid string) (bool, int64, int64, error) {
+	key := KeyRedisDedupPrefix + dedupKey(from, cliMid)
+	ok, err := r.client.SetNX(key, dedupValue(0, 0), dedupReserveTTL).Result()
+	if err != nil || ok {
+		return ok, 0, 0, err
+	}
+	v, err := r.client.Get(key).Result()
+	if err == redis.Nil {
+		// the reservation expired just now, treat as being handled, the client will resend it again
+		return false, 0, 0, nil
+	}
+	if err != nil {
+		return false, 0, 0, err
+	}
+	mid, seq, err := parseDedupValue(v)
+	return false, mid, seq, err
+}
+
+func (r *RedisDedupCache) Release(from string, cliMid string) error {
+	return r.client.Del(KeyRedisDedupPrefix + dedupKey(from, cliMid)).Err()
+}
+
+func (r *RedisDedupCache) PutMid(from string, cliMid string, mid int64, seq int64) error {
+	return r.client.Set(KeyRedisDedupPrefix+dedupKey(from, cliMid), dedupValue(mid, seq), r.ttl).Err()
+}
+
+// dedupValue returns the cached value of the message, formatted as `mid:seq`.
+func dedupValue(mid int64, seq int64) string {
+	return strconv.FormatInt(mid, 10) + ":" + strconv.FormatInt(seq, 10)
+}
+
+func parseDedupValue(v string) (int64, int64, error) {
+	i := strings.IndexByte(v, ':')
+	if i < 0 {
+		// the value cached by the previous version contains the mid only
+		mid, err := strconv.ParseInt(v, 10, 64)
+		return mid, 0, err
+	}
+	mid, err := strconv.ParseInt(v[:i], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	seq, err := strconv.ParseInt(v[i+1:], 10, 64)
+	return mid, seq, err
+}
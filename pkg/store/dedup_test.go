@@ -0,0 +1,101 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryDedupCache_GetMid(t *testing.T) {
+	cache := NewMemoryDedupCache(time.Minute)
+
+	mid, _, err := cache.GetMid("1", "cli_1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), mid)
+
+	err = cache.PutMid("1", "cli_1", 100, 7)
+	assert.NoError(t, err)
+
+	mid, seq, err := cache.GetMid("1", "cli_1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), mid)
+	assert.Equal(t, int64(7), seq)
+
+	// same client message id from another sender
+	mid, _, err = cache.GetMid("2", "cli_1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), mid)
+}
+
+func TestMemoryDedupCache_Expired(t *testing.T) {
+	cache := NewMemoryDedupCache(time.Millisecond * 50)
+
+	err := cache.PutMid("1", "cli_1", 100, 1)
+	assert.NoError(t, err)
+
+	time.Sleep(time.Millisecond * 100)
+
+	mid, _, err := cache.GetMid("1", "cli_1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), mid)
+}
+
+func testDedupCacheReserve(t *testing.T, cache DedupCache, cliMid string) {
+	// only one of the concurrent sends is reserved
+	var reserved int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, mid, _, err := cache.Reserve("1", cliMid)
+			assert.NoError(t, err)
+			if ok {
+				atomic.AddInt32(&reserved, 1)
+			} else {
+				assert.Equal(t, int64(0), mid)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), reserved)
+
+	// the released message can be reserved again
+	assert.NoError(t, cache.Release("1", cliMid))
+	ok, _, _, err := cache.Reserve("1", cliMid)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, cache.PutMid("1", cliMid, 100, 7))
+	ok, mid, seq, err := cache.Reserve("1", cliMid)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(100), mid)
+	assert.Equal(t, int64(7), seq)
+}
+
+func TestMemoryDedupCache_Reserve(t *testing.T) {
+	testDedupCacheReserve(t, NewMemoryDedupCache(time.Minute), "cli_1")
+}
+
+func TestRedisDedupCache_Reserve(t *testing.T) {
+	client := newTestRedisClient(t)
+	cliMid := "test_dedup_" + time.Now().Format("150405.000")
+	defer client.Del(KeyRedisDedupPrefix + dedupKey("1", cliMid))
+
+	testDedupCacheReserve(t, NewRedisDedupCache(client, time.Minute), cliMid)
+}
+
+func TestParseDedupValue(t *testing.T) {
+	mid, seq, err := parseDedupValue(dedupValue(100, 7))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), mid)
+	assert.Equal(t, int64(7), seq)
+
+	mid, seq, err = parseDedupValue("100")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), mid)
+	assert.Equal(t, int64(0), seq)
+}
@@ -0,0 +1,16 @@
+package store
+
+const (
+	ErrMessageNotFound = "message not found"
+	ErrReactionLimit   = "too many reactions"
+)
+
+// IsMessageNotFound returns true if the error is caused by the message does not exist in store.
+func IsMessageNotFound(err error) bool {
+	return err != nil && err.Error() == ErrMessageNotFound
+}
+
+// IsReactionLimit returns true if the user reaches the max count of distinct reactions of the message.
+func IsReactionLimit(err error) bool {
+	return err != nil && err.Error() == ErrReactionLimit
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var _ MessageExpiryStore = (*MemoryMessageExpiryStore)(nil)
+
+// expiringKey returns the key of the expiring message.
+func expiringKey(chatType int32, mid int64) string {
+	return strconv.Itoa(int(chatType)) + "_" + strconv.FormatInt(mid, 10)
+}
+
+// MemoryMessageExpiryStore is an in-memory MessageExpiryStore.
+type MemoryMessageExpiryStore struct {
+	mu       sync.Mutex
+	messages map[string]*ExpiringMessage
+}
+
+func NewMemoryMessageExpiryStore() *MemoryMessageExpiryStore {
+	return &MemoryMessageExpiryStore{
+		messages: map[string]*ExpiringMessage{},
+	}
+}
+
+func (s *MemoryMessageExpiryStore) AddExpiring(m *ExpiringMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *m
+	s.messages[expiringKey(m.ChatType, m.Mid)] = &c
+	return nil
+}
+
+func (s *MemoryMessageExpiryStore) GetExpiring(chatType int32, mid int64) (*ExpiringMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[expiringKey(chatType, mid)]
+	if !ok {
+		return nil, nil
+	}
+	c := *m
+	return &c, nil
+}
+
+func (s *MemoryMessageExpiryStore) TakeExpiring(chatType int32, mid int64) (*ExpiringMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := expiringKey(chatType, mid)
+	m, ok := s.messages[key]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.messages, key)
+	return m, nil
+}
+
+func (s *MemoryMessageExpiryStore) ListExpired(before int64, limit int) ([]*ExpiringMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*ExpiringMessage
+	for _, m := range s.messages {
+		if m.ExpireAt < before {
+			c := *m
+			result = append(result, &c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ExpireAt < result[j].ExpireAt
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
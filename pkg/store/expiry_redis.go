@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/go-redis/redis"
+	"strconv"
+)
+
+const (
+	KeyRedisExpiringMsg = "im:msg:expiring"
+	KeyRedisExpiringDue = "im:msg:expiring:due"
+)
+
+// takeExpiringScript removes the expiring message from the hash and the due set, returns the removed message.
+// KEYS: message hash, due set; ARGV: key
+var takeExpiringScript = redis.NewScript(`
+local v = redis.call('HGET', KEYS[1], ARGV[1])
+if not v then
+	return false
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+return v
+`)
+
+var _ MessageExpiryStore = (*RedisMessageExpiryStore)(nil)
+
+// RedisMessageExpiryStore is a MessageExpiryStore backed by redis, the messages are saved in a hash, and indexed by a
+// sorted set scored by the expiry time.
+type RedisMessageExpiryStore struct {
+	client *redis.Client
+}
+
+func NewRedisMessageExpiryStore(client *redis.Client) *RedisMessageExpiryStore {
+	return &RedisMessageExpiryStore{
+		client: client,
+	}
+}
+
+func (r *RedisMessageExpiryStore) AddExpiring(m *ExpiringMessage) error {
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	key := expiringKey(m.ChatType, m.Mid)
+	pipe := r.client.TxPipeline()
+	pipe.HSet(KeyRedisExpiringMsg, key, string(bytes))
+	pipe.ZAdd(KeyRedisExpiringDue, redis.Z{Score: float64(m.ExpireAt), Member: key})
+	_, err = pipe.Exec()
+	return err
+}
+
+func (r *RedisMessageExpiryStore) GetExpiring(chatType int32, mid int64) (*ExpiringMessage, error) {
+	v, err := r.client.HGet(KeyRedisExpiringMsg, expiringKey(chatType, mid)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeExpiring(v)
+}
+
+func (r *RedisMessageExpiryStore) TakeExpiring(chatType int32, mid int64) (*ExpiringMessage, error) {
+	keys := []string{KeyRedisExpiringMsg, KeyRedisExpiringDue}
+	v, err := takeExpiringScript.Run(r.client, keys, expiringKey(chatType, mid)).String()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeExpiring(v)
+}
+
+func (r *RedisMessageExpiryStore) ListExpired(before int64, limit int) ([]*ExpiringMessage, error) {
+	keys, err := r.client.ZRangeByScore(KeyRedisExpiringDue, redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   "(" + strconv.FormatInt(before, 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	values, err := r.client.HMGet(KeyRedisExpiringMsg, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var result []*ExpiringMessage
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		m, err := decodeExpiring(s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func decodeExpiring(v string) (*ExpiringMessage, error) {
+	m := new(ExpiringMessage)
+	err := json.Unmarshal([]byte(v), m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
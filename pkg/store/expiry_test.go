@@ -0,0 +1,24 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryMessageExpiryStore_TakeExpiring(t *testing.T) {
+	s := NewMemoryMessageExpiryStore()
+
+	_ = s.AddExpiring(&ExpiringMessage{ChatType: 1, Mid: 1, ExpireAt: 10})
+	_ = s.AddExpiring(&ExpiringMessage{ChatType: 1, Mid: 2, ExpireAt: 20})
+
+	expired, err := s.ListExpired(15, 10)
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, int64(1), expired[0].Mid)
+
+	// only one caller takes the message
+	m, _ := s.TakeExpiring(1, 1)
+	assert.NotNil(t, m)
+	m, _ = s.TakeExpiring(1, 1)
+	assert.Nil(t, m)
+}
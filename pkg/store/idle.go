@@ -1,10 +1,13 @@
 package store
 
 import (
+	"errors"
 	"github.com/glide-im/glide/pkg/messages"
+	"time"
 )
 
 var _ MessageStore = &IdleMessageStore{}
+var _ MessageHistoryStore = &IdleMessageStore{}
 
 type IdleMessageStore struct {
 }
@@ -16,3 +19,20 @@ func (i *IdleMessageStore) StoreOffline(message *messages.ChatMessage) error {
 func (i *IdleMessageStore) StoreMessage(*messages.ChatMessage) error {
 	return nil
 }
+
+func (i *IdleMessageStore) RecallMessage(*messages.Recall) error {
+	return nil
+}
+
+func (i *IdleMessageStore) StoreMessageRevision(edit *messages.MessageEdit) error {
+	edit.Revision = time.Now().UnixNano()
+	return nil
+}
+
+func (i *IdleMessageStore) GetMessage(int32, int64) (*messages.ChatMessage, error) {
+	return nil, errors.New(ErrMessageNotFound)
+}
+
+func (i *IdleMessageStore) ListMessages(*HistoryQuery) ([]*messages.ChatMessage, error) {
+	return nil, nil
+}
@@ -12,6 +12,8 @@ const (
 	KafkaChatMessageTopic        = "getaway_chat_message"
 	KafkaChatOfflineMessageTopic = "getaway_chat_offline_message"
 	KafkaChannelMessageTopic     = "gateway_channel_message"
+	KafkaMessageRecallTopic      = "gateway_message_recall"
+	KafkaMessageRevisionTopic    = "gateway_message_revision"
 )
 
 var _ MessageStore = &KafkaMessageStore{}
@@ -74,6 +76,46 @@ func (k *KafkaMessageStore) StoreOffline(message *messages.ChatMessage) error {
 	return nil
 }
 
+func (k *KafkaMessageStore) RecallMessage(recall *messages.Recall) error {
+	msgBytes, err := json.Marshal(recall)
+	if err != nil {
+		return err
+	}
+
+	cm := &sarama.ProducerMessage{
+		Topic:     KafkaMessageRecallTopic,
+		Value:     &msg{data: msgBytes},
+		Headers:   nil,
+		Metadata:  nil,
+		Offset:    0,
+		Partition: 0,
+		Timestamp: time.Now(),
+	}
+	k.producer.Input() <- cm
+	return nil
+}
+
+// StoreMessageRevision push message edit to kafka, the revision number is the nanosecond timestamp of the edit.
+func (k *KafkaMessageStore) StoreMessageRevision(edit *messages.MessageEdit) error {
+	edit.Revision = time.Now().UnixNano()
+	msgBytes, err := json.Marshal(edit)
+	if err != nil {
+		return err
+	}
+
+	cm := &sarama.ProducerMessage{
+		Topic:     KafkaMessageRevisionTopic,
+		Value:     &msg{data: msgBytes},
+		Headers:   nil,
+		Metadata:  nil,
+		Offset:    0,
+		Partition: 0,
+		Timestamp: time.Now(),
+	}
+	k.producer.Input() <- cm
+	return nil
+}
+
 func (k *KafkaMessageStore) NextSegmentSequence(id subscription.ChanID, info subscription.ChanInfo) (int64, int64, error) {
 	//TODO implement me
 	return 0, 0, nil
@@ -0,0 +1,226 @@
+package store
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var _ MessageStore = (*MemoryMessageStore)(nil)
+var _ MessageHistoryStore = (*MemoryMessageStore)(nil)
+var _ MessagePurgeStore = (*MemoryMessageStore)(nil)
+var _ SubscriptionStore = (*MemoryMessageStore)(nil)
+
+type memoryMessage struct {
+	message  *messages.ChatMessage
+	revision int64
+}
+
+// MemoryMessageStore is an in-memory store of single chat and channel messages, the mid of messages is assigned by the
+// store, the messages are lost after restart.
+type MemoryMessageStore struct {
+	mu  sync.RWMutex
+	mid int64
+	// limit the max count of messages kept in each conversation, 0 express no limit
+	limit    int
+	messages map[string]*memoryMessage
+	// conversation => messages ordered by cursor, the cursor is mid of single chat and seq of channel
+	conversations map[string][]*memoryMessage
+}
+
+// NewMemoryMessageStore returns the MemoryMessageStore keeps at most limit latest messages in each conversation,
+// 0 express no limit.
+func NewMemoryMessageStore(limit int) *MemoryMessageStore {
+	return &MemoryMessageStore{
+		limit:         limit,
+		messages:      map[string]*memoryMessage{},
+		conversations: map[string][]*memoryMessage{},
+	}
+}
+
+func (m *MemoryMessageStore) StoreMessage(message *messages.ChatMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mid++
+	message.Mid = m.mid
+	c := *message
+	m.add(messages.ChatTypeSingle, memoryConversation(messages.ChatTypeSingle, message.From, message.To), &c)
+	return nil
+}
+
+// StoreOffline does nothing, the offline messages are stored by the OfflineStore.
+func (m *MemoryMessageStore) StoreOffline(*messages.ChatMessage) error {
+	return nil
+}
+
+func (m *MemoryMessageStore) NextSegmentSequence(id subscription.ChanID, _ subscription.ChanInfo) (int64, int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var seq int64 = 1
+	list := m.conversations[memoryConversation(messages.ChatTypeChannel, "", string(id))]
+	if len(list) > 0 {
+		seq = list[len(list)-1].message.Seq + 1
+	}
+	// the segment is never exhausted, the seq continues from the stored messages after the channel is recreated
+	return seq, 1 << 62, nil
+}
+
+func (m *MemoryMessageStore) StoreChannelMessage(ch subscription.ChanID, msg *messages.ChatMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mid++
+	msg.Mid = m.mid
+	c := *msg
+	c.To = string(ch)
+	c.Status = messages.MessageStatusNormal
+	m.add(messages.ChatTypeChannel, memoryConversation(messages.ChatTypeChannel, "", c.To), &c)
+	return nil
+}
+
+func (m *MemoryMessageStore) add(chatType int32, conversation string, message *messages.ChatMessage) {
+	mm := &memoryMessage{message: message}
+	m.messages[memoryMessageKey(chatType, message.Mid)] = mm
+
+	list := append(m.conversations[conversation], mm)
+	// the channel messages may be stored out of the order of seq when published concurrently
+	for i := len(list) - 1; i > 0 && cursorOf(chatType, list[i-1]) > cursorOf(chatType, list[i]); i-- {
+		list[i-1], list[i] = list[i], list[i-1]
+	}
+	if m.limit > 0 && len(list) > m.limit {
+		for _, dropped := range list[:len(list)-m.limit] {
+			delete(m.messages, memoryMessageKey(chatType, dropped.message.Mid))
+		}
+		list = append([]*memoryMessage{}, list[len(list)-m.limit:]...)
+	}
+	m.conversations[conversation] = list
+}
+
+func (m *MemoryMessageStore) RecallMessage(recall *messages.Recall) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm, ok := m.messages[memoryMessageKey(recall.ChatType, recall.Mid)]
+	if !ok {
+		return errors.New(ErrMessageNotFound)
+	}
+	mm.message.Status = messages.MessageStatusRecalled
+	return nil
+}
+
+func (m *MemoryMessageStore) StoreMessageRevision(edit *messages.MessageEdit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm, ok := m.messages[memoryMessageKey(edit.ChatType, edit.Mid)]
+	if !ok {
+		return errors.New(ErrMessageNotFound)
+	}
+	mm.revision++
+	edit.Revision = mm.revision
+	mm.message.Content = edit.Content
+	return nil
+}
+
+func (m *MemoryMessageStore) PurgeMessage(chatType int32, mid int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoryMessageKey(chatType, mid)
+	mm, ok := m.messages[key]
+	if !ok {
+		return nil
+	}
+	delete(m.messages, key)
+	conversation := memoryConversation(chatType, mm.message.From, mm.message.To)
+	list := m.conversations[conversation]
+	for i, item := range list {
+		if item == mm {
+			m.conversations[conversation] = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *MemoryMessageStore) GetMessage(chatType int32, mid int64) (*messages.ChatMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	mm, ok := m.messages[memoryMessageKey(chatType, mid)]
+	if !ok {
+		return nil, errors.New(ErrMessageNotFound)
+	}
+	c := *mm.message
+	return &c, nil
+}
+
+func (m *MemoryMessageStore) ListMessages(query *HistoryQuery) ([]*messages.ChatMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := m.conversations[memoryConversation(query.ChatType, query.From, query.To)]
+	var result []*messages.ChatMessage
+	if query.After {
+		for _, mm := range list {
+			if len(result) >= query.Limit {
+				break
+			}
+			if visibleTo(mm.message, query.From) && cursorOf(query.ChatType, mm) > query.Cursor {
+				c := *mm.message
+				result = append(result, &c)
+			}
+		}
+		return result, nil
+	}
+	for i := len(list) - 1; i >= 0 && len(result) < query.Limit; i-- {
+		mm := list[i]
+		if !visibleTo(mm.message, query.From) || (query.Cursor > 0 && cursorOf(query.ChatType, mm) >= query.Cursor) {
+			continue
+		}
+		c := *mm.message
+		result = append(result, &c)
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
+
+// visibleTo returns true if the message is visible to uid in the history.
+func visibleTo(message *messages.ChatMessage, uid string) bool {
+	switch message.Status {
+	case messages.MessageStatusNormal:
+		return true
+	case messages.MessageStatusBlocked:
+		return message.From == uid
+	}
+	return false
+}
+
+func cursorOf(chatType int32, mm *memoryMessage) int64 {
+	if chatType == messages.ChatTypeChannel {
+		return mm.message.Seq
+	}
+	return mm.message.Mid
+}
+
+func memoryMessageKey(chatType int32, mid int64) string {
+	return strconv.Itoa(int(chatType)) + "_" + strconv.FormatInt(mid, 10)
+}
+
+// memoryConversation returns the key of the conversation, the participants of single chat are sorted.
+func memoryConversation(chatType int32, from string, to string) string {
+	if chatType == messages.ChatTypeChannel {
+		return "ch_" + to
+	}
+	uids := []string{from, to}
+	sort.Strings(uids)
+	return "single_" + strings.Join(uids, "_")
+}
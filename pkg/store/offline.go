@@ -0,0 +1,72 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"sync"
+)
+
+var _ OfflineStore = (*MemoryOfflineStore)(nil)
+
+// MemoryOfflineStore is an in-memory OfflineStore, the offline messages are lost after restart.
+type MemoryOfflineStore struct {
+	mu      sync.Mutex
+	cursors map[string]int64
+	// offline uid => offline messages ordered by cursor
+	offline map[string][]*messages.OfflineMessage
+}
+
+func NewMemoryOfflineStore() *MemoryOfflineStore {
+	return &MemoryOfflineStore{
+		cursors: map[string]int64{},
+		offline: map[string][]*messages.OfflineMessage{},
+	}
+}
+
+func (m *MemoryOfflineStore) AppendOffline(uid string, message *messages.ChatMessage) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cursors[uid]++
+	cursor := m.cursors[uid]
+	m.offline[uid] = append(m.offline[uid], &messages.OfflineMessage{Cursor: cursor, Message: message})
+	return cursor, nil
+}
+
+func (m *MemoryOfflineStore) RangeOffline(uid string, cursor int64, limit int) ([]*messages.OfflineMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []*messages.OfflineMessage
+	for _, om := range m.offline[uid] {
+		if len(result) >= limit {
+			break
+		}
+		if om.Cursor > cursor {
+			result = append(result, om)
+		}
+	}
+	return result, nil
+}
+
+func (m *MemoryOfflineStore) AckOffline(uid string, cursor int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := m.offline[uid]
+	i := 0
+	for i < len(list) && list[i].Cursor <= cursor {
+		i++
+	}
+	if i == len(list) {
+		delete(m.offline, uid)
+	} else {
+		m.offline[uid] = list[i:]
+	}
+	return int64(i), nil
+}
+
+func (m *MemoryOfflineStore) CountOffline(uid string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.offline[uid])), nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+	"strconv"
+	"time"
+)
+
+const (
+	// KeyRedisOfflineMsgPrefix the sorted set of offline messages, the set of the previous versions used "im:msg:offline:"
+	// and is left to expire in 2 days, the new prefix avoids WRONGTYPE error on the keys not expired yet after upgrade.
+	KeyRedisOfflineMsgPrefix    = "im:msg:offline_z:"
+	KeyRedisOfflineCursorPrefix = "im:msg:offline_cursor:"
+)
+
+var _ OfflineStore = (*RedisOfflineStore)(nil)
+
+// RedisOfflineStore is an OfflineStore backed by redis, the offline messages of a user are saved in a sorted set
+// scored by the cursor, the offline messages expire after ttl since the last message arrived, the cursor is kept.
+type RedisOfflineStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisOfflineStore(client *redis.Client, ttl time.Duration) *RedisOfflineStore {
+	return &RedisOfflineStore{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func (r *RedisOfflineStore) AppendOffline(uid string, message *messages.ChatMessage) (int64, error) {
+	cursor, err := r.client.Incr(KeyRedisOfflineCursorPrefix + uid).Result()
+	if err != nil {
+		return 0, err
+	}
+	bytes, err := messages.JsonCodec.Encode(&messages.OfflineMessage{Cursor: cursor, Message: message})
+	if err != nil {
+		return 0, err
+	}
+	key := KeyRedisOfflineMsgPrefix + uid
+	err = r.client.ZAdd(key, redis.Z{Score: float64(cursor), Member: string(bytes)}).Err()
+	if err != nil {
+		return 0, err
+	}
+	// TODO 2022-6-22 16:56:57 do not reset expire on new offline message arrived
+	// use fixed time segment save offline msg reset segment only.
+	// the cursor never expires, the cursor acked by client must not be reused after the offline messages expired.
+	r.client.Expire(key, r.ttl)
+	return cursor, nil
+}
+
+func (r *RedisOfflineStore) RangeOffline(uid string, cursor int64, limit int) ([]*messages.OfflineMessage, error) {
+	members, err := r.client.ZRangeByScore(KeyRedisOfflineMsgPrefix+uid, redis.ZRangeBy{
+		Min:   "(" + strconv.FormatInt(cursor, 10),
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	var result []*messages.OfflineMessage
+	for _, member := range members {
+		m := new(messages.OfflineMessage)
+		err = messages.JsonCodec.Decode([]byte(member), m)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func (r *RedisOfflineStore) AckOffline(uid string, cursor int64) (int64, error) {
+	return r.client.ZRemRangeByScore(KeyRedisOfflineMsgPrefix+uid, "-inf", strconv.FormatInt(cursor, 10)).Result()
+}
+
+func (r *RedisOfflineStore) CountOffline(uid string) (int64, error) {
+	return r.client.ZCard(KeyRedisOfflineMsgPrefix + uid).Result()
+}
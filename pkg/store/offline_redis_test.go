@@ -0,0 +1,64 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// newTestRedisClient returns the client of the local redis, the test is skipped if redis is not available.
+func newTestRedisClient(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   15,
+	})
+	if client.Ping().Err() != nil {
+		t.Skip("redis is not available on localhost:6379")
+	}
+	return client
+}
+
+func TestRedisOfflineStore(t *testing.T) {
+	client := newTestRedisClient(t)
+	uid := "test_offline_" + time.Now().Format("150405.000")
+	defer client.Del(KeyRedisOfflineMsgPrefix+uid, KeyRedisOfflineCursorPrefix+uid)
+
+	s := NewRedisOfflineStore(client, time.Minute)
+	for i := 1; i <= 5; i++ {
+		cursor, err := s.AppendOffline(uid, &messages.ChatMessage{Mid: int64(i)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), cursor)
+	}
+
+	list, err := s.RangeOffline(uid, 2, 2)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, int64(3), list[0].Cursor)
+	assert.Equal(t, int64(3), list[0].Message.Mid)
+
+	removed, err := s.AckOffline(uid, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+	count, err := s.CountOffline(uid)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// the messages expire, the cursor never expires
+	ttl, err := client.TTL(KeyRedisOfflineMsgPrefix + uid).Result()
+	assert.NoError(t, err)
+	assert.True(t, ttl > 0)
+	ttl, err = client.TTL(KeyRedisOfflineCursorPrefix + uid).Result()
+	assert.NoError(t, err)
+	assert.True(t, ttl < 0)
+
+	// the cursor keeps increasing after the messages expired
+	client.Del(KeyRedisOfflineMsgPrefix + uid)
+	cursor, err := s.AppendOffline(uid, &messages.ChatMessage{Mid: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), cursor)
+	list, err = s.RangeOffline(uid, 5, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+}
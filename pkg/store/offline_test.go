@@ -0,0 +1,42 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryOfflineStore_RangeOffline(t *testing.T) {
+	s := NewMemoryOfflineStore()
+	for i := 1; i <= 5; i++ {
+		cursor, err := s.AppendOffline("1", &messages.ChatMessage{Mid: int64(i)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), cursor)
+	}
+
+	list, err := s.RangeOffline("1", 2, 2)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, int64(3), list[0].Cursor)
+	assert.Equal(t, int64(4), list[1].Cursor)
+}
+
+func TestMemoryOfflineStore_AckOffline(t *testing.T) {
+	s := NewMemoryOfflineStore()
+	for i := 1; i <= 5; i++ {
+		_, _ = s.AppendOffline("1", &messages.ChatMessage{Mid: int64(i)})
+	}
+
+	removed, err := s.AckOffline("1", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+
+	count, err := s.CountOffline("1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// the cursor keeps increasing after ack
+	cursor, err := s.AppendOffline("1", &messages.ChatMessage{Mid: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), cursor)
+}
@@ -0,0 +1,288 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"sync"
+	"time"
+)
+
+var _ PresenceStore = (*MemoryPresenceStore)(nil)
+
+// PresenceDeviceTTL the device not refreshed in the duration is treated as offline, the clients refresh the device by
+// heartbeat.
+const PresenceDeviceTTL = time.Minute * 3
+
+// aggregatePresence returns the presence state of user from the states of devices, online if any device is online,
+// away if all devices are away, offline if no device.
+func aggregatePresence(states []int32) int32 {
+	state := messages.PresenceOffline
+	for _, s := range states {
+		if s == messages.PresenceOnline {
+			return messages.PresenceOnline
+		}
+		if s == messages.PresenceAway {
+			state = messages.PresenceAway
+		}
+	}
+	return state
+}
+
+type memoryDevice struct {
+	state    int32
+	activeAt int64
+}
+
+type memoryPresence struct {
+	devices    map[string]memoryDevice
+	lastSeen   int64
+	statusText string
+	privacy    int32
+	allowList  []string
+}
+
+// MemoryPresenceStore is an in-memory PresenceStore, the presence is only available in the current node.
+type MemoryPresenceStore struct {
+	mu       sync.RWMutex
+	presence map[string]*memoryPresence
+	// watching watcher => watched uid
+	watching map[string]map[string]struct{}
+	// watchers watched uid => watcher
+	watchers map[string]map[string]struct{}
+
+	deviceTTL time.Duration
+}
+
+func NewMemoryPresenceStore() *MemoryPresenceStore {
+	return &MemoryPresenceStore{
+		presence:  map[string]*memoryPresence{},
+		watching:  map[string]map[string]struct{}{},
+		watchers:  map[string]map[string]struct{}{},
+		deviceTTL: PresenceDeviceTTL,
+	}
+}
+
+// get returns the presence of uid, creates if not exist, the caller must hold the lock.
+func (m *MemoryPresenceStore) get(uid string) *memoryPresence {
+	p, ok := m.presence[uid]
+	if !ok {
+		p = &memoryPresence{devices: map[string]memoryDevice{}}
+		m.presence[uid] = p
+	}
+	return p
+}
+
+func (m *MemoryPresenceStore) SetDeviceState(uid string, device string, state int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.get(uid)
+	if state == messages.PresenceOffline {
+		if _, ok := p.devices[device]; ok {
+			delete(p.devices, device)
+			now := time.Now().Unix()
+			for k, d := range p.devices {
+				if deviceExpired(d.activeAt, now, m.deviceTTL) {
+					delete(p.devices, k)
+				}
+			}
+			if len(p.devices) == 0 {
+				p.lastSeen = now
+			}
+		}
+		return nil
+	}
+	p.devices[device] = memoryDevice{state: state, activeAt: time.Now().Unix()}
+	return nil
+}
+
+func (m *MemoryPresenceStore) RefreshDevice(uid string, device string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.presence[uid]
+	if !ok {
+		return false, nil
+	}
+	d, ok := p.devices[device]
+	now := time.Now().Unix()
+	if !ok || deviceExpired(d.activeAt, now, m.deviceTTL) {
+		return false, nil
+	}
+	d.activeAt = now
+	p.devices[device] = d
+	return true, nil
+}
+
+func (m *MemoryPresenceStore) GetPresence(uid string) (*messages.Presence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ret := &messages.Presence{Uid: uid}
+	p, ok := m.presence[uid]
+	if !ok {
+		return ret, nil
+	}
+	now := time.Now().Unix()
+	ret.LastSeen = p.lastSeen
+	states := make([]int32, 0, len(p.devices))
+	for _, d := range p.devices {
+		if deviceExpired(d.activeAt, now, m.deviceTTL) {
+			ret.LastSeen = maxInt64(ret.LastSeen, d.activeAt)
+			continue
+		}
+		states = append(states, d.state)
+	}
+	ret.State = aggregatePresence(states)
+	ret.Online = ret.State != messages.PresenceOffline
+	if ret.Online {
+		ret.LastSeen = p.lastSeen
+	}
+	ret.StatusText = p.statusText
+	return ret, nil
+}
+
+func (m *MemoryPresenceStore) ExpireDevices() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expired []string
+	now := time.Now().Unix()
+	for uid, p := range m.presence {
+		if len(p.devices) == 0 {
+			continue
+		}
+		var lastSeen int64
+		for k, d := range p.devices {
+			if deviceExpired(d.activeAt, now, m.deviceTTL) {
+				lastSeen = maxInt64(lastSeen, d.activeAt)
+				delete(p.devices, k)
+			}
+		}
+		if len(p.devices) == 0 {
+			p.lastSeen = maxInt64(p.lastSeen, lastSeen)
+			expired = append(expired, uid)
+		}
+	}
+	return expired, nil
+}
+
+func (m *MemoryPresenceStore) SetStatusText(uid string, text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(uid).statusText = text
+	return nil
+}
+
+func (m *MemoryPresenceStore) SetPrivacy(uid string, privacy int32, allowList []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.get(uid)
+	p.privacy = privacy
+	p.allowList = append([]string{}, allowList...)
+	return nil
+}
+
+func (m *MemoryPresenceStore) GetPrivacy(uid string) (int32, []string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.presence[uid]
+	if !ok {
+		return messages.PresencePrivacyEveryone, nil, nil
+	}
+	return p.privacy, append([]string{}, p.allowList...), nil
+}
+
+func (m *MemoryPresenceStore) AddWatching(watcher string, uids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	watching, ok := m.watching[watcher]
+	if !ok {
+		watching = map[string]struct{}{}
+		m.watching[watcher] = watching
+	}
+	for _, uid := range uids {
+		watching[uid] = struct{}{}
+		watchers, ok := m.watchers[uid]
+		if !ok {
+			watchers = map[string]struct{}{}
+			m.watchers[uid] = watchers
+		}
+		watchers[watcher] = struct{}{}
+	}
+	return nil
+}
+
+func (m *MemoryPresenceStore) RemoveWatching(watcher string, uids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	watching := m.watching[watcher]
+	for _, uid := range uids {
+		delete(watching, uid)
+		if watchers, ok := m.watchers[uid]; ok {
+			delete(watchers, watcher)
+			if len(watchers) == 0 {
+				delete(m.watchers, uid)
+			}
+		}
+	}
+	if len(watching) == 0 {
+		delete(m.watching, watcher)
+	}
+	return nil
+}
+
+func (m *MemoryPresenceStore) GetWatching(watcher string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return setKeys(m.watching[watcher]), nil
+}
+
+func (m *MemoryPresenceStore) GetWatchers(uid string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return setKeys(m.watchers[uid]), nil
+}
+
+// deviceExpired returns true if the device last active at activeAt is expired at now.
+func deviceExpired(activeAt int64, now int64, ttl time.Duration) bool {
+	return now-activeAt > int64(ttl/time.Second)
+}
+
+func maxInt64(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func setKeys(set map[string]struct{}) []string {
+	ret := make([]string, 0, len(set))
+	for k := range set {
+		ret = append(ret, k)
+	}
+	return ret
+}
+
+// CanSeePresence returns true if the viewer can see the presence of uid according to the privacy of uid.
+func CanSeePresence(s PresenceStore, viewer string, uid string) (bool, error) {
+	if viewer == uid {
+		return true, nil
+	}
+	privacy, allowList, err := s.GetPrivacy(uid)
+	if err != nil {
+		return false, err
+	}
+	switch privacy {
+	case messages.PresencePrivacyEveryone:
+		return true, nil
+	case messages.PresencePrivacyAllowList:
+		for _, u := range allowList {
+			if u == viewer {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
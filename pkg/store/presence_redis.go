@@ -0,0 +1,311 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/glide-im/glide/pkg/logger"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KeyRedisPresencePrefix         = "im:presence:"
+	KeyRedisPresenceDevicePrefix   = "im:presence:dev:"
+	KeyRedisPresenceAllowPrefix    = "im:presence:allow:"
+	KeyRedisPresenceWatchingPrefix = "im:presence:watching:"
+	KeyRedisPresenceWatchersPrefix = "im:presence:watchers:"
+	// KeyRedisPresenceActive the sorted set of the users with devices, scored by the latest active time of the devices
+	KeyRedisPresenceActive = "im:presence:active"
+
+	// KeyRedisPresenceChannel the pub/sub channel of the presence changes
+	KeyRedisPresenceChannel = "im:presence:events"
+)
+
+// presenceExpireLimit the max count of users checked in one ExpireDevices
+const presenceExpireLimit = 500
+
+// removeDeviceScript removes the device and the expired devices of user, updates the last seen time when the last
+// device removed.
+// KEYS: device hash, presence hash, active set; ARGV: device, now, ttl seconds, uid
+var removeDeviceScript = redis.NewScript(`
+if redis.call('HDEL', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+local devices = redis.call('HGETALL', KEYS[1])
+for i = 1, #devices, 2 do
+	local activeAt = string.match(devices[i + 1], ':(%d+)$')
+	if not activeAt or tonumber(ARGV[2]) - tonumber(activeAt) > tonumber(ARGV[3]) then
+		redis.call('HDEL', KEYS[1], devices[i])
+	end
+end
+if redis.call('HLEN', KEYS[1]) == 0 then
+	redis.call('HSET', KEYS[2], 'last_seen', ARGV[2])
+	redis.call('ZREM', KEYS[3], ARGV[4])
+end
+return 1
+`)
+
+// expireDevicesScript removes the expired devices of user, returns 1 if the last device expired and updates the last
+// seen time to the latest active time, the hash may be expired already.
+// KEYS: device hash, presence hash, active set; ARGV: uid, now, ttl seconds
+var expireDevicesScript = redis.NewScript(`
+local lastSeen = redis.call('ZSCORE', KEYS[3], ARGV[1])
+if not lastSeen then
+	return 0
+end
+local latest = 0
+local devices = redis.call('HGETALL', KEYS[1])
+for i = 1, #devices, 2 do
+	local activeAt = tonumber(string.match(devices[i + 1], ':(%d+)$') or '0')
+	if tonumber(ARGV[2]) - activeAt > tonumber(ARGV[3]) then
+		redis.call('HDEL', KEYS[1], devices[i])
+	elseif activeAt > latest then
+		latest = activeAt
+	end
+end
+if latest > 0 then
+	redis.call('ZADD', KEYS[3], latest, ARGV[1])
+	return 0
+end
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('HSET', KEYS[2], 'last_seen', lastSeen)
+return 1
+`)
+
+// refreshDeviceScript refreshes the active time of the device if it is not expired, and extends the expiry of the
+// device hash.
+// KEYS: device hash, active set; ARGV: device, now, ttl seconds, uid
+var refreshDeviceScript = redis.NewScript(`
+local v = redis.call('HGET', KEYS[1], ARGV[1])
+if not v then
+	return 0
+end
+local state, activeAt = string.match(v, '^(%d+):(%d+)$')
+if not state or tonumber(ARGV[2]) - tonumber(activeAt) > tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], state .. ':' .. ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[4])
+return 1
+`)
+
+var _ PresenceStore = (*RedisPresenceStore)(nil)
+var _ PresencePublisher = (*RedisPresenceStore)(nil)
+
+// RedisPresenceStore is a PresenceStore backed by redis, shared by all gateway nodes. The states of devices are saved
+// in a hash of each user with the active time, the devices not refreshed in PresenceDeviceTTL are treated as offline,
+// the hash is expired after all devices expired, the users with devices are indexed by the latest active time to find
+// the users whose devices expired without heartbeat. The watching relations are saved in two sets of both directions, and
+// the presence changes are published through the redis pub/sub.
+type RedisPresenceStore struct {
+	client    *redis.Client
+	deviceTTL time.Duration
+}
+
+func NewRedisPresenceStore(client *redis.Client) *RedisPresenceStore {
+	return &RedisPresenceStore{
+		client:    client,
+		deviceTTL: PresenceDeviceTTL,
+	}
+}
+
+func (r *RedisPresenceStore) SetDeviceState(uid string, device string, state int32) error {
+	if state == messages.PresenceOffline {
+		keys := []string{KeyRedisPresenceDevicePrefix + uid, KeyRedisPresencePrefix + uid, KeyRedisPresenceActive}
+		ttl := int64(r.deviceTTL / time.Second)
+		return removeDeviceScript.Run(r.client, keys, device, time.Now().Unix(), ttl, uid).Err()
+	}
+	key := KeyRedisPresenceDevicePrefix + uid
+	now := time.Now().Unix()
+	pipe := r.client.TxPipeline()
+	pipe.HSet(key, device, strconv.Itoa(int(state))+":"+strconv.FormatInt(now, 10))
+	pipe.Expire(key, r.deviceTTL)
+	pipe.ZAdd(KeyRedisPresenceActive, redis.Z{Score: float64(now), Member: uid})
+	_, err := pipe.Exec()
+	return err
+}
+
+func (r *RedisPresenceStore) RefreshDevice(uid string, device string) (bool, error) {
+	keys := []string{KeyRedisPresenceDevicePrefix + uid, KeyRedisPresenceActive}
+	ttl := int64(r.deviceTTL / time.Second)
+	n, err := refreshDeviceScript.Run(r.client, keys, device, time.Now().Unix(), ttl, uid).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisPresenceStore) GetPresence(uid string) (*messages.Presence, error) {
+	pipe := r.client.Pipeline()
+	devices := pipe.HVals(KeyRedisPresenceDevicePrefix + uid)
+	info := pipe.HMGet(KeyRedisPresencePrefix+uid, "last_seen", "status_text")
+	_, err := pipe.Exec()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	ret := &messages.Presence{Uid: uid}
+	vals := info.Val()
+	if s, ok := vals[0].(string); ok {
+		ret.LastSeen, _ = strconv.ParseInt(s, 10, 64)
+	}
+	lastSeen := ret.LastSeen
+	now := time.Now().Unix()
+	states := make([]int32, 0, len(devices.Val()))
+	for _, v := range devices.Val() {
+		state, activeAt := parseDeviceValue(v)
+		if deviceExpired(activeAt, now, r.deviceTTL) {
+			lastSeen = maxInt64(lastSeen, activeAt)
+			continue
+		}
+		states = append(states, state)
+	}
+	ret.State = aggregatePresence(states)
+	ret.Online = ret.State != messages.PresenceOffline
+	if !ret.Online {
+		ret.LastSeen = lastSeen
+	}
+	if s, ok := vals[1].(string); ok {
+		ret.StatusText = s
+	}
+	return ret, nil
+}
+
+func (r *RedisPresenceStore) ExpireDevices() ([]string, error) {
+	now := time.Now().Unix()
+	ttl := int64(r.deviceTTL / time.Second)
+	uids, err := r.client.ZRangeByScore(KeyRedisPresenceActive, redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   "(" + strconv.FormatInt(now-ttl, 10),
+		Count: presenceExpireLimit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	var expired []string
+	for _, uid := range uids {
+		keys := []string{KeyRedisPresenceDevicePrefix + uid, KeyRedisPresencePrefix + uid, KeyRedisPresenceActive}
+		n, err := expireDevicesScript.Run(r.client, keys, uid, now, ttl).Int64()
+		if err != nil {
+			return expired, err
+		}
+		if n == 1 {
+			expired = append(expired, uid)
+		}
+	}
+	return expired, nil
+}
+
+func (r *RedisPresenceStore) SetStatusText(uid string, text string) error {
+	return r.client.HSet(KeyRedisPresencePrefix+uid, "status_text", text).Err()
+}
+
+func (r *RedisPresenceStore) SetPrivacy(uid string, privacy int32, allowList []string) error {
+	pipe := r.client.TxPipeline()
+	pipe.HSet(KeyRedisPresencePrefix+uid, "privacy", privacy)
+	pipe.Del(KeyRedisPresenceAllowPrefix + uid)
+	if len(allowList) > 0 {
+		members := make([]interface{}, 0, len(allowList))
+		for _, u := range allowList {
+			members = append(members, u)
+		}
+		pipe.SAdd(KeyRedisPresenceAllowPrefix+uid, members...)
+	}
+	_, err := pipe.Exec()
+	return err
+}
+
+func (r *RedisPresenceStore) GetPrivacy(uid string) (int32, []string, error) {
+	pipe := r.client.Pipeline()
+	privacy := pipe.HGet(KeyRedisPresencePrefix+uid, "privacy")
+	allow := pipe.SMembers(KeyRedisPresenceAllowPrefix + uid)
+	_, err := pipe.Exec()
+	if err != nil && err != redis.Nil {
+		return 0, nil, err
+	}
+	p, _ := strconv.ParseInt(privacy.Val(), 10, 32)
+	return int32(p), allow.Val(), nil
+}
+
+func (r *RedisPresenceStore) AddWatching(watcher string, uids ...string) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	pipe := r.client.TxPipeline()
+	members := make([]interface{}, 0, len(uids))
+	for _, uid := range uids {
+		members = append(members, uid)
+		pipe.SAdd(KeyRedisPresenceWatchersPrefix+uid, watcher)
+	}
+	pipe.SAdd(KeyRedisPresenceWatchingPrefix+watcher, members...)
+	_, err := pipe.Exec()
+	return err
+}
+
+func (r *RedisPresenceStore) RemoveWatching(watcher string, uids ...string) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	pipe := r.client.TxPipeline()
+	members := make([]interface{}, 0, len(uids))
+	for _, uid := range uids {
+		members = append(members, uid)
+		pipe.SRem(KeyRedisPresenceWatchersPrefix+uid, watcher)
+	}
+	pipe.SRem(KeyRedisPresenceWatchingPrefix+watcher, members...)
+	_, err := pipe.Exec()
+	return err
+}
+
+func (r *RedisPresenceStore) GetWatching(watcher string) ([]string, error) {
+	return r.client.SMembers(KeyRedisPresenceWatchingPrefix + watcher).Result()
+}
+
+func (r *RedisPresenceStore) GetWatchers(uid string) ([]string, error) {
+	return r.client.SMembers(KeyRedisPresenceWatchersPrefix + uid).Result()
+}
+
+func (r *RedisPresenceStore) PublishPresence(e *PresenceEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(KeyRedisPresenceChannel, string(b)).Err()
+}
+
+func (r *RedisPresenceStore) SubscribePresence(fn func(e *PresenceEvent)) error {
+	ps := r.client.Subscribe(KeyRedisPresenceChannel)
+	// wait for the subscription confirmed, the events published before are not received
+	_, err := ps.Receive()
+	if err != nil {
+		_ = ps.Close()
+		return err
+	}
+	go func() {
+		for m := range ps.Channel() {
+			e := &PresenceEvent{}
+			err := json.Unmarshal([]byte(m.Payload), e)
+			if err != nil || e.Presence == nil {
+				logger.E("invalid presence event %s", m.Payload)
+				continue
+			}
+			fn(e)
+		}
+	}()
+	return nil
+}
+
+// parseDeviceValue parses the "state:activeAt" value of the device, the value without the active time is expired.
+func parseDeviceValue(v string) (int32, int64) {
+	var activeAt int64
+	i := strings.IndexByte(v, ':')
+	if i >= 0 {
+		activeAt, _ = strconv.ParseInt(v[i+1:], 10, 64)
+		v = v[:i]
+	}
+	state, _ := strconv.ParseInt(v, 10, 32)
+	return int32(state), activeAt
+}
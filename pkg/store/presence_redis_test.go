@@ -0,0 +1,87 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRedisPresenceStore_RefreshDevice(t *testing.T) {
+	client := newTestRedisClient(t)
+	uid := "test_presence_" + time.Now().Format("150405.000")
+	defer client.Del(KeyRedisPresenceDevicePrefix+uid, KeyRedisPresencePrefix+uid)
+
+	s := NewRedisPresenceStore(client)
+	_ = s.SetDeviceState(uid, "a", messages.PresenceAway)
+	ok, err := s.RefreshDevice(uid, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ttl := client.TTL(KeyRedisPresenceDevicePrefix + uid).Val()
+	assert.True(t, ttl > 0 && ttl <= PresenceDeviceTTL)
+
+	// the device not refreshed in time is offline, and can not be refreshed any more
+	activeAt := time.Now().Unix() - int64(PresenceDeviceTTL/time.Second) - 1
+	client.HSet(KeyRedisPresenceDevicePrefix+uid, "a", "2:"+strconv.FormatInt(activeAt, 10))
+	p, err := s.GetPresence(uid)
+	assert.NoError(t, err)
+	assert.Equal(t, messages.PresenceOffline, p.State)
+	assert.Equal(t, activeAt, p.LastSeen)
+	ok, _ = s.RefreshDevice(uid, "a")
+	assert.False(t, ok)
+
+	// the expired devices are removed with the last device
+	_ = s.SetDeviceState(uid, "b", messages.PresenceOnline)
+	_ = s.SetDeviceState(uid, "b", messages.PresenceOffline)
+	assert.Zero(t, client.HLen(KeyRedisPresenceDevicePrefix+uid).Val())
+}
+
+func TestRedisPresenceStore_ExpireDevices(t *testing.T) {
+	client := newTestRedisClient(t)
+	uid := "test_presence_" + time.Now().Format("150405.000")
+	defer client.Del(KeyRedisPresenceDevicePrefix+uid, KeyRedisPresencePrefix+uid)
+	defer client.ZRem(KeyRedisPresenceActive, uid)
+
+	s := NewRedisPresenceStore(client)
+	_ = s.SetDeviceState(uid, "a", messages.PresenceOnline)
+	expired, err := s.ExpireDevices()
+	assert.NoError(t, err)
+	assert.NotContains(t, expired, uid)
+
+	// the device hash is expired by redis before the user is expired
+	activeAt := time.Now().Unix() - int64(PresenceDeviceTTL/time.Second) - 1
+	client.Del(KeyRedisPresenceDevicePrefix + uid)
+	client.ZAdd(KeyRedisPresenceActive, redis.Z{Score: float64(activeAt), Member: uid})
+	expired, err = s.ExpireDevices()
+	assert.NoError(t, err)
+	assert.Contains(t, expired, uid)
+	p, _ := s.GetPresence(uid)
+	assert.Equal(t, messages.PresenceOffline, p.State)
+	assert.Equal(t, activeAt, p.LastSeen)
+
+	expired, _ = s.ExpireDevices()
+	assert.NotContains(t, expired, uid)
+}
+
+func TestRedisPresenceStore_PublishPresence(t *testing.T) {
+	client := newTestRedisClient(t)
+	s := NewRedisPresenceStore(client)
+
+	received := make(chan *PresenceEvent, 1)
+	err := s.SubscribePresence(func(e *PresenceEvent) {
+		received <- e
+	})
+	assert.NoError(t, err)
+
+	err = s.PublishPresence(&PresenceEvent{Presence: &messages.Presence{Uid: "1", State: messages.PresenceOnline}})
+	assert.NoError(t, err)
+	select {
+	case e := <-received:
+		assert.Equal(t, "1", e.Presence.Uid)
+		assert.Equal(t, messages.PresenceOnline, e.Presence.State)
+	case <-time.After(time.Second * 3):
+		t.Fatal("presence event not received")
+	}
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryPresenceStore_GetPresence(t *testing.T) {
+	s := NewMemoryPresenceStore()
+
+	_ = s.SetDeviceState("1", "a", messages.PresenceAway)
+	_ = s.SetDeviceState("1", "b", messages.PresenceOnline)
+	p, err := s.GetPresence("1")
+	assert.NoError(t, err)
+	assert.Equal(t, messages.PresenceOnline, p.State)
+
+	// online if any device is online, away if all devices are away
+	_ = s.SetDeviceState("1", "b", messages.PresenceOffline)
+	p, _ = s.GetPresence("1")
+	assert.Equal(t, messages.PresenceAway, p.State)
+	assert.Zero(t, p.LastSeen)
+
+	_ = s.SetDeviceState("1", "a", messages.PresenceOffline)
+	p, _ = s.GetPresence("1")
+	assert.Equal(t, messages.PresenceOffline, p.State)
+	assert.NotZero(t, p.LastSeen)
+
+	_ = s.SetPrivacy("1", messages.PresencePrivacyAllowList, []string{"2"})
+	visible, _ := CanSeePresence(s, "2", "1")
+	assert.True(t, visible)
+	visible, _ = CanSeePresence(s, "3", "1")
+	assert.False(t, visible)
+}
+
+func TestMemoryPresenceStore_RemoveWatching(t *testing.T) {
+	s := NewMemoryPresenceStore()
+
+	_ = s.AddWatching("1", "2", "3")
+	_ = s.AddWatching("4", "2")
+	watchers, _ := s.GetWatchers("2")
+	assert.ElementsMatch(t, []string{"1", "4"}, watchers)
+
+	// both directions are removed
+	_ = s.RemoveWatching("1", "2", "3")
+	watching, _ := s.GetWatching("1")
+	assert.Empty(t, watching)
+	watchers, _ = s.GetWatchers("2")
+	assert.Equal(t, []string{"4"}, watchers)
+	watchers, _ = s.GetWatchers("3")
+	assert.Empty(t, watchers)
+	assert.NotContains(t, s.watchers, "3")
+	assert.NotContains(t, s.watching, "1")
+}
+
+func TestMemoryPresenceStore_RefreshDevice(t *testing.T) {
+	s := NewMemoryPresenceStore()
+
+	ok, err := s.RefreshDevice("1", "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_ = s.SetDeviceState("1", "a", messages.PresenceAway)
+	ok, _ = s.RefreshDevice("1", "a")
+	assert.True(t, ok)
+	p, _ := s.GetPresence("1")
+	assert.Equal(t, messages.PresenceAway, p.State)
+
+	// the device not refreshed in time is offline, and can not be refreshed any more
+	activeAt := time.Now().Unix() - int64(PresenceDeviceTTL/time.Second) - 1
+	s.presence["1"].devices["a"] = memoryDevice{state: messages.PresenceAway, activeAt: activeAt}
+	p, _ = s.GetPresence("1")
+	assert.Equal(t, messages.PresenceOffline, p.State)
+	assert.False(t, p.Online)
+	assert.Equal(t, activeAt, p.LastSeen)
+	ok, _ = s.RefreshDevice("1", "a")
+	assert.False(t, ok)
+
+	// the expired devices are removed with the last device
+	_ = s.SetDeviceState("1", "b", messages.PresenceOnline)
+	_ = s.SetDeviceState("1", "b", messages.PresenceOffline)
+	assert.Empty(t, s.presence["1"].devices)
+	p, _ = s.GetPresence("1")
+	assert.Greater(t, p.LastSeen, activeAt)
+}
+
+func TestMemoryPresenceStore_ExpireDevices(t *testing.T) {
+	s := NewMemoryPresenceStore()
+	_ = s.SetDeviceState("1", "a", messages.PresenceOnline)
+	_ = s.SetDeviceState("1", "b", messages.PresenceOnline)
+	_ = s.SetDeviceState("2", "a", messages.PresenceOnline)
+
+	expired, err := s.ExpireDevices()
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+
+	// the user is expired after the last device expired, and returned only once
+	activeAt := time.Now().Unix() - int64(PresenceDeviceTTL/time.Second) - 1
+	s.presence["1"].devices["a"] = memoryDevice{state: messages.PresenceOnline, activeAt: activeAt}
+	s.presence["2"].devices["a"] = memoryDevice{state: messages.PresenceOnline, activeAt: activeAt}
+	expired, _ = s.ExpireDevices()
+	assert.Equal(t, []string{"2"}, expired)
+	assert.Len(t, s.presence["1"].devices, 1)
+	p, _ := s.GetPresence("2")
+	assert.Equal(t, messages.PresenceOffline, p.State)
+	assert.Equal(t, activeAt, p.LastSeen)
+
+	expired, _ = s.ExpireDevices()
+	assert.Empty(t, expired)
+}
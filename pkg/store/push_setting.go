@@ -0,0 +1,34 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"sync"
+)
+
+var _ PushSettingStore = (*MemoryPushSettingStore)(nil)
+
+// MemoryPushSettingStore is an in-memory PushSettingStore.
+type MemoryPushSettingStore struct {
+	mu       sync.RWMutex
+	settings map[string]messages.PushSetting
+}
+
+func NewMemoryPushSettingStore() *MemoryPushSettingStore {
+	return &MemoryPushSettingStore{
+		settings: map[string]messages.PushSetting{},
+	}
+}
+
+func (m *MemoryPushSettingStore) SetPushSetting(uid string, s *messages.PushSetting) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[uid] = *s
+	return nil
+}
+
+func (m *MemoryPushSettingStore) GetPushSetting(uid string) (*messages.PushSetting, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s := m.settings[uid]
+	return &s, nil
+}
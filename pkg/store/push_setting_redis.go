@@ -0,0 +1,45 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+)
+
+const KeyRedisPushSetting = "im:push:setting"
+
+var _ PushSettingStore = (*RedisPushSettingStore)(nil)
+
+// RedisPushSettingStore is a PushSettingStore backed by redis, the settings are saved in a hash by uid.
+type RedisPushSettingStore struct {
+	client *redis.Client
+}
+
+func NewRedisPushSettingStore(client *redis.Client) *RedisPushSettingStore {
+	return &RedisPushSettingStore{
+		client: client,
+	}
+}
+
+func (r *RedisPushSettingStore) SetPushSetting(uid string, s *messages.PushSetting) error {
+	bytes, err := messages.JsonCodec.Encode(s)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(KeyRedisPushSetting, uid, string(bytes)).Err()
+}
+
+func (r *RedisPushSettingStore) GetPushSetting(uid string) (*messages.PushSetting, error) {
+	s := new(messages.PushSetting)
+	v, err := r.client.HGet(KeyRedisPushSetting, uid).Result()
+	if err == redis.Nil {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = messages.JsonCodec.Decode([]byte(v), s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/messages"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var _ ReactionStore = (*MemoryReactionStore)(nil)
+
+// messageReactions the users of each emoji in reacted order, and the emojis of each user.
+type messageReactions struct {
+	users  map[string][]string
+	emojis map[string][]string
+}
+
+// MemoryReactionStore is an in-memory ReactionStore.
+type MemoryReactionStore struct {
+	mu        sync.RWMutex
+	reactions map[string]*messageReactions
+}
+
+func NewMemoryReactionStore() *MemoryReactionStore {
+	return &MemoryReactionStore{
+		reactions: map[string]*messageReactions{},
+	}
+}
+
+// reactionKey returns the key of the reactions of the message.
+func reactionKey(chatType int32, mid int64) string {
+	return strconv.Itoa(int(chatType)) + "_" + strconv.FormatInt(mid, 10)
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MemoryReactionStore) AddReaction(chatType int32, mid int64, uid string, emoji string, limit int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := reactionKey(chatType, mid)
+	r, ok := m.reactions[key]
+	if !ok {
+		r = &messageReactions{
+			users:  map[string][]string{},
+			emojis: map[string][]string{},
+		}
+		m.reactions[key] = r
+	}
+	if indexOf(r.emojis[uid], emoji) >= 0 {
+		return false, nil
+	}
+	if limit > 0 && len(r.emojis[uid]) >= limit {
+		return false, errors.New(ErrReactionLimit)
+	}
+	r.emojis[uid] = append(r.emojis[uid], emoji)
+	r.users[emoji] = append(r.users[emoji], uid)
+	return true, nil
+}
+
+func (m *MemoryReactionStore) RemoveReaction(chatType int32, mid int64, uid string, emoji string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := reactionKey(chatType, mid)
+	r, ok := m.reactions[key]
+	if !ok {
+		return false, nil
+	}
+	i := indexOf(r.emojis[uid], emoji)
+	if i < 0 {
+		return false, nil
+	}
+	r.emojis[uid] = append(r.emojis[uid][:i], r.emojis[uid][i+1:]...)
+	if len(r.emojis[uid]) == 0 {
+		delete(r.emojis, uid)
+	}
+	users := r.users[emoji]
+	if j := indexOf(users, uid); j >= 0 {
+		r.users[emoji] = append(users[:j], users[j+1:]...)
+	}
+	if len(r.users[emoji]) == 0 {
+		delete(r.users, emoji)
+	}
+	if len(r.emojis) == 0 {
+		delete(m.reactions, key)
+	}
+	return true, nil
+}
+
+func (m *MemoryReactionStore) GetReactions(chatType int32, users int, mids ...int64) (map[int64][]*messages.ReactionSummary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := map[int64][]*messages.ReactionSummary{}
+	for _, mid := range mids {
+		r, ok := m.reactions[reactionKey(chatType, mid)]
+		if !ok {
+			continue
+		}
+		var summaries []*messages.ReactionSummary
+		for emoji, uids := range r.users {
+			n := len(uids)
+			if n > users {
+				n = users
+			}
+			summaries = append(summaries, &messages.ReactionSummary{
+				Emoji: emoji,
+				Count: int64(len(uids)),
+				Uids:  append([]string{}, uids[:n]...),
+			})
+		}
+		sortReactions(summaries)
+		result[mid] = summaries
+	}
+	return result, nil
+}
+
+// sortReactions sorts the summaries by count descending, and by emoji for the same count.
+func sortReactions(summaries []*messages.ReactionSummary) {
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].Emoji < summaries[j].Emoji
+	})
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"errors"
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+	"strconv"
+	"time"
+)
+
+const KeyRedisReactionPrefix = "im:reaction:"
+
+// addReactionScript adds the reaction if the user does not reach the limit, returns 1 if added, 0 if reacted before,
+// -1 if the limit reached.
+// KEYS: count hash, users sorted set of the emoji, emojis set of the user; ARGV: emoji, uid, limit, score
+var addReactionScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[3], ARGV[1]) == 1 then
+	return 0
+end
+local limit = tonumber(ARGV[3])
+if limit > 0 and redis.call('SCARD', KEYS[3]) >= limit then
+	return -1
+end
+redis.call('SADD', KEYS[3], ARGV[1])
+redis.call('ZADD', KEYS[2], ARGV[4], ARGV[2])
+redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+return 1
+`)
+
+// removeReactionScript removes the reaction, returns 1 if removed, 0 if not reacted.
+// KEYS: count hash, users sorted set of the emoji, emojis set of the user; ARGV: emoji, uid
+var removeReactionScript = redis.NewScript(`
+if redis.call('SREM', KEYS[3], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[2])
+if redis.call('HINCRBY', KEYS[1], ARGV[1], -1) <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+var _ ReactionStore = (*RedisReactionStore)(nil)
+
+// RedisReactionStore is a ReactionStore backed by redis, the counts of each message are saved in a hash by emoji,
+// the users of each emoji are saved in a sorted set scored by the react time, and the emojis of each user are saved in
+// a set.
+type RedisReactionStore struct {
+	client *redis.Client
+}
+
+func NewRedisReactionStore(client *redis.Client) *RedisReactionStore {
+	return &RedisReactionStore{
+		client: client,
+	}
+}
+
+func reactionKeys(chatType int32, mid int64, uid string, emoji string) []string {
+	base := KeyRedisReactionPrefix + reactionKey(chatType, mid)
+	return []string{base + ":count", base + ":emoji:" + emoji, base + ":user:" + uid}
+}
+
+func (r *RedisReactionStore) AddReaction(chatType int32, mid int64, uid string, emoji string, limit int) (bool, error) {
+	keys := reactionKeys(chatType, mid, uid, emoji)
+	score := time.Now().UnixNano() / int64(time.Microsecond)
+	n, err := addReactionScript.Run(r.client, keys, emoji, uid, limit, score).Int()
+	if err != nil {
+		return false, err
+	}
+	if n < 0 {
+		return false, errors.New(ErrReactionLimit)
+	}
+	return n == 1, nil
+}
+
+func (r *RedisReactionStore) RemoveReaction(chatType int32, mid int64, uid string, emoji string) (bool, error) {
+	keys := reactionKeys(chatType, mid, uid, emoji)
+	n, err := removeReactionScript.Run(r.client, keys, emoji, uid).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisReactionStore) GetReactions(chatType int32, users int, mids ...int64) (map[int64][]*messages.ReactionSummary, error) {
+	result := map[int64][]*messages.ReactionSummary{}
+	if len(mids) == 0 {
+		return result, nil
+	}
+	pipe := r.client.Pipeline()
+	counts := make([]*redis.StringStringMapCmd, len(mids))
+	for i, mid := range mids {
+		counts[i] = pipe.HGetAll(KeyRedisReactionPrefix + reactionKey(chatType, mid) + ":count")
+	}
+	_, err := pipe.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe = r.client.Pipeline()
+	var uids []*redis.StringSliceCmd
+	var summaries []*messages.ReactionSummary
+	for i, mid := range mids {
+		for emoji, count := range counts[i].Val() {
+			c, _ := strconv.ParseInt(count, 10, 64)
+			if c <= 0 {
+				continue
+			}
+			s := &messages.ReactionSummary{Emoji: emoji, Count: c}
+			result[mid] = append(result[mid], s)
+			if users > 0 {
+				summaries = append(summaries, s)
+				uids = append(uids, pipe.ZRange(reactionKeys(chatType, mid, "", emoji)[1], 0, int64(users-1)))
+			}
+		}
+	}
+	if len(uids) > 0 {
+		_, err = pipe.Exec()
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range summaries {
+			s.Uids = uids[i].Val()
+		}
+	}
+	for _, list := range result {
+		sortReactions(list)
+	}
+	return result, nil
+}
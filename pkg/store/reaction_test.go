@@ -0,0 +1,39 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryReactionStore_AddReaction(t *testing.T) {
+	s := NewMemoryReactionStore()
+
+	ok, err := s.AddReaction(1, 1, "a", "👍", 2)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = s.AddReaction(1, 1, "a", "👍", 2)
+	assert.False(t, ok)
+	_, _ = s.AddReaction(1, 1, "a", "❤", 2)
+	_, err = s.AddReaction(1, 1, "a", "😂", 2)
+	assert.True(t, IsReactionLimit(err))
+	_, _ = s.AddReaction(1, 1, "b", "❤", 2)
+	_, _ = s.AddReaction(2, 1, "b", "❤", 2)
+
+	reactions, err := s.GetReactions(1, 1, 1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, reactions[1], 2)
+	assert.Equal(t, "❤", reactions[1][0].Emoji)
+	assert.Equal(t, int64(2), reactions[1][0].Count)
+	assert.Equal(t, []string{"a"}, reactions[1][0].Uids)
+	assert.Empty(t, reactions[2])
+
+	ok, _ = s.RemoveReaction(1, 1, "a", "❤")
+	assert.True(t, ok)
+	ok, _ = s.RemoveReaction(1, 1, "a", "❤")
+	assert.False(t, ok)
+	_, err = s.AddReaction(1, 1, "a", "😂", 2)
+	assert.NoError(t, err)
+
+	reactions, _ = s.GetReactions(1, 10, 1)
+	assert.Equal(t, []string{"b"}, reactions[1][0].Uids)
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"sync"
+)
+
+var _ ReadCursorStore = (*MemoryReadCursorStore)(nil)
+
+// MemoryReadCursorStore is an in-memory ReadCursorStore.
+type MemoryReadCursorStore struct {
+	mu sync.RWMutex
+	// cursors conversation => uid => seq
+	cursors map[string]map[string]int64
+}
+
+func NewMemoryReadCursorStore() *MemoryReadCursorStore {
+	return &MemoryReadCursorStore{
+		cursors: map[string]map[string]int64{},
+	}
+}
+
+func (m *MemoryReadCursorStore) UpdateReadCursor(uid string, conversation string, seq int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.cursors[conversation]
+	if !ok {
+		c = map[string]int64{}
+		m.cursors[conversation] = c
+	}
+	if c[uid] >= seq {
+		return false, nil
+	}
+	c[uid] = seq
+	return true, nil
+}
+
+func (m *MemoryReadCursorStore) GetReadCursor(uid string, conversation string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cursors[conversation][uid], nil
+}
+
+func (m *MemoryReadCursorStore) CountReaders(conversation string, seq int64, members []string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	cursors := m.cursors[conversation]
+	if members != nil {
+		for _, uid := range members {
+			if s, ok := cursors[uid]; ok && s >= seq {
+				count++
+			}
+		}
+		return count, nil
+	}
+	for _, s := range cursors {
+		if s >= seq {
+			count++
+		}
+	}
+	return count, nil
+}
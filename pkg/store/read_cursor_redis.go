@@ -0,0 +1,94 @@
+package store
+
+import (
+	"github.com/go-redis/redis"
+	"strconv"
+)
+
+const (
+	KeyRedisReadCursorPrefix = "im:read:cursor:"
+)
+
+// maxCountReadersMembers the max count of members read by a HMGET when count the readers in members.
+const maxCountReadersMembers = 500
+
+// advanceCursorScript sets the field to ARGV[2] if it is greater than current value, returns 1 if updated.
+var advanceCursorScript = redis.NewScript(`
+local cur = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+if tonumber(ARGV[2]) > cur then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+var _ ReadCursorStore = (*RedisReadCursorStore)(nil)
+
+// RedisReadCursorStore is a ReadCursorStore backed by redis, cursors of a conversation are saved in a hash.
+type RedisReadCursorStore struct {
+	client *redis.Client
+}
+
+func NewRedisReadCursorStore(client *redis.Client) *RedisReadCursorStore {
+	return &RedisReadCursorStore{
+		client: client,
+	}
+}
+
+func (r *RedisReadCursorStore) UpdateReadCursor(uid string, conversation string, seq int64) (bool, error) {
+	updated, err := advanceCursorScript.Run(r.client, []string{KeyRedisReadCursorPrefix + conversation}, uid, seq).Int64()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+func (r *RedisReadCursorStore) GetReadCursor(uid string, conversation string) (int64, error) {
+	seq, err := r.client.HGet(KeyRedisReadCursorPrefix+conversation, uid).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return seq, err
+}
+
+func (r *RedisReadCursorStore) CountReaders(conversation string, seq int64, members []string) (int64, error) {
+	key := KeyRedisReadCursorPrefix + conversation
+	if members == nil {
+		values, err := r.client.HVals(key).Result()
+		if err != nil {
+			return 0, err
+		}
+		return countCursors(values, seq), nil
+	}
+	var count int64
+	for start := 0; start < len(members); start += maxCountReadersMembers {
+		end := start + maxCountReadersMembers
+		if end > len(members) {
+			end = len(members)
+		}
+		values, err := r.client.HMGet(key, members[start:end]...).Result()
+		if err != nil {
+			return 0, err
+		}
+		cursors := make([]string, 0, len(values))
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				cursors = append(cursors, s)
+			}
+		}
+		count += countCursors(cursors, seq)
+	}
+	return count, nil
+}
+
+// countCursors returns the count of the cursors not less than seq.
+func countCursors(values []string, seq int64) int64 {
+	var count int64
+	for _, v := range values {
+		s, err := strconv.ParseInt(v, 10, 64)
+		if err == nil && s >= seq {
+			count++
+		}
+	}
+	return count
+}
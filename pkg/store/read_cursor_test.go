@@ -0,0 +1,39 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryReadCursorStore_UpdateReadCursor(t *testing.T) {
+	s := NewMemoryReadCursorStore()
+
+	advanced, err := s.UpdateReadCursor("1", "c1", 10)
+	assert.NoError(t, err)
+	assert.True(t, advanced)
+
+	// cursor never goes back
+	advanced, err = s.UpdateReadCursor("1", "c1", 5)
+	assert.NoError(t, err)
+	assert.False(t, advanced)
+
+	seq, err := s.GetReadCursor("1", "c1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), seq)
+}
+
+func TestMemoryReadCursorStore_CountReaders(t *testing.T) {
+	s := NewMemoryReadCursorStore()
+	_, _ = s.UpdateReadCursor("1", "c1", 10)
+	_, _ = s.UpdateReadCursor("2", "c1", 20)
+	_, _ = s.UpdateReadCursor("3", "c1", 5)
+
+	count, err := s.CountReaders("c1", 10, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// the readers not in members are not counted
+	count, err = s.CountReaders("c1", 10, []string{"2", "3", "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+)
+
+var _ MessageStore = (*RecentMessageStore)(nil)
+var _ MessageHistoryStore = (*RecentMessageStore)(nil)
+var _ SubscriptionStore = (*RecentMessageStore)(nil)
+
+// RecentMessageStore keeps the recent messages in the MemoryMessageStore and writes all messages to the next store, used
+// when the next store is write only, such as the KafkaMessageStore. The mid, channel seq and revision are assigned by
+// the memory store before the message is written to the next store, the recent messages are read from the memory store
+// to recall, edit, react and query history.
+type RecentMessageStore struct {
+	recent  *MemoryMessageStore
+	next    MessageStore
+	nextSub SubscriptionStore
+}
+
+// NewRecentMessageStore returns the RecentMessageStore writes the single chat messages to next and the channel messages
+// to nextSub.
+func NewRecentMessageStore(recent *MemoryMessageStore, next MessageStore, nextSub SubscriptionStore) *RecentMessageStore {
+	return &RecentMessageStore{
+		recent:  recent,
+		next:    next,
+		nextSub: nextSub,
+	}
+}
+
+func (r *RecentMessageStore) StoreMessage(message *messages.ChatMessage) error {
+	err := r.recent.StoreMessage(message)
+	if err != nil {
+		return err
+	}
+	return r.next.StoreMessage(message)
+}
+
+func (r *RecentMessageStore) StoreOffline(message *messages.ChatMessage) error {
+	return r.next.StoreOffline(message)
+}
+
+func (r *RecentMessageStore) RecallMessage(recall *messages.Recall) error {
+	err := r.recent.RecallMessage(recall)
+	if err != nil {
+		return err
+	}
+	return r.next.RecallMessage(recall)
+}
+
+// StoreMessageRevision stores the revision in the memory store, the revision number is replaced by the next store.
+func (r *RecentMessageStore) StoreMessageRevision(edit *messages.MessageEdit) error {
+	err := r.recent.StoreMessageRevision(edit)
+	if err != nil {
+		return err
+	}
+	return r.next.StoreMessageRevision(edit)
+}
+
+func (r *RecentMessageStore) GetMessage(chatType int32, mid int64) (*messages.ChatMessage, error) {
+	return r.recent.GetMessage(chatType, mid)
+}
+
+func (r *RecentMessageStore) ListMessages(query *HistoryQuery) ([]*messages.ChatMessage, error) {
+	return r.recent.ListMessages(query)
+}
+
+func (r *RecentMessageStore) NextSegmentSequence(id subscription.ChanID, info subscription.ChanInfo) (int64, int64, error) {
+	return r.recent.NextSegmentSequence(id, info)
+}
+
+func (r *RecentMessageStore) StoreChannelMessage(ch subscription.ChanID, msg *messages.ChatMessage) error {
+	err := r.recent.StoreChannelMessage(ch, msg)
+	if err != nil {
+		return err
+	}
+	return r.nextSub.StoreChannelMessage(ch, msg)
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/glide-im/glide/pkg/subscription"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecentMessageStore(t *testing.T) {
+	next := NewMemoryMessageStore(0)
+	r := NewRecentMessageStore(NewMemoryMessageStore(10), next, next)
+
+	m := &messages.ChatMessage{From: "1", To: "2", Content: "hello"}
+	assert.NoError(t, r.StoreMessage(m))
+	assert.NotZero(t, m.Mid)
+	assert.NoError(t, r.RecallMessage(&messages.Recall{ChatType: messages.ChatTypeSingle, Mid: m.Mid}))
+
+	// the recent message is read from the memory store, the write is passed to the next store
+	got, err := r.GetMessage(messages.ChatTypeSingle, m.Mid)
+	assert.NoError(t, err)
+	assert.Equal(t, messages.MessageStatusRecalled, got.Status)
+	got, err = next.GetMessage(messages.ChatTypeSingle, m.Mid)
+	assert.NoError(t, err)
+	assert.Equal(t, messages.MessageStatusRecalled, got.Status)
+
+	seq, _, err := r.NextSegmentSequence("ch", subscription.ChanInfo{})
+	assert.NoError(t, err)
+	cm := &messages.ChatMessage{Seq: seq, From: "1", Content: "world"}
+	assert.NoError(t, r.StoreChannelMessage("ch", cm))
+	list, err := r.ListMessages(&HistoryQuery{ChatType: messages.ChatTypeChannel, To: "ch", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, cm.Mid, list[0].Mid)
+	_, err = next.GetMessage(messages.ChatTypeChannel, cm.Mid)
+	assert.NoError(t, err)
+}
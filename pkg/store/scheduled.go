@@ -0,0 +1,111 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"sort"
+	"sync"
+)
+
+var _ ScheduledMessageStore = (*MemoryScheduledMessageStore)(nil)
+
+// MemoryScheduledMessageStore is an in-memory ScheduledMessageStore, the scheduled messages are lost after restart.
+type MemoryScheduledMessageStore struct {
+	mu       sync.Mutex
+	id       int64
+	messages map[int64]*messages.ScheduledMessage
+}
+
+func NewMemoryScheduledMessageStore() *MemoryScheduledMessageStore {
+	return &MemoryScheduledMessageStore{
+		messages: map[int64]*messages.ScheduledMessage{},
+	}
+}
+
+// copyScheduled returns a copy of the scheduled message, the stored message is not shared with callers.
+func copyScheduled(m *messages.ScheduledMessage) *messages.ScheduledMessage {
+	c := *m
+	if m.Message != nil {
+		cm := *m.Message
+		c.Message = &cm
+	}
+	return &c
+}
+
+func (s *MemoryScheduledMessageStore) SaveScheduled(m *messages.ScheduledMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id++
+	c := copyScheduled(m)
+	c.ID = s.id
+	s.messages[c.ID] = c
+	return c.ID, nil
+}
+
+func (s *MemoryScheduledMessageStore) UpdateScheduled(m *messages.ScheduledMessage) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.messages[m.ID]; !ok {
+		return false, nil
+	}
+	s.messages[m.ID] = copyScheduled(m)
+	return true, nil
+}
+
+func (s *MemoryScheduledMessageStore) GetScheduled(id int64) (*messages.ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyScheduled(m), nil
+}
+
+func (s *MemoryScheduledMessageStore) TakeScheduled(id int64) (*messages.ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[id]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.messages, id)
+	return m, nil
+}
+
+func (s *MemoryScheduledMessageStore) ListScheduled(from string) ([]*messages.ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*messages.ScheduledMessage
+	for _, m := range s.messages {
+		if m.Message != nil && m.Message.From == from {
+			result = append(result, copyScheduled(m))
+		}
+	}
+	sortScheduled(result)
+	return result, nil
+}
+
+func (s *MemoryScheduledMessageStore) ListDue(before int64, limit int) ([]*messages.ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*messages.ScheduledMessage
+	for _, m := range s.messages {
+		if m.DeliverAt < before {
+			result = append(result, copyScheduled(m))
+		}
+	}
+	sortScheduled(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func sortScheduled(list []*messages.ScheduledMessage) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].DeliverAt != list[j].DeliverAt {
+			return list[i].DeliverAt < list[j].DeliverAt
+		}
+		return list[i].ID < list[j].ID
+	})
+}
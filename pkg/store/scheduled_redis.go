@@ -0,0 +1,168 @@
+package store
+
+import (
+	"github.com/glide-im/glide/pkg/messages"
+	"github.com/go-redis/redis"
+	"strconv"
+)
+
+const (
+	KeyRedisScheduledMsg        = "im:msg:scheduled"
+	KeyRedisScheduledDue        = "im:msg:scheduled:due"
+	KeyRedisScheduledID         = "im:msg:scheduled:id"
+	KeyRedisScheduledUserPrefix = "im:msg:scheduled:user:"
+)
+
+// takeScheduledScript removes the scheduled message from the hash and the due set, returns the removed message.
+// KEYS: message hash, due set; ARGV: id
+var takeScheduledScript = redis.NewScript(`
+local v = redis.call('HGET', KEYS[1], ARGV[1])
+if not v then
+	return false
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+return v
+`)
+
+// updateScheduledScript updates the scheduled message if exists.
+// KEYS: message hash, due set; ARGV: id, message, deliver_at
+var updateScheduledScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`)
+
+var _ ScheduledMessageStore = (*RedisScheduledMessageStore)(nil)
+
+// RedisScheduledMessageStore is a ScheduledMessageStore backed by redis, the messages are saved in a hash by id, and
+// indexed by a sorted set scored by the deliver time and a set of ids of each sender.
+type RedisScheduledMessageStore struct {
+	client *redis.Client
+}
+
+func NewRedisScheduledMessageStore(client *redis.Client) *RedisScheduledMessageStore {
+	return &RedisScheduledMessageStore{
+		client: client,
+	}
+}
+
+func (r *RedisScheduledMessageStore) SaveScheduled(m *messages.ScheduledMessage) (int64, error) {
+	id, err := r.client.Incr(KeyRedisScheduledID).Result()
+	if err != nil {
+		return 0, err
+	}
+	c := copyScheduled(m)
+	c.ID = id
+	bytes, err := messages.JsonCodec.Encode(c)
+	if err != nil {
+		return 0, err
+	}
+	member := strconv.FormatInt(id, 10)
+	pipe := r.client.TxPipeline()
+	pipe.HSet(KeyRedisScheduledMsg, member, string(bytes))
+	pipe.ZAdd(KeyRedisScheduledDue, redis.Z{Score: float64(c.DeliverAt), Member: member})
+	pipe.SAdd(KeyRedisScheduledUserPrefix+c.Message.From, member)
+	_, err = pipe.Exec()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *RedisScheduledMessageStore) UpdateScheduled(m *messages.ScheduledMessage) (bool, error) {
+	bytes, err := messages.JsonCodec.Encode(m)
+	if err != nil {
+		return false, err
+	}
+	keys := []string{KeyRedisScheduledMsg, KeyRedisScheduledDue}
+	n, err := updateScheduledScript.Run(r.client, keys, m.ID, string(bytes), m.DeliverAt).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisScheduledMessageStore) GetScheduled(id int64) (*messages.ScheduledMessage, error) {
+	v, err := r.client.HGet(KeyRedisScheduledMsg, strconv.FormatInt(id, 10)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeScheduled(v)
+}
+
+func (r *RedisScheduledMessageStore) TakeScheduled(id int64) (*messages.ScheduledMessage, error) {
+	keys := []string{KeyRedisScheduledMsg, KeyRedisScheduledDue}
+	v, err := takeScheduledScript.Run(r.client, keys, id).String()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := decodeScheduled(v)
+	if err != nil {
+		return nil, err
+	}
+	if m.Message != nil {
+		r.client.SRem(KeyRedisScheduledUserPrefix+m.Message.From, strconv.FormatInt(id, 10))
+	}
+	return m, nil
+}
+
+func (r *RedisScheduledMessageStore) ListScheduled(from string) ([]*messages.ScheduledMessage, error) {
+	ids, err := r.client.SMembers(KeyRedisScheduledUserPrefix + from).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return r.getAll(ids)
+}
+
+func (r *RedisScheduledMessageStore) ListDue(before int64, limit int) ([]*messages.ScheduledMessage, error) {
+	ids, err := r.client.ZRangeByScore(KeyRedisScheduledDue, redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   "(" + strconv.FormatInt(before, 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return r.getAll(ids)
+}
+
+// getAll returns the scheduled messages of ids ordered by deliver time, the ids not exist are skipped.
+func (r *RedisScheduledMessageStore) getAll(ids []string) ([]*messages.ScheduledMessage, error) {
+	values, err := r.client.HMGet(KeyRedisScheduledMsg, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var result []*messages.ScheduledMessage
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		m, err := decodeScheduled(s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	sortScheduled(result)
+	return result, nil
+}
+
+func decodeScheduled(v string) (*messages.ScheduledMessage, error) {
+	m := new(messages.ScheduledMessage)
+	err := messages.JsonCodec.Decode([]byte(v), m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
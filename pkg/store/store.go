@@ -13,6 +13,50 @@ type MessageStore interface {
 	StoreMessage(message *messages.ChatMessage) error
 
 	StoreOffline(message *messages.ChatMessage) error
+
+	// RecallMessage marks the stored message recalled.
+	RecallMessage(recall *messages.Recall) error
+
+	// StoreMessageRevision stores the edit of message as a new revision, and set the revision number to edit.Revision,
+	// the revision number must be greater than all previous revisions of the message.
+	StoreMessageRevision(edit *messages.MessageEdit) error
+}
+
+// MessagePurgeStore deletes the stored messages permanently, it is optionally implemented by the MessageStore, used to
+// purge the self-destructing messages.
+type MessagePurgeStore interface {
+
+	// PurgeMessage deletes the stored message of chatType with specified mid.
+	PurgeMessage(chatType int32, mid int64) error
+}
+
+// HistoryQuery is the query of messages in a conversation.
+type HistoryQuery struct {
+	// ChatType messages.ChatTypeSingle or messages.ChatTypeChannel
+	ChatType int32
+	// From the uid of the requester, one of the participants of single chat, the messages blocked are visible to the
+	// sender only
+	From string
+	// To the peer uid of single chat or the channel id
+	To string
+	// Cursor the mid of single chat or the seq of channel, the message at the cursor is excluded,
+	// 0 express from the latest message when query before, from the first message when query after.
+	Cursor int64
+	// After true express query messages after the cursor, otherwise before the cursor.
+	After bool
+	Limit int
+}
+
+// MessageHistoryStore is the read side of stored messages.
+type MessageHistoryStore interface {
+
+	// GetMessage returns the stored message of chatType with specified mid, the recalled and blocked message is returned
+	// with the status, returns error that IsMessageNotFound if not exist.
+	GetMessage(chatType int32, mid int64) (*messages.ChatMessage, error)
+
+	// ListMessages returns at most query.Limit messages of the conversation next to the cursor, the recalled messages
+	// and the blocked messages not sent by query.From are excluded, the result is ordered from old to new whatever the direction is.
+	ListMessages(query *HistoryQuery) ([]*messages.ChatMessage, error)
 }
 
 type SubscriptionStore interface {
@@ -23,3 +67,254 @@ type SubscriptionStore interface {
 	// StoreChannelMessage stores a published message.
 	StoreChannelMessage(ch subscription.ChanID, msg *messages.ChatMessage) error
 }
+
+// ChatSequenceStore allocates the seq of single chat messages for each conversation, the seq of a conversation is
+// increased monotonically.
+type ChatSequenceStore interface {
+
+	// NextChatSegment returns the first seq and the length of the next segment of the conversation.
+	NextChatSegment(conversation string) (int64, int64, error)
+
+	// ReturnChatSegment gives back the unused seq from next to end of the last allocated segment of the conversation,
+	// the following segment starts from next. Returns false if another segment has been allocated after it, the unused
+	// seq are skipped then.
+	ReturnChatSegment(conversation string, next int64, end int64) (bool, error)
+}
+
+// ChannelAckStore stores the acked message seq of each member in channels, it is optionally implemented by the
+// SubscriptionStore.
+type ChannelAckStore interface {
+
+	// UpdateMemberAck advances the acked seq of the member in the channel to seq,
+	// returns false if the seq is not greater than current acked seq.
+	UpdateMemberAck(ch subscription.ChanID, member subscription.SubscriberID, seq int64) (bool, error)
+
+	// GetMemberAck returns the acked seq of the member in the channel, 0 if never acked.
+	GetMemberAck(ch subscription.ChanID, member subscription.SubscriberID) (int64, error)
+
+	// CountAcked returns the count of the current members whose acked seq in the channel is not less than seq, the
+	// acks of the users not in members, such as who left the channel, are not counted.
+	CountAcked(ch subscription.ChanID, seq int64, members []string) (int64, error)
+}
+
+// ReadCursorStore stores the read cursor, the last read message seq, of users in conversations.
+type ReadCursorStore interface {
+
+	// UpdateReadCursor advances the read cursor of uid in the conversation to seq,
+	// returns false if the seq is not greater than current cursor.
+	UpdateReadCursor(uid string, conversation string, seq int64) (bool, error)
+
+	// GetReadCursor returns the read cursor of uid in the conversation, 0 if never read.
+	GetReadCursor(uid string, conversation string) (int64, error)
+
+	// CountReaders returns the count of users whose read cursor in the conversation is not less than seq, only the users
+	// in members are counted if members is not nil, such as the current members of a channel.
+	CountReaders(conversation string, seq int64, members []string) (int64, error)
+}
+
+// OfflineStore stores the offline messages of users, the offline messages are kept until the user acknowledged.
+type OfflineStore interface {
+
+	// AppendOffline appends the message to the offline messages of uid, returns the cursor of the message,
+	// the cursor is increasing in the offline messages of a user.
+	AppendOffline(uid string, message *messages.ChatMessage) (int64, error)
+
+	// RangeOffline returns at most limit offline messages of uid after the cursor, ordered by cursor.
+	RangeOffline(uid string, cursor int64, limit int) ([]*messages.OfflineMessage, error)
+
+	// AckOffline removes the offline messages of uid up to the cursor, returns the removed count.
+	AckOffline(uid string, cursor int64) (int64, error)
+
+	// CountOffline returns the count of offline messages of uid.
+	CountOffline(uid string) (int64, error)
+}
+
+// ConversationStore stores the recent conversations of users.
+type ConversationStore interface {
+
+	// UpdateConversation updates the last message and the activity time of the conversation of uid, the conversation is
+	// created if not exist, the Unread, Pinned and Muted of conv are ignored, the unread count increases if unread is true.
+	UpdateConversation(uid string, conv *messages.Conversation, unread bool) error
+
+	// ClearUnread resets the unread count of the conversation of uid.
+	ClearUnread(uid string, chatType int32, id string) error
+
+	// SetConversationFlags sets the pinned and muted flags of the conversation of uid, the nil flag is kept, the
+	// conversation is created if not exist.
+	SetConversationFlags(uid string, chatType int32, id string, pinned *bool, muted *bool) error
+
+	// ListConversations returns the conversations of uid, the pinned conversations first, then ordered by the activity time desc.
+	ListConversations(uid string, offset int, limit int) ([]*messages.Conversation, error)
+}
+
+// ConversationBatchStore is an optional interface of ConversationStore, updates the conversations of many users at once,
+// used to update the conversations of channel members.
+type ConversationBatchStore interface {
+
+	// UpdateConversations updates the conversation of each uid as UpdateConversation, the unread count increases except
+	// for the sender.
+	UpdateConversations(uids []string, conv *messages.Conversation, sender string) error
+}
+
+// ConversationBadgeStore is an optional interface of ConversationStore, used to compute the badge of offline push.
+type ConversationBadgeStore interface {
+
+	// GetConversation returns the conversation of uid, nil if not exist.
+	GetConversation(uid string, chatType int32, id string) (*messages.Conversation, error)
+
+	// TotalUnread returns the sum of the unread count of all conversations of uid.
+	TotalUnread(uid string) (int64, error)
+}
+
+// PushSettingStore stores the offline push settings of users.
+type PushSettingStore interface {
+
+	// SetPushSetting sets the push setting of uid.
+	SetPushSetting(uid string, s *messages.PushSetting) error
+
+	// GetPushSetting returns the push setting of uid, the zero setting if not set.
+	GetPushSetting(uid string) (*messages.PushSetting, error)
+}
+
+// PresenceStore stores the presence of users and the presence subscriptions, shared by all gateway nodes.
+type PresenceStore interface {
+
+	// SetDeviceState sets the state of the device of uid, messages.PresenceOffline removes the device, and updates the
+	// last seen time of the user when the last device removed.
+	SetDeviceState(uid string, device string, state int32) error
+
+	// RefreshDevice refreshes the active time of the device of uid, returns false if the device is not exist or expired.
+	// The device not refreshed in PresenceDeviceTTL is treated as offline, so that the devices of the crashed nodes
+	// are not online forever.
+	RefreshDevice(uid string, device string) (bool, error)
+
+	// GetPresence returns the presence of uid aggregated from all devices.
+	GetPresence(uid string) (*messages.Presence, error)
+
+	// ExpireDevices removes the devices not refreshed in PresenceDeviceTTL, returns the uids whose last device expired
+	// and updates the last seen time of them, so that the offline of the users without heartbeat is notified. Each uid
+	// is returned to only one of the callers.
+	ExpireDevices() ([]string, error)
+
+	// SetStatusText sets the status text of uid.
+	SetStatusText(uid string, text string) error
+
+	// SetPrivacy sets who can see the presence of uid, the allowList is used when privacy is messages.PresencePrivacyAllowList.
+	SetPrivacy(uid string, privacy int32, allowList []string) error
+
+	// GetPrivacy returns the privacy and the allow list of uid.
+	GetPrivacy(uid string) (int32, []string, error)
+
+	// AddWatching adds the uids to the watching list of watcher, and adds watcher to the watchers of each uid.
+	AddWatching(watcher string, uids ...string) error
+
+	// RemoveWatching removes the uids from the watching list of watcher, and removes watcher from the watchers of each uid.
+	RemoveWatching(watcher string, uids ...string) error
+
+	// GetWatching returns the uids watched by watcher.
+	GetWatching(watcher string) ([]string, error)
+
+	// GetWatchers returns the users who are watching the uid.
+	GetWatchers(uid string) ([]string, error)
+}
+
+// PresenceEvent is the change of the presence published to all gateway nodes.
+type PresenceEvent struct {
+	Presence *messages.Presence `json:"presence"`
+	// Refresh true express the visibility of the presence changed, the watchers can not see it are notified too.
+	Refresh bool `json:"refresh,omitempty"`
+}
+
+// PresencePublisher is optionally implemented by the PresenceStore shared by gateway nodes, the presence changes are
+// published to all nodes, so that the watchers connected to any node are notified.
+type PresencePublisher interface {
+
+	// PublishPresence publishes the event to all nodes, include the current node.
+	PublishPresence(e *PresenceEvent) error
+
+	// SubscribePresence calls fn with the events published by all nodes in a new goroutine.
+	SubscribePresence(fn func(e *PresenceEvent)) error
+}
+
+// ScheduledMessageStore stores the scheduled messages until delivered or cancelled.
+type ScheduledMessageStore interface {
+
+	// SaveScheduled saves the scheduled message, returns the assigned id.
+	SaveScheduled(m *messages.ScheduledMessage) (int64, error)
+
+	// UpdateScheduled updates the scheduled message with the same id, returns false if it is not exist.
+	UpdateScheduled(m *messages.ScheduledMessage) (bool, error)
+
+	// GetScheduled returns the scheduled message, nil if it is not exist.
+	GetScheduled(id int64) (*messages.ScheduledMessage, error)
+
+	// TakeScheduled removes and returns the scheduled message, nil if it is not exist, only one of the concurrent
+	// callers gets the message, used to claim the message to deliver or cancel.
+	TakeScheduled(id int64) (*messages.ScheduledMessage, error)
+
+	// ListScheduled returns the scheduled messages of the sender.
+	ListScheduled(from string) ([]*messages.ScheduledMessage, error)
+
+	// ListDue returns the scheduled messages deliver before the time, order by deliver time.
+	ListDue(before int64, limit int) ([]*messages.ScheduledMessage, error)
+}
+
+// ExpiringMessage the self-destructing message waiting for expiry.
+type ExpiringMessage struct {
+	ChatType int32
+	Mid      int64
+	From     string
+	To       string
+	// ExpireAt the unix time in seconds the message expires
+	ExpireAt int64
+	// BurnAfterRead the message expires once the receiver read it
+	BurnAfterRead bool
+}
+
+// MessageExpiryStore stores the self-destructing messages until expired.
+type MessageExpiryStore interface {
+
+	// AddExpiring adds the message waiting for expiry.
+	AddExpiring(m *ExpiringMessage) error
+
+	// GetExpiring returns the message waiting for expiry, nil if it is not exist.
+	GetExpiring(chatType int32, mid int64) (*ExpiringMessage, error)
+
+	// TakeExpiring removes and returns the message, nil if it is not exist, only one of the concurrent callers gets
+	// the message.
+	TakeExpiring(chatType int32, mid int64) (*ExpiringMessage, error)
+
+	// ListExpired returns the messages expire before the time.
+	ListExpired(before int64, limit int) ([]*ExpiringMessage, error)
+}
+
+// BlockStore stores the block lists of users.
+type BlockStore interface {
+
+	// Block adds the users to the block list of uid.
+	Block(uid string, blocked ...string) error
+
+	// Unblock removes the users from the block list of uid.
+	Unblock(uid string, blocked ...string) error
+
+	// IsBlocked returns true if uid has blocked other.
+	IsBlocked(uid string, other string) (bool, error)
+
+	// GetBlocked returns the block list of uid.
+	GetBlocked(uid string) ([]string, error)
+}
+
+// ReactionStore stores the reactions of messages.
+type ReactionStore interface {
+
+	// AddReaction adds the reaction of uid to the message, returns false if reacted before, returns error that
+	// IsReactionLimit if the user has reacted limit distinct emojis to the message.
+	AddReaction(chatType int32, mid int64, uid string, emoji string, limit int) (bool, error)
+
+	// RemoveReaction removes the reaction of uid from the message, returns false if not reacted.
+	RemoveReaction(chatType int32, mid int64, uid string, emoji string) (bool, error)
+
+	// GetReactions returns the aggregated reactions of the messages by mid, each summary contains at most users
+	// earliest reacted, the summaries are ordered by count descending.
+	GetReactions(chatType int32, users int, mids ...int64) (map[int64][]*messages.ReactionSummary, error)
+}
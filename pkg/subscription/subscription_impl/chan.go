@@ -55,22 +55,27 @@ type SubscriberInfo struct {
 	Perm Permission
 }
 
-func (i *SubscriberInfo) canRead() bool {
+func (i *SubscriberInfo) CanRead() bool {
 	return i.Perm.allows(MaskPermRead)
 }
 
-func (i *SubscriberInfo) canWrite() bool {
+func (i *SubscriberInfo) CanWrite() bool {
 	return i.Perm.allows(MaskPermWrite)
 }
 
-func (i *SubscriberInfo) isSystem() bool {
+func (i *SubscriberInfo) IsSystem() bool {
 	return i.Perm.allows(MaskPermSystem)
 }
 
-func (i *SubscriberInfo) isAdmin() bool {
+func (i *SubscriberInfo) IsAdmin() bool {
 	return i.Perm.allows(MaskPermAdmin)
 }
 
+// IsBot returns true if the subscriber is a bot of the channel, the commands in the channel are delivered to it.
+func (i *SubscriberInfo) IsBot() bool {
+	return i.Perm.allows(MaskPermBot)
+}
+
 func (i *SubscriberInfo) update(options *SubscriberOptions) error {
 	i.Perm = options.Perm
 	return nil
@@ -143,6 +148,18 @@ func (g *Channel) GetSubscribers() []string {
 	return result
 }
 
+// GetSubscriber returns the copy of subscriber info of the specified subscriber.
+func (g *Channel) GetSubscriber(id subscription.SubscriberID) (*SubscriberInfo, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	s, ok := g.subscribers[id]
+	if !ok {
+		return nil, errors.New(subscription.ErrNotSubscribed)
+	}
+	info := *s
+	return &info, nil
+}
+
 func (g *Channel) Subscribe(id subscription.SubscriberID, extra interface{}) error {
 	so, err := getSubscriberOptions(extra)
 	if err != nil {
@@ -256,16 +273,17 @@ func (g *Channel) Publish(msg subscription.Message) error {
 	if !exist {
 		return errors.New(errNotMemberOfChannel)
 	}
-	if !s.canWrite() {
+	// the notifications of members, like reactions and read receipts, are allowed for the readers
+	if message.Type == TypeNotify && !s.CanRead() || message.Type != TypeNotify && !s.CanWrite() {
 		return errors.New(errPermissionDeniedWrite)
 	}
 	if g.info.Muted {
-		if !s.isSystem() || !s.isAdmin() {
+		if !s.IsSystem() || !s.IsAdmin() {
 			return errors.New(errChannelMuted)
 		}
 	}
 	if g.info.Blocked {
-		if !s.isSystem() {
+		if !s.IsSystem() {
 			return errors.New(errChannelBlocked)
 		}
 	}
@@ -316,14 +334,16 @@ func (g *Channel) enqueue(m *PublishMessage) error {
 		return err
 	}
 	cm.Seq = m.Seq
-	m.Message.Data = messages.NewData(&cm)
+	cm.SendAt = time.Now().Unix()
 
 	if m.Type == TypeMessage {
+		// the store assigns the mid of the message, the mid is delivered to subscribers and used to recall, edit and react
 		err = g.store.StoreChannelMessage(g.id, cm)
 		if err != nil {
 			return errors2.Wrap(err, "store channel message error")
 		}
 	}
+	m.Message.Data = messages.NewData(cm)
 
 	select {
 	case g.messages <- m:
@@ -417,7 +437,7 @@ func (g *Channel) push(message *PublishMessage) {
 				continue
 			}
 		}
-		if !sInfo.canRead() {
+		if !sInfo.CanRead() {
 			continue
 		}
 		err := g.gate.EnqueueMessage(gate.NewID2(string(subscriberID)), message.Message)
@@ -48,6 +48,10 @@ func (m mockGate) GetAll() map[gate.ID]gate.Info {
 	panic("implement me")
 }
 
+func (m mockGate) IsOnline(id gate.ID) bool {
+	return false
+}
+
 func (m mockGate) SetMessageHandler(h gate.MessageHandler) {
 	//TODO implement me
 	panic("implement me")
@@ -7,6 +7,7 @@ const (
 	MaskPermWrite           = 1 << iota
 	MaskPermAdmin           = 1 << iota
 	MaskPermSystem          = 1 << iota
+	MaskPermBot             = 1 << iota
 )
 
 const (
@@ -14,6 +15,7 @@ const (
 	PermRead  Permission = 1 << MaskPermRead
 	PermWrite Permission = 1 << MaskPermWrite
 	PermAdmin Permission = 1 << MaskPermAdmin
+	PermBot   Permission = 1 << MaskPermBot
 )
 
 type Permission int64
@@ -9,6 +9,18 @@ import (
 )
 
 var _ subscription.Subscribe = (*subscriptionImpl)(nil)
+var _ SubscriberQuery = (*subscriptionImpl)(nil)
+
+// SubscriberQuery provides the subscriber information of channels, implemented by the subscription.Subscribe
+// created by NewSubscription.
+type SubscriberQuery interface {
+
+	// GetSubscriber returns the info of specified subscriber in the channel.
+	GetSubscriber(ch subscription.ChanID, id subscription.SubscriberID) (*SubscriberInfo, error)
+
+	// GetSubscribers returns the id of all subscribers in the channel.
+	GetSubscribers(ch subscription.ChanID) ([]string, error)
+}
 
 type subscriptionImpl struct {
 	unwrap *realSubscription
@@ -78,6 +90,14 @@ func (s *subscriptionImpl) PublishMessage(id subscription.ChanID, message subscr
 	return s.unwrap.Publish(id, message)
 }
 
+func (s *subscriptionImpl) GetSubscriber(ch subscription.ChanID, id subscription.SubscriberID) (*SubscriberInfo, error) {
+	return s.unwrap.GetSubscriber(ch, id)
+}
+
+func (s *subscriptionImpl) GetSubscribers(ch subscription.ChanID) ([]string, error) {
+	return s.unwrap.GetSubscribers(ch)
+}
+
 func (s *subscriptionImpl) SetGateInterface(g gate.DefaultGateway) {
 	s.unwrap.gate = g
 }
@@ -135,6 +155,36 @@ func (u *realSubscription) UpdateSubscriber(chID subscription.ChanID, id subscri
 	return ch.Subscribe(id, update)
 }
 
+func (u *realSubscription) GetSubscriber(chID subscription.ChanID, id subscription.SubscriberID) (*SubscriberInfo, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	ch, ok := u.channels[chID]
+	if !ok {
+		return nil, errors.New(subscription.ErrChanNotExist)
+	}
+	c, ok := ch.(*Channel)
+	if !ok {
+		return nil, errors.New("unexpected channel type")
+	}
+	return c.GetSubscriber(id)
+}
+
+func (u *realSubscription) GetSubscribers(chID subscription.ChanID) ([]string, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	ch, ok := u.channels[chID]
+	if !ok {
+		return nil, errors.New(subscription.ErrChanNotExist)
+	}
+	c, ok := ch.(*Channel)
+	if !ok {
+		return nil, errors.New("unexpected channel type")
+	}
+	return c.GetSubscribers(), nil
+}
+
 func (u *realSubscription) RemoveChannel(chID subscription.ChanID) error {
 	u.mu.Lock()
 	defer u.mu.Unlock()